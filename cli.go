@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"video-factory-backend/pkg/pipeline"
+)
+
+// --- RENDER CLI ---
+// `vixio render --topic ... --scenes scenes.json --out video.mp4` renders one
+// video locally through the Pipeline, which calls the server's multi-scene
+// generator directly. scenes.json is the scenes form value, where each scene may
+// also name local files:
+//
+//	[{"name": "Dune", "details": "...", "media": "dune.jpg", "greenscreen": "host.mp4"}]
+//
+// Relative paths are resolved against the scenes file.
+
+// formValues collects repeated --set key=value flags.
+type formValues map[string]string
+
+func (f formValues) String() string { return fmt.Sprint(map[string]string(f)) }
+
+func (f formValues) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	f[key] = value
+	return nil
+}
+
+func runRenderCLI(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	topic := fs.String("topic", "", "video topic")
+	category := fs.String("category", "", "category, e.g. movie")
+	videoType := fs.String("type", "short", "short or long")
+	scenesPath := fs.String("scenes", "", "scenes JSON file")
+	intro := fs.String("intro", "", "intro media file")
+	outro := fs.String("outro", "", "outro media file")
+	out := fs.String("out", "video.mp4", "where to write the video")
+	extra := formValues{}
+	fs.Var(extra, "set", "extra form field as key=value (repeatable), e.g. --set look=cinematic")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *topic == "" || *scenesPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: vixio render --topic TOPIC --scenes scenes.json [--out video.mp4] [--set key=value ...]")
+		return 2
+	}
+
+	data, err := os.ReadFile(*scenesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	var scenes []pipeline.SceneSpec
+	if err := json.Unmarshal(data, &scenes); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s: %v\n", *scenesPath, err)
+		return 1
+	}
+
+	dir := filepath.Dir(*scenesPath)
+	for i := range scenes {
+		scenes[i].Media = resolveCLIPath(dir, scenes[i].Media)
+		scenes[i].Greenscreen = resolveCLIPath(dir, scenes[i].Greenscreen)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	result, err := (&pipeline.Pipeline{}).Generate(context.Background(), pipeline.Spec{
+		Topic: *topic, Category: *category, Type: *videoType,
+		Scenes: scenes, Intro: *intro, Outro: *outro, Options: extra,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := pipeline.CopyFile(result.VideoPath, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Wrote %s (job %s)\n", *out, result.JobID)
+	return 0
+}
+
+func resolveCLIPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
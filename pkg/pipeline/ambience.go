@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --- AMBIENCE ---
+// ambience=room|vinyl|hum lays a quiet bed under a video that ends up with
+// no music, so the gaps between sentences aren't dead digital silence:
+//
+//	room   soft brown-noise room tone
+//	vinyl  record surface noise with the odd crackle
+//	hum    lo-fi mains hum and tape hiss
+//
+// The beds are generated by ffmpeg, so nothing has to be installed, and are
+// mixed in the finishing pass where music would be, at ambience_volume
+// (0-1, default 0.5 of an already quiet bed). A brand kit or script
+// template can set it for all its videos; music, chosen or picked for the
+// mood, always replaces it.
+var ambienceBeds = map[string]string{
+	"room":  "anoisesrc=color=brown:amplitude=0.06:seed=7,highpass=f=60,lowpass=f=500",
+	"vinyl": "aevalsrc=exprs='0.004*(random(0)-0.5)+if(lt(random(1),0.0006),0.25*(random(2)-0.5),0)':s=44100,highpass=f=800,lowpass=f=7000",
+	"hum":   "aevalsrc=exprs='0.01*sin(2*PI*60*t)+0.004*sin(2*PI*120*t)+0.002*(random(0)-0.5)':s=44100,lowpass=f=2000",
+}
+
+const defaultAmbienceVolume = 0.5
+
+func checkAmbience(name string) error {
+	if _, ok := ambienceBeds[name]; name != "" && name != "none" && !ok {
+		return fmt.Errorf("unknown ambience %q (use room, vinyl or hum)", name)
+	}
+	return nil
+}
+
+// ambienceFromForm reads ambience, falling back to the script template's.
+// renderOptionsFromForm has already rejected an unknown bed.
+func ambienceFromForm(f Form) (string, float64) {
+	name := strings.ToLower(strings.TrimSpace(f.PostForm("ambience")))
+	if id := strings.ToLower(strings.TrimSpace(f.PostForm("script_template"))); name == "" && id != "" {
+		if t, err := loadScriptTemplate(id); err == nil {
+			name = t.Ambience
+		}
+	}
+	if checkAmbience(name) != nil || name == "none" {
+		name = ""
+	}
+	volume := defaultAmbienceVolume
+	if v, err := strconv.ParseFloat(f.PostForm("ambience_volume"), 64); err == nil && v >= 0 && v <= 1 {
+		volume = v
+	}
+	return name, volume
+}
+
+func mixAmbience(path string, opts FinishOptions) error {
+	return mixBed(path, "ambience", opts.AmbienceVolume, func(tl *Timeline) int {
+		return tl.Input(ambienceBeds[opts.Ambience], "-f", "lavfi")
+	}, opts.Mastering)
+}
@@ -0,0 +1,342 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+)
+
+// --- ARTICLE / MARKDOWN MODE ---
+// The page and its images come from a caller-supplied URL and are fetched
+// through publicClient.
+const (
+	maxArticleChars = 12000
+	maxArticleBytes = 5 << 20 // HTML read from the page
+)
+
+type Article struct {
+	Title  string
+	Text   string
+	Images []string
+}
+
+type articleScript struct {
+	Intro string `json:"intro"`
+	Items []struct {
+		Title      string `json:"title"`
+		Details    string `json:"details"`
+		ImageQuery string `json:"image_query"`
+	} `json:"items"`
+	Outro string `json:"outro"`
+	Mood  string `json:"mood"`
+}
+
+func handleArticleVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Article Received")
+
+	topic := r.PostForm("topic")
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sources, err := mediaSourcesFromForm(r, queryMediaSources)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var article Article
+	var credit Source
+	if pageUrl := strings.TrimSpace(r.PostForm("url")); pageUrl != "" {
+		article, err = fetchArticle(pageUrl)
+		if err != nil {
+			r.JSON(422, gin.H{"error": "Could not fetch article: " + err.Error()})
+			return
+		}
+		credit = articleSource(pageUrl, article.Title)
+		job.AddSource("", credit)
+	} else if md := r.PostForm("markdown"); strings.TrimSpace(md) != "" {
+		article = parseMarkdown(md)
+	} else {
+		r.JSON(400, gin.H{"error": "Provide either url or markdown"})
+		return
+	}
+	if topic == "" {
+		topic = article.Title
+	}
+	if !checkPolicy(r, article.Title+"\n"+article.Text) {
+		return
+	}
+	if len(article.Text) < 200 {
+		r.JSON(422, gin.H{"error": "Article has too little text to summarize"})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Summarizing Article (Groq)...")
+	job.Stage("script")
+	scriptData, queries, err := summarizeArticle(job, topic, article.Text, videoType, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Sections: %d\n", topic, videoType, len(scriptData.Items))
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	factCheck, ok := runFactCheck(r, job, topic, scriptData)
+	if !ok {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	// Article images go first, the media source chain fills the rest.
+	images := article.Images
+	nextImage := func(query, fallback, context string, index int) (string, error) {
+		for len(images) > 0 {
+			src := images[0]
+			images = images[1:]
+			if path, err := job.Fetch("url:"+src, ".jpg", gated(func(dest string) error {
+				return downloadPublicFile(src, dest)
+			})); err == nil {
+				job.AddSource(path, credit)
+				return path, nil
+			}
+		}
+		return resolveMedia(r, job, sources, MediaRequest{Query: query, Text: fallback, Context: context, Index: index}, videoType, base.Card)
+	}
+
+	introPath, err := nextImage(topic, topic, scriptData.Intro, 0)
+	scenePaths := make([]string, len(scriptData.Items))
+	for i, item := range scriptData.Items {
+		if err == nil {
+			scenePaths[i], err = nextImage(queries[i], item.Title, item.Title+". "+item.Details, i+1)
+		}
+	}
+	if err != nil {
+		r.JSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	fmt.Println("🔹 STEP 3: Rendering Segments...")
+	job.Stage("render")
+	offerPreview(r, job, scriptData, introPath, scenePaths, outroPath, base, nil)
+	segments, err := renderScript(job, scriptData, introPath, scenePaths, outroPath, base, nil)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (TTS): %v\n", err)
+		r.JSON(502, gin.H{"error": "Narration incomplete: " + err.Error(), "narration_lost": job.LostNarration()})
+		return
+	}
+	segments = withSting(segments, base)
+
+	fmt.Println("🔹 STEP 4: Stitching Video...")
+	job.Stage("stitch")
+	finalVideo := job.Path("final_article.mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	offerStream(r, job, finalVideo)
+	finish := finishOptionsFromForm(r, job, videoType)
+	applyMood(job, &finish, scriptData.Mood)
+	finish.Title, finish.Chapters = topic, segmentChapters(segments)
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Article Video Ready.")
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": job.URL(r.base, finalVideo), "stitch": stitch,
+		"narration_url": narrationURL(r.base, job, finish), "thumbnails": thumbnailURLs(r.base, job, finish),
+		"teaser_url": teaserURL(r.base, job, finish), "size_budget": finish.SizeBudget, "fact_check": factCheck,
+		"output": output, "segments": segmentInfo, "script": scriptData,
+		"chapters": finish.Chapters, "chapters_text": youtubeChapters(finish.Chapters)})
+}
+
+func summarizeArticle(job *Job, topic, text, videoType string, opts ScriptOptions) (ScriptResponse, []string, error) {
+	if len(text) > maxArticleChars {
+		text = strings.ToValidUTF8(text[:maxArticleChars], "")
+	}
+	minWords, maxWords := wordRange(videoType)
+	maxItems := 5
+	if videoType == "long" {
+		maxItems = 8
+	}
+
+	result, err := generateForAudience(opts, func(audience string) (ScriptResponse, error) {
+		prompt := fmt.Sprintf(`
+    Topic: "%s" (%s mode)
+    Tone: Engaging and professional.%s
+    Summarize the article below into at most %d key sections for a narrated video.
+    Constraint: Each item must be between %d and %d words to ensure duration.
+    For each item also give a concrete stock-photo search query for a fitting image.
+    ARTICLE:
+    %s
+    RETURN JSON ONLY:
+    {
+        "intro": "Hook around 35 words",
+        "items": [
+            { "title": "Title", "details": "Script text between %d and %d words...", "image_query": "search query" }
+        ],
+        "outro": "Conclusion around 35 words",
+        "mood": "one of: %s"
+    }
+    `, topic, videoType, audience, maxItems, minWords, maxWords, text, minWords, maxWords, strings.Join(musicMoods, ", "))
+
+		var raw articleScript
+		if err := completeTemplated(job, "article_summary", opts.Template, prompt, &raw); err != nil {
+			return ScriptResponse{}, err
+		}
+		if len(raw.Items) == 0 {
+			return ScriptResponse{}, fmt.Errorf("no sections returned")
+		}
+
+		result := ScriptResponse{Intro: raw.Intro, Outro: raw.Outro, Mood: raw.Mood}
+		for _, it := range raw.Items {
+			query := it.ImageQuery
+			if query == "" {
+				query = it.Title
+			}
+			result.Items = append(result.Items, ScriptItem{Title: it.Title, Details: it.Details, query: query})
+		}
+		return result, nil
+	})
+	if err != nil {
+		return ScriptResponse{}, nil, err
+	}
+	queries := make([]string, len(result.Items))
+	for i, it := range result.Items {
+		queries[i] = it.query
+	}
+	return result, queries, nil
+}
+
+// fetchArticle downloads a web page and extracts its title, readable text and images.
+func fetchArticle(pageUrl string) (Article, error) {
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return Article{}, fmt.Errorf("invalid url")
+	}
+	if err := checkPublicURL(pageUrl); err != nil {
+		return Article{}, err
+	}
+	req, _ := http.NewRequest("GET", pageUrl, nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	resp, err := publicClient.Do(req)
+	if err != nil {
+		return Article{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return Article{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxArticleBytes))
+	if err != nil {
+		return Article{}, err
+	}
+
+	var article Article
+	var text strings.Builder
+	addImage := func(src string) {
+		if ref, err := base.Parse(src); err == nil && src != "" {
+			article.Images = append(article.Images, ref.String())
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "nav", "header", "footer", "aside", "noscript":
+				return
+			case "title":
+				if n.FirstChild != nil && article.Title == "" {
+					article.Title = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case "meta":
+				if attr(n, "property") == "og:image" {
+					addImage(attr(n, "content"))
+				}
+			case "img":
+				addImage(attr(n, "src"))
+			case "p", "h1", "h2", "h3", "li":
+				text.WriteString("\n")
+			}
+		}
+		if n.Type == html.TextNode {
+			if t := strings.TrimSpace(n.Data); t != "" {
+				text.WriteString(t + " ")
+			}
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+	}
+	walk(doc)
+
+	article.Text = strings.TrimSpace(text.String())
+	if len(article.Images) > 10 {
+		article.Images = article.Images[:10]
+	}
+	return article, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+var (
+	mdImage   = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)[^)]*\)`)
+	mdLink    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdHeading = regexp.MustCompile(`(?m)^#{1,6}\s*(.*)$`)
+	mdMarkup  = regexp.MustCompile("[*_`>~]+")
+)
+
+// parseMarkdown reduces markdown to plain text, keeping the first heading as title.
+func parseMarkdown(md string) Article {
+	var article Article
+	for _, m := range mdImage.FindAllStringSubmatch(md, 10) {
+		if strings.HasPrefix(m[1], "http") {
+			article.Images = append(article.Images, m[1])
+		}
+	}
+	text := mdImage.ReplaceAllString(md, "")
+	text = mdLink.ReplaceAllString(text, "$1")
+	if m := mdHeading.FindStringSubmatch(text); m != nil {
+		article.Title = strings.TrimSpace(mdMarkup.ReplaceAllString(m[1], ""))
+	}
+	text = mdHeading.ReplaceAllString(text, "$1.")
+	article.Text = strings.TrimSpace(mdMarkup.ReplaceAllString(text, ""))
+	return article
+}
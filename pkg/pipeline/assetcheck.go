@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// --- ASSET CHECKS ---
+// A download that failed quietly (a 404 page, a JSON error, a connection
+// cut halfway) used to be saved as the scene's "image" and only surfaced
+// as a cryptic ffmpeg error at render time. downloadFile now rejects
+// non-2xx answers, bodies that are text rather than media, and short
+// reads, and every file it writes must decode: JPEG and PNG fully, anything
+// else (videos, WebP, GIF) must show ffprobe a video stream. A rejected
+// download fails its fetch, so the next candidate or media source is
+// tried. Media that still can't be read when its segment renders (an empty
+// upload, say) is swapped for a placeholder card up front, reported as the
+// still_image fallback.
+
+// checkDownload rejects error responses and non-media bodies.
+func checkDownload(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("download status %d", resp.StatusCode)
+	}
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	if ct == "" {
+		return nil
+	}
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/octet-stream", "binary/octet-stream"} {
+		if strings.HasPrefix(ct, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("download is %s, not media", ct)
+}
+
+// verifyAsset reports whether path is a non-empty, readable image or video.
+func verifyAsset(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("%s is empty", filepath.Base(path))
+	}
+	if !isVideoFile(path) {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, format, err := image.Decode(f)
+		f.Close()
+		if err == nil {
+			return nil
+		}
+		if format != "" {
+			return fmt.Errorf("%s is a broken image: %v", filepath.Base(path), err)
+		}
+	}
+	// Videos and image formats Go can't read.
+	out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width", "-of", "csv=p=0", path).Output()
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		return fmt.Errorf("%s is not a readable image or video", filepath.Base(path))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- ASSET LIBRARY ---
+// Uploaded and fetched media are stored once under their SHA-256, so the
+// same poster or upload used by many jobs shares a single file. Each asset
+// has a small JSON sidecar describing where it came from and which callers
+// stored it (hashes of their X-Api-Key); GET /assets lists only the
+// caller's own, and only they can reuse it by ID.
+const assetsDir = "output/assets"
+
+var assetsMu sync.Mutex // sidecar updates
+
+type Asset struct {
+	ID      string    `json:"id"` // hex SHA-256 of the content
+	Ext     string    `json:"ext"`
+	Kind    string    `json:"kind"`   // image, video, audio or other
+	Source  string    `json:"source"` // upload, tmdb, stock, ai, card, url
+	Label   string    `json:"label,omitempty"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+	URL     string    `json:"url,omitempty"`
+	Owners  []string  `json:"owners,omitempty"` // see keyOwner
+}
+
+// keyOwner identifies a caller by its X-Api-Key without storing the key.
+func keyOwner(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// storeAsset moves the file at path into the library and returns the
+// stored path. If the content is already there the new copy is dropped and
+// owner is added to the existing asset.
+func storeAsset(path, source, label, owner string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(h.Sum(nil))
+	ext := safeExt(path, "")
+	dest := filepath.Join(assetsDir, id+ext)
+
+	os.MkdirAll(assetsDir, 0755)
+	assetsMu.Lock()
+	defer assetsMu.Unlock()
+	if _, err := os.Stat(dest); err == nil {
+		os.Remove(path)
+		if a, err := readAsset(id); err == nil && owner != "" && !slices.Contains(a.Owners, owner) {
+			a.Owners = append(a.Owners, owner)
+			writeAsset(a)
+		}
+		return dest, nil
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+	a := Asset{ID: id, Ext: ext, Kind: assetKind(ext), Source: source, Label: label, Size: size, Created: time.Now().UTC()}
+	if owner != "" {
+		a.Owners = []string{owner}
+	}
+	writeAsset(a)
+	return dest, nil
+}
+
+func writeAsset(a Asset) {
+	meta, _ := json.Marshal(a)
+	os.WriteFile(filepath.Join(assetsDir, a.ID+".json"), meta, 0644)
+}
+
+// assetPath resolves a library ID to its file, for requests reusing an
+// asset. Only the callers who stored it (owner, see keyOwner) may reuse it;
+// to anyone else it is not found.
+func assetPath(id, owner string) (string, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if _, err := hex.DecodeString(id); err != nil || len(id) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid asset id")
+	}
+	a, err := readAsset(id)
+	if err != nil || owner == "" || !slices.Contains(a.Owners, owner) {
+		return "", fmt.Errorf("asset %s not found", id)
+	}
+	return filepath.Join(assetsDir, id+a.Ext), nil
+}
+
+func readAsset(id string) (Asset, error) {
+	var a Asset
+	data, err := os.ReadFile(filepath.Join(assetsDir, id+".json"))
+	if err != nil {
+		return a, err
+	}
+	err = json.Unmarshal(data, &a)
+	return a, err
+}
+
+func assetKind(ext string) string {
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+		return "image"
+	case ".mp3", ".wav", ".m4a", ".aac", ".ogg", ".flac":
+		return "audio"
+	}
+	if isVideoFile(ext) {
+		return "video"
+	}
+	return "other"
+}
+
+// GET /assets?kind=image&source=upload&limit=50&offset=0, newest first:
+// the assets stored by requests with the caller's X-Api-Key.
+func handleListAssets(c *gin.Context) {
+	owner := keyOwner(c.GetHeader("X-Api-Key"))
+	if owner == "" {
+		c.JSON(401, gin.H{"error": "X-Api-Key header is required"})
+		return
+	}
+	kind, source := c.Query("kind"), c.Query("source")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	offset = max(0, offset)
+
+	metas, _ := filepath.Glob(filepath.Join(assetsDir, "*.json"))
+	assets := []Asset{}
+	for _, m := range metas {
+		a, err := readAsset(strings.TrimSuffix(filepath.Base(m), ".json"))
+		if err != nil || !slices.Contains(a.Owners, owner) || (kind != "" && a.Kind != kind) || (source != "" && a.Source != source) {
+			continue
+		}
+		a.Owners = nil // other callers' key hashes
+		assets = append(assets, a)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Created.After(assets[j].Created) })
+
+	total := len(assets)
+	assets = assets[min(offset, total):min(offset+limit, total)]
+	for i := range assets {
+		assets[i].URL = publicURL(baseURL(c), "assets/"+assets[i].ID+assets[i].Ext)
+	}
+	c.JSON(200, gin.H{"assets": assets, "total": total})
+}
@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// --- AUDIENCE / READING LEVEL ---
+// audience=kids|general|expert adds vocabulary and sentence guidance to the
+// script prompt and checks the result's Flesch reading ease (English only).
+// A script easier-audience targets find too hard is regenerated once with
+// the score as feedback; the final score is returned as script.readability.
+type audienceProfile struct {
+	Prompt    string
+	MinFlesch float64 // 0: no check
+}
+
+var audiences = map[string]audienceProfile{
+	"kids": {"Audience: children aged 8-12. Use simple everyday words, short sentences of at most 12 words, " +
+		"explain any unusual word, no jargon.", 80},
+	"general": {"Audience: a general adult audience. Use clear conversational language and avoid jargon.", 60},
+	"expert":  {"Audience: experts in the field. Precise technical terms are welcome; skip basic explanations.", 0},
+}
+
+// ScriptOptions adjusts script generation beyond topic and scenes.
+type ScriptOptions struct {
+	Audience string // key of audiences, "" for the default tone only
+	Hooks    string // "", auto or all, see applyHook
+	Hook     string // creator-chosen intro, replaces the generated one
+
+	Template *ScriptTemplate // script_template, see templates.go
+	Series   *Series         // series_id, see series.go
+	Channel  *ChannelHistory // caller's earlier scripts, see duplicates.go
+
+	Consensus *consensusJudge // script_consensus, see consensus.go
+}
+
+type Readability struct {
+	Audience string  `json:"audience"`
+	Flesch   float64 `json:"flesch"`
+	Target   float64 `json:"target,omitempty"`
+	Passed   bool    `json:"passed"`
+	Attempts int     `json:"attempts"`
+}
+
+func scriptOptionsFromForm(r *request) (ScriptOptions, error) {
+	opts := ScriptOptions{Audience: strings.ToLower(strings.TrimSpace(r.PostForm("audience")))}
+	if _, ok := audiences[opts.Audience]; opts.Audience != "" && !ok {
+		return opts, fmt.Errorf("unknown audience %q (use kids, general or expert)", opts.Audience)
+	}
+	opts.Hooks = strings.ToLower(strings.TrimSpace(r.PostForm("hooks")))
+	if opts.Hooks != "" && opts.Hooks != "auto" && opts.Hooks != "all" {
+		return opts, fmt.Errorf("hooks must be auto or all")
+	}
+	opts.Hook = strings.TrimSpace(r.PostForm("hook"))
+	if id := strings.ToLower(strings.TrimSpace(r.PostForm("script_template"))); id != "" {
+		t, err := loadScriptTemplate(id)
+		if err != nil {
+			return opts, err
+		}
+		opts.Template = t
+	}
+	if id := strings.ToLower(strings.TrimSpace(r.PostForm("series_id"))); id != "" {
+		s, err := loadSeries(id)
+		if err != nil {
+			return opts, err
+		}
+		opts.Series = s
+	}
+	var err error
+	if opts.Consensus, err = consensusFromForm(r); err != nil {
+		return opts, err
+	}
+	opts.Channel, err = channelFromForm(r)
+	return opts, err
+}
+
+// promptLines returns the extra prompt lines for opts, each starting on a
+// new line, plus feedback from a previous attempt if any.
+func (o ScriptOptions) promptLines(feedback string) string {
+	var lines string
+	if p, ok := audiences[o.Audience]; ok {
+		lines += "\n    " + p.Prompt
+	}
+	lines += o.Series.promptLines()
+	if feedback != "" {
+		lines += "\n    " + feedback
+	}
+	return lines
+}
+
+// generateForAudience runs gen and, when the audience has a reading-ease
+// target the script misses, once more with feedback. gen gets the extra
+// prompt lines to include. With script_consensus a second model's draft may
+// win first, see consensusJudge. The result is then checked against the
+// caller's earlier scripts, see ChannelHistory.
+func generateForAudience(opts ScriptOptions, gen func(lines string) (ScriptResponse, error)) (ScriptResponse, error) {
+	script, err := gen(opts.promptLines(""))
+	if err != nil {
+		return script, err
+	}
+	if opts.Consensus != nil {
+		script = opts.Consensus.pick(opts, script, gen)
+	}
+	if opts.Audience != "" {
+		script = readableScript(opts, script, gen)
+	}
+	if opts.Channel != nil {
+		script = opts.Channel.check(opts, script, gen)
+	}
+	return script, nil
+}
+
+func readableScript(opts ScriptOptions, script ScriptResponse, gen func(lines string) (ScriptResponse, error)) ScriptResponse {
+	profile := audiences[opts.Audience]
+	r := &Readability{Audience: opts.Audience, Target: profile.MinFlesch, Attempts: 1}
+	r.Flesch = fleschReadingEase(scriptText(script))
+	if profile.MinFlesch > 0 && r.Flesch < profile.MinFlesch {
+		fmt.Printf("📖 Readability %.0f below %.0f for %s, retrying\n", r.Flesch, profile.MinFlesch, opts.Audience)
+		feedback := fmt.Sprintf("Your previous draft scored %.0f on the Flesch reading ease scale; it must score at least %.0f. "+
+			"Use shorter sentences and simpler, shorter words.", r.Flesch, profile.MinFlesch)
+		if retry, err := gen(opts.promptLines(feedback)); err == nil {
+			r.Attempts = 2
+			if f := fleschReadingEase(scriptText(retry)); f > r.Flesch {
+				script, r.Flesch = retry, f
+			}
+		}
+	}
+	r.Flesch = math.Round(r.Flesch*10) / 10
+	r.Passed = profile.MinFlesch == 0 || r.Flesch >= profile.MinFlesch
+	script.Readability = r
+	return script
+}
+
+func scriptText(s ScriptResponse) string {
+	parts := []string{s.Intro}
+	for _, it := range s.Items {
+		parts = append(parts, it.Details)
+	}
+	return strings.Join(append(parts, s.Outro), " ")
+}
+
+// fleschReadingEase scores English text: 206.835 - 1.015 words/sentence -
+// 84.6 syllables/word. Higher is easier; 80+ suits children.
+func fleschReadingEase(text string) float64 {
+	words, syllables := 0, 0
+	for _, w := range strings.Fields(text) {
+		w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) })
+		if w == "" {
+			continue
+		}
+		words++
+		syllables += countSyllables(w)
+	}
+	if words == 0 {
+		return 0
+	}
+	n := float64(max(len(sentences(text)), 1))
+	return 206.835 - 1.015*float64(words)/n - 84.6*float64(syllables)/float64(words)
+}
+
+// countSyllables approximates by vowel groups, dropping a silent final e.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count, prevVowel := 0, false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	return max(count, 1)
+}
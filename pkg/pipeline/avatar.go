@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// --- AI AVATAR NARRATOR ---
+// The segment's TTS audio is sent to the avatar provider so the returned
+// talking head is lip-synced to the narration we already mix in.
+const avatarTimeout = 5 * time.Minute
+
+var avatarProviders = map[string]bool{"d-id": true, "heygen": true}
+
+// renderAvatarClip produces a talking-head clip for audioPath at dest.
+func renderAvatarClip(provider, avatarID, audioPath, dest string) error {
+	var clipUrl string
+	var err error
+	switch provider {
+	case "d-id":
+		clipUrl, err = didTalk(avatarID, audioPath)
+	case "heygen":
+		clipUrl, err = heygenVideo(avatarID, audioPath)
+	default:
+		return fmt.Errorf("unknown avatar provider %q", provider)
+	}
+	if err != nil {
+		return err
+	}
+	return downloadFile(clipUrl, dest)
+}
+
+// D-ID: upload the audio, create a talk from a source portrait, poll until done.
+func didTalk(sourceUrl, audioPath string) (string, error) {
+	apiKey := os.Getenv("DID_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("missing DID_API_KEY")
+	}
+	if sourceUrl == "" {
+		sourceUrl = os.Getenv("DID_AVATAR_URL")
+	}
+	if sourceUrl == "" {
+		return "", fmt.Errorf("missing avatar image (avatar_id or DID_AVATAR_URL)")
+	}
+	auth := func(req *http.Request) { req.Header.Set("Authorization", "Basic "+apiKey) }
+
+	var uploaded struct {
+		Url string `json:"url"`
+	}
+	if err := postMultipartFile("https://api.d-id.com/audios", "audio", audioPath, auth, &uploaded); err != nil {
+		return "", fmt.Errorf("d-id audio upload: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"source_url": sourceUrl,
+		"script":     map[string]string{"type": "audio", "audio_url": uploaded.Url},
+	})
+	var created struct {
+		Id string `json:"id"`
+	}
+	if err := doJSON("POST", "https://api.d-id.com/talks", body, auth, &created); err != nil {
+		return "", fmt.Errorf("d-id create: %v", err)
+	}
+
+	deadline := time.Now().Add(avatarTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(3 * time.Second)
+		var status struct {
+			Status    string `json:"status"`
+			ResultUrl string `json:"result_url"`
+		}
+		if err := doJSON("GET", "https://api.d-id.com/talks/"+created.Id, nil, auth, &status); err != nil {
+			continue
+		}
+		switch status.Status {
+		case "done":
+			return status.ResultUrl, nil
+		case "error", "rejected":
+			return "", fmt.Errorf("d-id talk %s", status.Status)
+		}
+	}
+	return "", fmt.Errorf("d-id timed out")
+}
+
+// HeyGen: upload the audio as an asset, generate an avatar video, poll status.
+func heygenVideo(avatarID, audioPath string) (string, error) {
+	apiKey := os.Getenv("HEYGEN_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("missing HEYGEN_API_KEY")
+	}
+	if avatarID == "" {
+		avatarID = os.Getenv("HEYGEN_AVATAR_ID")
+	}
+	if avatarID == "" {
+		return "", fmt.Errorf("missing avatar (avatar_id or HEYGEN_AVATAR_ID)")
+	}
+	auth := func(req *http.Request) { req.Header.Set("X-Api-Key", apiKey) }
+
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return "", err
+	}
+	var asset struct {
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}
+	req, _ := http.NewRequest("POST", "https://upload.heygen.com/v1/asset", bytes.NewReader(audio))
+	req.Header.Set("Content-Type", "audio/mpeg")
+	auth(req)
+	if err := sendJSON(req, &asset); err != nil {
+		return "", fmt.Errorf("heygen asset upload: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"video_inputs": []map[string]any{{
+			"character": map[string]string{"type": "avatar", "avatar_id": avatarID, "avatar_style": "normal"},
+			"voice":     map[string]string{"type": "audio", "audio_asset_id": asset.Data.Id},
+		}},
+		"dimension": map[string]int{"width": 720, "height": 720},
+	})
+	var created struct {
+		Data struct {
+			VideoId string `json:"video_id"`
+		} `json:"data"`
+	}
+	if err := doJSON("POST", "https://api.heygen.com/v2/video/generate", body, auth, &created); err != nil {
+		return "", fmt.Errorf("heygen create: %v", err)
+	}
+
+	deadline := time.Now().Add(avatarTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(5 * time.Second)
+		var status struct {
+			Data struct {
+				Status   string `json:"status"`
+				VideoUrl string `json:"video_url"`
+			} `json:"data"`
+		}
+		if err := doJSON("GET", "https://api.heygen.com/v1/video_status.get?video_id="+created.Data.VideoId, nil, auth, &status); err != nil {
+			continue
+		}
+		switch status.Data.Status {
+		case "completed":
+			return status.Data.VideoUrl, nil
+		case "failed":
+			return "", fmt.Errorf("heygen video failed")
+		}
+	}
+	return "", fmt.Errorf("heygen timed out")
+}
+
+func doJSON(method, urlStr string, body []byte, auth func(*http.Request), out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, _ := http.NewRequest(method, urlStr, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	auth(req)
+	return sendJSON(req, out)
+}
+
+func sendJSON(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func postMultipartFile(urlStr, field, path string, auth func(*http.Request), out any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile(field, filepath.Base(path))
+	io.Copy(part, f)
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", urlStr, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	auth(req)
+	return sendJSON(req, out)
+}
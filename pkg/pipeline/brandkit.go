@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- BRAND KITS ---
+// A brand kit is a saved set of form defaults (colors, placeholder style,
+// sting, ...) under an ID. Requests pass brand_kit=<id>; any setting the
+// request doesn't send itself is filled in from the kit before the handler
+// runs, so every option parser picks kit values up unchanged.
+type BrandKit struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Settings map[string]string `json:"settings"`
+}
+
+// Form keys a kit may carry.
+var brandKitKeys = []string{
+	"brand_color", "brand_color_2", "placeholder_style", "placeholder_text_color",
+	"look", "transition", "effect", "fit", "sting", "sting_asset", "brand_name",
+	"captions", "caption_style", "media_sources", "audience", "script_template",
+	"citations", "unsplash_credit", "blur_faces", "broll", "fps", "ambience", "ambience_volume", "mastering",
+}
+
+func brandKitDir() string {
+	if dir := os.Getenv("BRAND_KIT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "brand_kits")
+}
+
+func loadBrandKit(id string) (BrandKit, error) {
+	var kit BrandKit
+	if !uuidPattern.MatchString(id) {
+		return kit, fmt.Errorf("invalid brand_kit id")
+	}
+	data, err := os.ReadFile(filepath.Join(brandKitDir(), id+".json"))
+	if err != nil {
+		return kit, fmt.Errorf("brand kit %s not found", id)
+	}
+	err = json.Unmarshal(data, &kit)
+	return kit, err
+}
+
+// applyBrandKit fills missing form values from the request's brand kit.
+func applyBrandKit(c *gin.Context) {
+	if err := fillBrandKit(c.PostForm("brand_kit"), c.Request.PostForm); err != nil {
+		c.AbortWithStatusJSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	c.Next()
+}
+
+// fillBrandKit fills the values form lacks from brand kit id, if any.
+func fillBrandKit(id string, form url.Values) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil
+	}
+	kit, err := loadBrandKit(id)
+	if err != nil || form == nil {
+		return err
+	}
+	for key, v := range kit.Settings {
+		if form.Get(key) == "" {
+			form.Set(key, v)
+		}
+	}
+	if form.Get("brand_name") == "" && kit.Name != "" {
+		form.Set("brand_name", kit.Name)
+	}
+	return nil
+}
+
+// POST /brand-kits (form: name plus any of brandKitKeys; a "sting" video
+// upload is stored in the asset library and referenced as sting_asset)
+func handleCreateBrandKit(c *gin.Context) {
+	kit := BrandKit{ID: newUUID(), Name: strings.TrimSpace(c.PostForm("name")), Settings: map[string]string{}}
+	if kit.Name == "" {
+		c.JSON(400, gin.H{"error": "Missing name"})
+		return
+	}
+	for _, key := range brandKitKeys {
+		if v := strings.TrimSpace(c.PostForm(key)); v != "" {
+			kit.Settings[key] = v
+		}
+	}
+	if _, err := cardStyleFromForm(httpForm{c}); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if file, err := (httpForm{c}).FormFile("sting"); err == nil {
+		if !isVideoFile(file.Filename) {
+			c.JSON(400, gin.H{"error": "sting must be a video file"})
+			return
+		}
+		job, err := newJob()
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		job.owner = keyOwner(c.GetHeader("X-Api-Key"))
+		path, err := saveMediaUpload(job, file, ".mp4")
+		os.RemoveAll(job.Dir)
+		if err != nil {
+			c.JSON(uploadErrorStatus(err), gin.H{"error": "Could not save sting: " + err.Error()})
+			return
+		}
+		kit.Settings["sting_asset"] = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		delete(kit.Settings, "sting")
+	}
+
+	os.MkdirAll(brandKitDir(), 0755)
+	data, _ := json.MarshalIndent(kit, "", "  ")
+	if err := os.WriteFile(filepath.Join(brandKitDir(), kit.ID+".json"), data, 0644); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save brand kit"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "brand_kit": kit})
+}
+
+// GET /brand-kits/:id
+func handleGetBrandKit(c *gin.Context) {
+	kit, err := loadBrandKit(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"brand_kit": kit})
+}
@@ -0,0 +1,204 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// --- B-ROLL ---
+// broll=true breaks up a long video's single-image scenes with short stock
+// clips. One LLM call picks 2-3 filmable search terms per item, each term
+// fetches a landscape Pexels video (PEXELS_API_KEY), and an item's clips
+// are dealt across its beats. Within a segment a clip cuts in at a sentence
+// boundary, timed like the captions (see captionCues), and plays for
+// BROLL_SECS (3) over the scene media before the scene comes back; the
+// presenter, PiP and captions stay on top. A segment too short for a
+// boundary keeps its plain shot; the intro, the outro and previews never
+// get B-roll. Clips are credited and licensed like stock photos.
+const maxBRollTerms = 3
+
+// brollCut is one clip shown over a segment from Start to End seconds.
+type brollCut struct {
+	Path       string
+	Start, End float64
+}
+
+type PexelsVideoResponse struct {
+	Videos []struct {
+		URL      string `json:"url"`
+		Duration int    `json:"duration"`
+		User     struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"user"`
+		VideoFiles []struct {
+			Link     string `json:"link"`
+			FileType string `json:"file_type"`
+			Width    int    `json:"width"`
+			Height   int    `json:"height"`
+		} `json:"video_files"`
+	} `json:"videos"`
+}
+
+// withBRoll fetches each item's B-roll clips into its render options.
+func withBRoll(job *Job, script ScriptResponse, base RenderOptions, sceneOpts []RenderOptions) []RenderOptions {
+	if !base.BRoll || base.Preview || len(script.Items) == 0 {
+		return sceneOpts
+	}
+	terms, err := brollTerms(job, script.Items)
+	if err != nil {
+		fmt.Printf("⚠️ B-roll skipped: %v\n", err)
+		return sceneOpts
+	}
+	opts := make([]RenderOptions, len(script.Items))
+	for i := range opts {
+		opts[i] = base
+		if i < len(sceneOpts) {
+			opts[i] = sceneOpts[i]
+		}
+		for _, term := range terms[i] {
+			var source Source
+			path, err := job.Fetch("broll:"+term, ".mp4", func(dest string) (err error) {
+				source, err = downloadStockClip(term, dest)
+				return err
+			})
+			if err != nil {
+				fmt.Printf("⚠️ No B-roll for %q: %v\n", term, err)
+				continue
+			}
+			job.AddSource(path, source)
+			opts[i].BRollClips = append(opts[i].BRollClips, path)
+		}
+	}
+	return opts
+}
+
+// brollTerms asks for the stock footage search terms of each item.
+func brollTerms(job *Job, items []ScriptItem) ([][]string, error) {
+	var list strings.Builder
+	for i, it := range items {
+		fmt.Fprintf(&list, "\n    Item %d: %s. %s", i, it.Title, it.Details)
+	}
+	prompt := fmt.Sprintf(`
+    For each item of this video script, give 2 or 3 short stock footage search terms for B-roll
+    clips cut in while it is narrated. Use concrete things a camera can film ("city traffic at night",
+    "hands typing on a laptop"), never names of people, brands, films or places that need a specific shot.
+    %s
+    RETURN JSON ONLY:
+    { "items": [ { "index": 0, "keywords": ["term one", "term two"] } ] }
+    `, list.String())
+
+	var result struct {
+		Items []struct {
+			Index    int      `json:"index"`
+			Keywords []string `json:"keywords"`
+		} `json:"items"`
+	}
+	if err := completeJSON(job, "broll_keywords", prompt, &result); err != nil {
+		return nil, err
+	}
+	terms := make([][]string, len(items))
+	for _, r := range result.Items {
+		if r.Index < 0 || r.Index >= len(items) {
+			continue
+		}
+		for _, kw := range r.Keywords {
+			if kw = strings.TrimSpace(kw); kw != "" && len(terms[r.Index]) < maxBRollTerms {
+				terms[r.Index] = append(terms[r.Index], kw)
+			}
+		}
+	}
+	return terms, nil
+}
+
+// downloadStockClip downloads the first Pexels video for query long
+// enough for a cut, in the smallest file at least full HD wide.
+func downloadStockClip(query, dest string) (Source, error) {
+	apiKey := os.Getenv("PEXELS_API_KEY")
+	if apiKey == "" {
+		return Source{}, fmt.Errorf("missing key")
+	}
+	searchUrl := fmt.Sprintf("https://api.pexels.com/videos/search?query=%s&per_page=%d&orientation=landscape", url.QueryEscape(query), stockCandidates())
+	req, _ := http.NewRequest("GET", searchUrl, nil)
+	req.Header.Set("Authorization", apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Source{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return Source{}, fmt.Errorf("pexels status %d", resp.StatusCode)
+	}
+	var res PexelsVideoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Source{}, err
+	}
+	w, _ := frameSize("long")
+	for _, v := range res.Videos {
+		if float64(v.Duration) < brollSecs() {
+			continue
+		}
+		link, best := "", 0
+		for _, f := range v.VideoFiles {
+			if f.FileType != "video/mp4" || f.Width <= f.Height || mediaQualityOK(f.Width, f.Height) != nil {
+				continue
+			}
+			// The smallest file that fills the frame, else the largest.
+			fills, bestFills := f.Width >= w, best >= w
+			if link == "" || fills && (!bestFills || f.Width < best) || !fills && !bestFills && f.Width > best {
+				link, best = f.Link, f.Width
+			}
+		}
+		if link != "" {
+			source := Source{Provider: "Pexels", Author: v.User.Name, AuthorURL: v.User.URL, URL: v.URL, clip: true}
+			return source, downloadFile(link, dest)
+		}
+	}
+	return Source{}, fmt.Errorf("not found")
+}
+
+func brollSecs() float64 {
+	return float64(envInt("BROLL_SECS", 3))
+}
+
+// brollShare is beat b of n's share of an item's clips: every n-th from b.
+func brollShare(clips []string, b, n int) []string {
+	var share []string
+	for i := b; i < len(clips); i += max(n, 1) {
+		share = append(share, clips[i])
+	}
+	return share
+}
+
+// brollCuts places clips at the narration's sentence boundaries, at least
+// a second in and a clip's length apart, so the scene shows between them.
+func brollCuts(text string, d float64, clips []string) []brollCut {
+	words := len(strings.Fields(text))
+	if words == 0 || len(clips) == 0 {
+		return nil
+	}
+	secs := brollSecs()
+	var cuts []brollCut
+	next := 1.0
+	for _, cue := range captionCues(text, d, words)[1:] {
+		if len(cuts) == len(clips) {
+			break
+		}
+		if cue.Start >= next && cue.Start+secs <= d {
+			cuts = append(cuts, brollCut{Path: clips[len(cuts)], Start: cue.Start, End: cue.Start + secs})
+			next = cue.Start + 2*secs
+		}
+	}
+	return cuts
+}
+
+// brollGraph overlays the cut's clip full frame for its window.
+func brollGraph(idx int, in, out string, cut brollCut, w, h int) string {
+	return fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1,setpts=PTS-STARTPTS+%.3f/TB[br%d];"+
+		"[%s][br%d]overlay=0:0:enable='between(t,%.3f,%.3f)':eof_action=pass[%s]",
+		idx, w, h, w, h, cut.Start, idx, in, idx, cut.Start, cut.End, out)
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// --- CAPTIONS ---
+// Captions are written as ASS and burned in through libass, which shapes
+// complex scripts (HarfBuzz), handles RTL (FriBidi) and falls back through
+// fontconfig for glyphs such as emoji that the main font lacks.
+type Cue struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+const captionFont = "Noto Sans"
+
+// Fonts for scripts that Noto Sans does not cover.
+var scriptFonts = []struct {
+	table *unicode.RangeTable
+	font  string
+}{
+	{unicode.Devanagari, "Noto Sans Devanagari"},
+	{unicode.Bengali, "Noto Sans Bengali"},
+	{unicode.Tamil, "Noto Sans Tamil"},
+	{unicode.Arabic, "Noto Sans Arabic"},
+	{unicode.Hebrew, "Noto Sans Hebrew"},
+	{unicode.Thai, "Noto Sans Thai"},
+	{unicode.Han, "Noto Sans CJK SC"},
+	{unicode.Hiragana, "Noto Sans CJK JP"},
+	{unicode.Katakana, "Noto Sans CJK JP"},
+	{unicode.Hangul, "Noto Sans CJK KR"},
+}
+
+// Caption presets. Sizes and borders are fractions of the frame's short
+// side so captions scale with the resolution. ASS has no gradient fill, so
+// gradient-pop approximates one with a warm fill blurred into a contrasting
+// glow, and scales each line in.
+type captionPreset struct {
+	size, outline, shadow float64
+	bold                  bool
+	boxed                 bool   // opaque box behind the text (BorderStyle 3)
+	primary, edge, back   string // ASS &HAABBGGRR fill, outline/box and shadow colors
+	tags                  string // override tags prefixed to every line
+}
+
+const defaultCaptionStyle = "bold-outline"
+
+var captionPresets = map[string]captionPreset{
+	"bold-outline": {size: 0.06, outline: 0.004, bold: true, primary: "&H00FFFFFF", edge: "&H00000000", back: "&H80000000"},
+	"boxed":        {size: 0.052, outline: 0.012, bold: true, boxed: true, primary: "&H00FFFFFF", edge: "&H60000000", back: "&H00000000"},
+	"gradient-pop": {size: 0.064, outline: 0.005, shadow: 0.002, bold: true, primary: "&H0000D7FF", edge: "&H00B4308C", back: "&H80000000",
+		tags: `\blur4\fscx70\fscy70\t(0,150,\fscx100\fscy100)`},
+	"minimal": {size: 0.045, outline: 0.0015, shadow: 0.002, primary: "&H00FFFFFF", edge: "&H40000000", back: "&HA0000000"},
+}
+
+func writeASS(cues []Cue, dest string, opts RenderOptions) error {
+	w, h := frameSize(opts.VideoType)
+	p, ok := captionPresets[opts.CaptionStyle]
+	if !ok {
+		p = captionPresets[defaultCaptionStyle]
+	}
+	short := float64(min(w, h))
+	marginV := 220
+	if opts.VideoType == "long" {
+		marginV = 60
+	}
+	safe := safeMargins(opts.Platform, w, h)
+	marginV = max(marginV, safe.Bottom+int(0.02*float64(h)))
+	marginL, marginR := max(60, safe.Left), max(60, safe.Right)
+	bold, border := 0, 1
+	if p.bold {
+		bold = -1
+	}
+	if p.boxed {
+		border = 3
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Script Info]\nScriptType: v4.00+\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 0\nScaledBorderAndShadow: yes\n\n", w, h)
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	// Encoding -1 makes libass detect the base direction per line, so RTL lines lay out correctly.
+	fmt.Fprintf(&b, "Style: Default,%s,%.0f,%s,&H000000FF,%s,%s,%d,0,0,0,100,100,0,0,%d,%.1f,%.1f,2,%d,%d,%d,-1\n\n",
+		captionFont, p.size*short, p.primary, p.edge, p.back, bold, border, p.outline*short, p.shadow*short, marginL, marginR, marginV)
+	b.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, cue := range cues {
+		text := assText(cue.Text)
+		if p.tags != "" {
+			text = "{" + p.tags + "}" + text
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n", assTime(cue.Start), assTime(cue.End), text)
+	}
+	return os.WriteFile(dest, []byte(b.String()), 0644)
+}
+
+func assTime(sec float64) string {
+	if sec < 0 {
+		sec = 0
+	}
+	cs := int(sec*100 + 0.5)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, (cs/6000)%60, (cs/100)%60, cs%100)
+}
+
+// assText escapes override braces and switches font when the line is in a
+// script the default font does not cover.
+func assText(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.NewReplacer("{", "(", "}", ")", "\r", "", "\n", `\N`).Replace(text)
+	if font := fontForText(text); font != captionFont {
+		return `{\fn` + font + `}` + text
+	}
+	return text
+}
+
+// fontForText returns the font for the first non-Latin letter's script.
+func fontForText(text string) string {
+	for _, r := range text {
+		if r < 0x0250 || !unicode.IsLetter(r) {
+			continue
+		}
+		for _, sf := range scriptFonts {
+			if unicode.Is(sf.table, r) {
+				return sf.font
+			}
+		}
+	}
+	return captionFont
+}
+
+// subtitlesFilter burns an .ass file in with libass. FONTS_DIR points libass
+// at bundled fonts in addition to the system fontconfig set.
+func subtitlesFilter(assPath, videoType string) string {
+	filter := "subtitles=" + escapeFilterArg(assPath)
+	if dir := os.Getenv("FONTS_DIR"); dir != "" {
+		filter += ":fontsdir=" + escapeFilterArg(dir)
+	}
+	return filter
+}
+
+// escapeFilterArg escapes a value used inside an ffmpeg filter option.
+func escapeFilterArg(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`, `,`, `\,`, `;`, `\;`, `[`, `\[`, `]`, `\]`).Replace(v)
+}
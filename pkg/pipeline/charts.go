@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"strings"
+)
+
+// --- CHARTS ---
+// Price charts are drawn in Go onto the card background as scene images;
+// ffmpeg drawtext then adds the title lines, as for placeholder cards.
+// Candles and lines are green for a rise over the period, red for a fall.
+var (
+	chartUp   = color.RGBA{0x26, 0xc2, 0x81, 0xff}
+	chartDown = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	chartGrid = color.NRGBA{0xff, 0xff, 0xff, 0x30}
+)
+
+var chartStyles = map[string]bool{"candlestick": true, "line": true}
+
+// Bar is one period of price data.
+type Bar struct {
+	Open, High, Low, Close float64
+}
+
+// ChartLabels are the text lines drawn above the plot.
+type ChartLabels struct {
+	Title    string // e.g. "AAPL"
+	Subtitle string // e.g. "$189.20  +1.4% today"
+}
+
+// renderPriceChart writes a w×h frame-sized chart of bars to dest (jpg).
+func renderPriceChart(bars []Bar, style string, labels ChartLabels, dest, videoType string, card CardStyle) error {
+	if len(bars) < 2 {
+		return fmt.Errorf("chart needs at least 2 bars")
+	}
+	w, h := frameSize(videoType)
+	img := cardBackground(w, h, card)
+	plot := image.Rect(w*8/100, h*30/100, w*92/100, h*88/100)
+	if videoType == "long" {
+		plot = image.Rect(w*10/100, h*28/100, w*90/100, h*90/100)
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, b := range bars {
+		lo, hi = math.Min(lo, b.Low), math.Max(hi, b.High)
+	}
+	if hi <= lo {
+		hi, lo = hi+1, lo-1
+	}
+	y := func(v float64) int {
+		return plot.Max.Y - int((v-lo)/(hi-lo)*float64(plot.Dy()))
+	}
+	x := func(i int) int {
+		return plot.Min.X + int((float64(i)+0.5)*float64(plot.Dx())/float64(len(bars)))
+	}
+
+	for i := 0; i <= 4; i++ {
+		gy := plot.Min.Y + i*plot.Dy()/4
+		fillRect(img, image.Rect(plot.Min.X, gy, plot.Max.X, gy+2), chartGrid)
+	}
+
+	trend := chartUp
+	if bars[len(bars)-1].Close < bars[0].Open {
+		trend = chartDown
+	}
+	thick := max(min(w, h)/180, 3)
+	switch style {
+	case "line":
+		fill := color.NRGBA{trend.R, trend.G, trend.B, 0x40}
+		for i := 0; i < len(bars)-1; i++ {
+			x0, x1 := x(i), x(i+1)
+			y0, y1 := y(bars[i].Close), y(bars[i+1].Close)
+			for px := x0; px < x1; px++ {
+				py := y0 + (y1-y0)*(px-x0)/max(x1-x0, 1)
+				fillRect(img, image.Rect(px, py, px+1, plot.Max.Y), fill)
+			}
+			drawLine(img, x0, y0, x1, y1, thick, trend)
+		}
+	default:
+		body := max(plot.Dx()/len(bars)*6/10, 2)
+		for i, b := range bars {
+			c := chartUp
+			if b.Close < b.Open {
+				c = chartDown
+			}
+			cx := x(i)
+			fillRect(img, image.Rect(cx-max(thick/3, 1), y(b.High), cx+max(thick/3, 1), y(b.Low)), c)
+			top, bottom := y(math.Max(b.Open, b.Close)), y(math.Min(b.Open, b.Close))
+			fillRect(img, image.Rect(cx-body/2, top, cx+body/2, max(bottom, top+2)), c)
+		}
+	}
+
+	bgPath := dest + ".bg.png"
+	if err := writePNG(bgPath, img); err != nil {
+		return err
+	}
+	defer os.Remove(bgPath)
+	titlePath, subPath := dest+".title.txt", dest+".sub.txt"
+	os.WriteFile(titlePath, []byte(labels.Title), 0644)
+	os.WriteFile(subPath, []byte(labels.Subtitle), 0644)
+	defer os.Remove(titlePath)
+	defer os.Remove(subPath)
+
+	size := min(w, h) / 14
+	font := escapeFilterArg(fontForText(labels.Title))
+	filter := fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s:x=(w-text_w)/2:y=%d:shadowcolor=black@0.4:shadowx=3:shadowy=3,"+
+		"drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=0x%02x%02x%02x:x=(w-text_w)/2:y=%d:shadowcolor=black@0.4:shadowx=2:shadowy=2",
+		font, escapeFilterArg(titlePath), size, card.TextColor, plot.Min.Y-size*3,
+		font, escapeFilterArg(subPath), size*3/4, trend.R, trend.G, trend.B, plot.Min.Y-size*3/2)
+	if output, err := ffmpegCommand("-y", "-i", bgPath, "-vf", filter, "-frames:v", "1", "-q:v", "2", dest).CombinedOutput(); err != nil {
+		return fmt.Errorf("chart labels: %v | Log: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r.Intersect(img.Bounds()), &image.Uniform{c}, image.Point{}, draw.Over)
+}
+
+// drawLine draws a segment thick pixels wide by stamping squares along it.
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thick int, c color.RGBA) {
+	steps := max(abs(x1-x0), abs(y1-y0), 1)
+	for s := 0; s <= steps; s++ {
+		px := x0 + (x1-x0)*s/steps
+		py := y0 + (y1-y0)*s/steps
+		fillRect(img, image.Rect(px-thick/2, py-thick/2, px+thick/2+1, py+thick/2+1), c)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- SCENE LANGUAGES ---
+// A scene's "locale" narrates it in another language than the request's,
+// so a bilingual creator can run, say, an English video with Hindi scenes.
+// The script is asked to write that item in the language the way a
+// bilingual speaker talks: technical terms, product names and numbers stay
+// in English, the rest in the language's own script. The scene is voiced
+// with the locale's Google voice, or its "voice_id" (an ElevenLabs
+// multilingual voice) when the request uses ElevenLabs; the intro and outro
+// keep the request's locale and voice.
+var languageNames = map[string]string{
+	"en": "English", "hi": "Hindi", "es": "Spanish", "fr": "French", "pt": "Portuguese",
+	"de": "German", "ar": "Arabic", "bn": "Bengali", "ta": "Tamil", "ja": "Japanese",
+}
+
+func checkSceneLocale(i int, s *SceneData) error {
+	s.Locale = strings.ToLower(strings.TrimSpace(s.Locale))
+	s.VoiceID = strings.TrimSpace(s.VoiceID)
+	if _, ok := googleLocales[s.Locale]; s.Locale != "" && !ok {
+		return fmt.Errorf("scene %d: unsupported locale %q", i, s.Locale)
+	}
+	if err := checkVoiceID(s.VoiceID); err != nil {
+		return fmt.Errorf("scene %d: %v", i, err)
+	}
+	return nil
+}
+
+// sceneLanguageNote is the prompt line asking for a scene's language.
+func sceneLanguageNote(s SceneData) string {
+	if s.Locale == "" {
+		return ""
+	}
+	lang := languageNames[googleLocales[s.Locale].Lang]
+	if lang == "English" {
+		return "\nLanguage: English."
+	}
+	return fmt.Sprintf("\nLanguage: write this item's title and details in %s, as a bilingual speaker would say it: "+
+		"keep technical terms, product names and numbers in English (Latin letters), the rest in %s script.", lang, lang)
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// --- COMPOSITING ---
+// chromaKeyGraph keys out the presenter clip on input idx and overlays it on
+// the [in] stream, producing [out]. The presenter fills 85% of frame height.
+func chromaKeyGraph(idx int, in, out string, w, h int, opts RenderOptions) string {
+	color, despill := keyColor(opts.KeyColor)
+	x := "(W-w)/2"
+	switch opts.PresenterPosition {
+	case "left":
+		x = "W*0.04"
+	case "right":
+		x = "W-w-W*0.04"
+	}
+	fg := fmt.Sprintf("[%d:v]scale=-2:%d,colorkey=%s:0.3:0.1", idx, h*85/100, color)
+	if despill != "" {
+		fg += ",despill=type=" + despill
+	}
+	return fmt.Sprintf("%s[fg%d];[%s][fg%d]overlay=x=%s:y=H-h[%s]", fg, idx, in, idx, x, out)
+}
+
+// hexColor is a key_color given as RRGGBB, optionally prefixed # or 0x.
+var hexColor = regexp.MustCompile(`^(#|0x)?[0-9a-fA-F]{6}$`)
+
+// checkKeyColor rejects a key_color that isn't green, blue or a hex color;
+// it goes into the filtergraph, so nothing else may get through.
+func checkKeyColor(where, c string) error {
+	switch c = strings.ToLower(strings.TrimSpace(c)); {
+	case c == "", c == "green", c == "blue", hexColor.MatchString(c):
+		return nil
+	}
+	return fmt.Errorf("%s: unknown key_color %q (use green, blue or #RRGGBB)", where, c)
+}
+
+// keyColor maps key_color to an ffmpeg color and the matching despill type.
+func keyColor(c string) (string, string) {
+	c = strings.ToLower(strings.TrimSpace(c))
+	switch {
+	case c == "blue":
+		return "0x0000FF", "blue"
+	case c != "green" && hexColor.MatchString(c):
+		return "0x" + strings.TrimPrefix(strings.TrimPrefix(c, "#"), "0x"), ""
+	}
+	return "0x00FF00", "green"
+}
+
+// overlayGraph scales the clip on input idx to pct of the frame width and
+// places it in a corner of [in], producing [out], inside the safe margins.
+func overlayGraph(idx int, in, out string, w, pct int, corner string, safe Margins) string {
+	margin := w * 4 / 100
+	top, bottom := max(margin, safe.Top), max(margin, safe.Bottom)
+	left, right := max(margin, safe.Left), max(margin, safe.Right)
+	x, y := fmt.Sprintf("W-w-%d", right), fmt.Sprintf("H-h-%d", bottom)
+	switch corner {
+	case "top-left":
+		x, y = fmt.Sprint(left), fmt.Sprint(top)
+	case "top-right":
+		y = fmt.Sprint(top)
+	case "bottom-left":
+		x = fmt.Sprint(left)
+	}
+	return fmt.Sprintf("[%d:v]scale=%d:-2[ov%d];[%s][ov%d]overlay=x=%s:y=%s[%s]", idx, w*pct/100, idx, in, idx, x, y, out)
+}
@@ -0,0 +1,30 @@
+package pipeline
+
+import "testing"
+
+func TestCheckKeyColor(t *testing.T) {
+	tests := []struct {
+		color string
+		ok    bool
+	}{
+		{"", true},
+		{"green", true},
+		{"blue", true},
+		{" Blue ", true},
+		{"00ff00", true},
+		{"#00FF00", true},
+		{"0x1a2B3c", true},
+		{"red", false},
+		{"#00ff0", false},
+		{"#00ff000", false},
+		{"0xGG0000", false},
+		{"##00ff00", false},
+		{"00ff00:0.5", false},
+		{"green,drawtext=text=x", false},
+	}
+	for _, tt := range tests {
+		if err := checkKeyColor("scene 1", tt.color); (err == nil) != tt.ok {
+			t.Errorf("checkKeyColor(%q) = %v, want ok %v", tt.color, err, tt.ok)
+		}
+	}
+}
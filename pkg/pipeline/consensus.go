@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- MODEL CONSENSUS ---
+// script_consensus=true writes the script twice, once with the usual model
+// (Groq, or the local one, see ollama.go) and once with Gemini
+// (GEMINI_API_KEY, GEMINI_MODEL, default gemini-2.0-flash, through its
+// OpenAI-compatible endpoint), and has a judge prompt score both drafts
+// 0-10 on hook strength and clarity. The higher total wins, the usual model
+// on a tie, and the scores come back as script.consensus. The drafts are
+// shown to the judge in a seed-chosen order so neither always comes first.
+// It roughly doubles the script's inference cost; audience and duplicate
+// retries afterwards use the usual model.
+type Consensus struct {
+	Winner string        `json:"winner"` // model name
+	Scores []ModelScores `json:"scores"`
+	Reason string        `json:"reason,omitempty"`
+}
+
+type ModelScores struct {
+	Model   string  `json:"model"`
+	Hook    float64 `json:"hook"`
+	Clarity float64 `json:"clarity"`
+}
+
+// chatModel is an OpenAI-compatible chat endpoint.
+type chatModel struct {
+	Name, BaseURL, Key string
+	Local              bool // gets the local JSON handling, see ollama.go
+}
+
+// consensusJudge writes and judges the second draft for a job.
+type consensusJudge struct {
+	job   *Job
+	model chatModel
+}
+
+const geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/openai/"
+
+func consensusFromForm(r *request) (*consensusJudge, error) {
+	if r.PostForm("script_consensus") != "true" {
+		return nil, nil
+	}
+	key := os.Getenv("GEMINI_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("script_consensus is not available on this server")
+	}
+	if r.job == nil {
+		return nil, nil
+	}
+	m := chatModel{Name: os.Getenv("GEMINI_MODEL"), BaseURL: geminiBaseURL, Key: key}
+	if m.Name == "" {
+		m.Name = "gemini-2.0-flash"
+	}
+	return &consensusJudge{job: r.job, model: m}, nil
+}
+
+// chatClient returns the client and model the job's LLM calls use now.
+func (j *Job) chatClient() (*openai.Client, chatModel, error) {
+	j.mu.Lock()
+	m := j.llm
+	j.mu.Unlock()
+	if m == nil {
+		return defaultChatClient()
+	}
+	config := openai.DefaultConfig(m.Key)
+	config.BaseURL = m.BaseURL
+	return openai.NewClientWithConfig(config), *m, nil
+}
+
+func (j *Job) useModel(m *chatModel) {
+	j.mu.Lock()
+	j.llm = m
+	j.mu.Unlock()
+}
+
+// pick writes the second draft and returns whichever the judge prefers.
+func (cj *consensusJudge) pick(opts ScriptOptions, script ScriptResponse, gen func(lines string) (ScriptResponse, error)) ScriptResponse {
+	cj.job.useModel(&cj.model)
+	alt, err := gen(opts.promptLines(""))
+	cj.job.useModel(nil)
+	if err != nil {
+		fmt.Printf("⚠️ Consensus skipped, %s failed: %v\n", cj.model.Name, err)
+		return script
+	}
+
+	_, base, _ := defaultChatClient()
+	drafts := []ScriptResponse{script, alt}
+	models := []string{base.Name, cj.model.Name}
+	first := seededIndex(cj.job.seed, "consensus", 2) // shown as draft A
+	order := []int{first, 1 - first}
+	prompt := fmt.Sprintf(`
+    Two drafts of the same video script follow. Score each from 0 to 10 on:
+    - hook: how strongly the intro's first line makes a viewer keep watching
+    - clarity: how easy the narration is to follow when heard once
+    DRAFT A:
+    %s
+    DRAFT B:
+    %s
+    RETURN JSON ONLY:
+    { "a": { "hook": 7, "clarity": 8 }, "b": { "hook": 6, "clarity": 9 }, "reason": "one sentence" }
+    `, draftText(drafts[order[0]]), draftText(drafts[order[1]]))
+
+	var verdict struct {
+		A      ModelScores `json:"a"`
+		B      ModelScores `json:"b"`
+		Reason string      `json:"reason"`
+	}
+	if err := completeJSON(cj.job, "consensus_judge", prompt, &verdict); err != nil {
+		fmt.Printf("⚠️ Consensus judge failed, keeping %s: %v\n", base.Name, err)
+		return script
+	}
+	scores := make([]ModelScores, 2)
+	scores[order[0]], scores[order[1]] = verdict.A, verdict.B
+	for i := range scores {
+		scores[i].Model = models[i]
+	}
+	winner := 0
+	if scores[1].Hook+scores[1].Clarity > scores[0].Hook+scores[0].Clarity {
+		winner = 1
+	}
+	fmt.Printf("⚖️ Consensus: %s wins (%.0f+%.0f vs %.0f+%.0f)\n", models[winner],
+		scores[winner].Hook, scores[winner].Clarity, scores[1-winner].Hook, scores[1-winner].Clarity)
+	picked := drafts[winner]
+	picked.Consensus = &Consensus{Winner: models[winner], Scores: scores, Reason: verdict.Reason}
+	return picked
+}
+
+func draftText(s ScriptResponse) string {
+	lines := []string{"Intro: " + s.Intro}
+	for _, it := range s.Items {
+		lines = append(lines, it.Title+": "+it.Details)
+	}
+	return strings.Join(append(lines, "Outro: "+s.Outro), "\n    ")
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// --- MEDIA FITTING ---
+// pad letterboxes the whole image (the original behavior), crop fills the
+// frame from the center, smart fills it around the most detailed region.
+var fitModes = map[string]bool{"pad": true, "crop": true, "smart": true}
+
+const saliencyGrid = 96 // analysis resolution of the longer image side
+
+// fitFilter scales the media to the w x h frame with the given fit mode.
+func fitFilter(mediaPath string, w, h int, mode string) string {
+	switch mode {
+	case "crop", "smart":
+		fx, fy := 0.5, 0.5
+		if mode == "smart" && !isVideoFile(mediaPath) {
+			if x, y, err := smartCropFocus(mediaPath, w, h); err == nil {
+				fx, fy = x, y
+			} else {
+				fmt.Printf("⚠️ Smart crop fell back to center: %v\n", err)
+			}
+		}
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d:(iw-ow)*%.3f:(ih-oh)*%.3f", w, h, w, h, fx, fy)
+	}
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", w, h, w, h)
+}
+
+// smartCropFocus picks where a frame-shaped window should sit inside the
+// image, as fractions of the overflow on each axis. Each cell is scored by
+// edge density (faces, text and subjects are detailed, backgrounds are not)
+// and saturation, weighted toward the center, and the window with the
+// highest total wins.
+func smartCropFocus(path string, w, h int) (float64, float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	b := img.Bounds()
+	if b.Dx() < 2 || b.Dy() < 2 {
+		return 0, 0, fmt.Errorf("image too small")
+	}
+
+	step := math.Max(float64(b.Dx()), float64(b.Dy())) / saliencyGrid
+	gw, gh := max(2, int(float64(b.Dx())/step)), max(2, int(float64(b.Dy())/step))
+	luma := make([]float64, gw*gh)
+	sat := make([]float64, gw*gh)
+	for y := 0; y < gh; y++ {
+		for x := 0; x < gw; x++ {
+			r, g, bl, _ := img.At(b.Min.X+int(float64(x)*step), b.Min.Y+int(float64(y)*step)).RGBA()
+			rf, gf, bf := float64(r)/65535, float64(g)/65535, float64(bl)/65535
+			luma[y*gw+x] = 0.299*rf + 0.587*gf + 0.114*bf
+			sat[y*gw+x] = math.Max(rf, math.Max(gf, bf)) - math.Min(rf, math.Min(gf, bf))
+		}
+	}
+
+	score := make([]float64, gw*gh)
+	for y := 1; y < gh-1; y++ {
+		for x := 1; x < gw-1; x++ {
+			i := y*gw + x
+			gx := luma[i+1] - luma[i-1]
+			gy := luma[i+gw] - luma[i-gw]
+			dx, dy := float64(x)/float64(gw)-0.5, float64(y)/float64(gh)-0.5
+			center := math.Exp(-(dx*dx + dy*dy) / 0.18)
+			score[i] = (math.Hypot(gx, gy) + 0.3*sat[i]) * (0.4 + 0.6*center)
+		}
+	}
+
+	// Only one axis overflows once the image is scaled to cover the frame.
+	frameAspect := float64(w) / float64(h)
+	if float64(gw)/float64(gh) > frameAspect {
+		win := max(1, int(float64(gh)*frameAspect))
+		return bestWindow(gw, win, func(x int) float64 {
+			sum := 0.0
+			for y := 0; y < gh; y++ {
+				sum += score[y*gw+x]
+			}
+			return sum
+		}), 0.5, nil
+	}
+	win := max(1, int(float64(gw)/frameAspect))
+	return 0.5, bestWindow(gh, win, func(y int) float64 {
+		sum := 0.0
+		for x := 0; x < gw; x++ {
+			sum += score[y*gw+x]
+		}
+		return sum
+	}), nil
+}
+
+// bestWindow slides a window of win cells over n lines and returns the best
+// start as a fraction of the available travel.
+func bestWindow(n, win int, line func(int) float64) float64 {
+	if win >= n {
+		return 0.5
+	}
+	sums := make([]float64, n)
+	for i := range sums {
+		sums[i] = line(i)
+	}
+	cur := 0.0
+	for i := 0; i < win; i++ {
+		cur += sums[i]
+	}
+	best, bestAt := cur, 0
+	for start := 1; start+win <= n; start++ {
+		cur += sums[start+win-1] - sums[start-1]
+		if cur > best {
+			best, bestAt = cur, start
+		}
+	}
+	return float64(bestAt) / float64(n-win)
+}
@@ -0,0 +1,189 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- DEBUG ECHO ---
+// debug=true records, for that job, every LLM call's exact prompt, the raw
+// model response and the cleaned JSON that was parsed. The record is
+// returned to the requester as "debug" (also on failures) and saved outside
+// the public output dir; GET /v1/jobs/:id only shows it to the job's owner
+// (the X-Api-Key that started it, see keyOwner) or with an X-Admin-Token
+// header matching ADMIN_TOKEN. Each echoed text is capped at maxDebugChars.
+const maxDebugChars = 32000
+
+const scriptModel = "llama-3.3-70b-versatile"
+
+type LLMCall struct {
+	Name     string `json:"name"`
+	Model    string `json:"model"`
+	Template string `json:"template,omitempty"` // script template whose system prompt and examples preceded the prompt
+	Prompt   string `json:"prompt"`
+	Raw      string `json:"raw,omitempty"`
+	Cleaned  string `json:"cleaned,omitempty"`
+	Error    string `json:"error,omitempty"`
+	MS       int64  `json:"ms"`
+}
+
+type Debug struct {
+	mu  sync.Mutex
+	llm []LLMCall
+}
+
+func (d *Debug) addLLM(call LLMCall) {
+	if d == nil {
+		return
+	}
+	call.Prompt, call.Raw, call.Cleaned = clipDebug(call.Prompt), clipDebug(call.Raw), clipDebug(call.Cleaned)
+	d.mu.Lock()
+	d.llm = append(d.llm, call)
+	d.mu.Unlock()
+}
+
+// Report is the JSON form of the record, or nil when debug is off.
+func (d *Debug) Report() map[string]any {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return map[string]any{"llm": append([]LLMCall{}, d.llm...)}
+}
+
+func clipDebug(s string) string {
+	if len(s) <= maxDebugChars {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxDebugChars], "") + fmt.Sprintf("…[%d more bytes]", len(s)-maxDebugChars)
+}
+
+// completeJSON sends prompt to the script model in JSON mode and decodes the
+// answer, stripped of markdown fences, into out. The call is recorded in the
+// job's debug record under name.
+func completeJSON(job *Job, name, prompt string, out any) error {
+	return completeTemplated(job, name, nil, prompt, out)
+}
+
+// completeTemplated is completeJSON with tmpl's system prompt and examples
+// sent first; tmpl may be nil.
+func completeTemplated(job *Job, name string, tmpl *ScriptTemplate, prompt string, out any) error {
+	client, model, err := job.chatClient()
+	call := LLMCall{Name: name, Model: model.Name, Prompt: prompt}
+	keyText := prompt
+	if model.Name != scriptModel {
+		keyText += "\x00" + model.Name
+	}
+	if tmpl != nil {
+		call.Template = tmpl.ID
+		data, _ := json.Marshal(tmpl)
+		keyText += "\x00" + string(data)
+	}
+	start := time.Now()
+	defer func() {
+		call.MS = time.Since(start).Milliseconds()
+		job.debug.addLLM(call)
+	}()
+
+	if err != nil {
+		call.Error = err.Error()
+		return err
+	}
+
+	key := llmKey(name, keyText)
+	if answer, ok := job.replayedAnswer(key); ok && json.Unmarshal([]byte(answer), out) == nil {
+		call.Cleaned = answer
+		job.stream.delta(name, answer)
+		return nil
+	}
+
+	seed := job.seed
+	req := openai.ChatCompletionRequest{
+		Model:          model.Name,
+		Messages:       model.messages(tmpl.messages(prompt)),
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Seed:           &seed,
+	}
+	for attempt := 1; ; attempt++ {
+		if call.Raw, err = job.chat(client, name, req); err != nil {
+			call.Error = err.Error()
+			return err
+		}
+		clean := strings.ReplaceAll(call.Raw, "```json", "")
+		call.Cleaned = strings.TrimSpace(strings.ReplaceAll(clean, "```", ""))
+		if model.Local {
+			call.Cleaned = jsonObject(call.Cleaned)
+		}
+		err = json.Unmarshal([]byte(call.Cleaned), out)
+		if err == nil {
+			break
+		}
+		if !model.Local || attempt == maxLocalAttempts {
+			call.Error = err.Error()
+			return fmt.Errorf("json parse error")
+		}
+		fmt.Printf("🔁 %s answered invalid JSON (%v), asking again\n", model.Name, err)
+		req.Messages = append(req.Messages, localRepair(call.Raw, err)...)
+	}
+	job.recordAnswer(key, call.Cleaned)
+	return nil
+}
+
+// chat sends req, streaming it when the job streams.
+func (j *Job) chat(client *openai.Client, name string, req openai.ChatCompletionRequest) (string, error) {
+	if j.stream != nil {
+		return j.stream.complete(client, name, req)
+	}
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty model response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func debugDir() string {
+	if dir := os.Getenv("DEBUG_RECORD_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "jobs")
+}
+
+// SaveDebug writes the debug record outside the publicly served output dir,
+// with the job's owner.
+func (j *Job) SaveDebug(report map[string]any) {
+	os.MkdirAll(debugDir(), 0700)
+	saved := maps.Clone(report)
+	if j.owner != "" {
+		saved["owner"] = j.owner
+	}
+	data, _ := json.MarshalIndent(saved, "", "  ")
+	os.WriteFile(filepath.Join(debugDir(), j.ID+".debug.json"), data, 0600)
+}
+
+// loadDebug reads a job's debug record and the owner it was saved with.
+func loadDebug(id string) (map[string]any, string, bool) {
+	data, err := os.ReadFile(filepath.Join(debugDir(), id+".debug.json"))
+	if err != nil {
+		return nil, "", false
+	}
+	var report map[string]any
+	if json.Unmarshal(data, &report) != nil {
+		return nil, "", false
+	}
+	owner, _ := report["owner"].(string)
+	delete(report, "owner")
+	return report, owner, true
+}
@@ -0,0 +1,204 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- DECK IMPORT ---
+// A PDF (or PPTX converted to PDF) becomes one scene per page: the page
+// image is the media and its embedded text is what the LLM narrates.
+const maxDeckSlides = 30
+
+func handleDeckVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Deck Received")
+
+	topic := r.PostForm("topic")
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "long"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := r.FormFile("deck")
+	if err != nil {
+		r.JSON(400, gin.H{"error": "Missing deck file"})
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".pdf" && ext != ".pptx" {
+		r.JSON(400, gin.H{"error": "Deck must be a .pdf or .pptx file"})
+		return
+	}
+	deckPath := job.MediaPath(ext)
+	if err := saveUpload(file, deckPath); err != nil {
+		r.JSON(uploadErrorStatus(err), gin.H{"error": "Could not save deck: " + err.Error()})
+		return
+	}
+	defer os.Remove(deckPath)
+
+	pdfPath := deckPath
+	if ext == ".pptx" {
+		if pdfPath, err = convertToPDF(deckPath); err != nil {
+			fmt.Printf("❌ CRITICAL ERROR (PPTX): %v\n", err)
+			r.JSON(422, gin.H{"error": "Could not convert PPTX: " + err.Error()})
+			return
+		}
+		defer os.Remove(pdfPath)
+	}
+
+	fmt.Println("🔹 STEP 2: Rasterizing Slides...")
+	job.Stage("rasterize")
+	slides, err := rasterizePDF(pdfPath, job.Path("slide"))
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Rasterize): %v\n", err)
+		r.JSON(422, gin.H{"error": "Could not read deck: " + err.Error()})
+		return
+	}
+	defer func() {
+		for _, s := range slides {
+			os.Remove(s)
+		}
+	}()
+
+	scenes := make([]SceneData, len(slides))
+	for i := range slides {
+		scenes[i] = SceneData{Name: fmt.Sprintf("Slide %d", i+1), Details: pdfPageText(pdfPath, i+1)}
+	}
+	if topic == "" {
+		topic = firstLine(scenes[0].Details)
+	}
+	for _, s := range scenes {
+		if !checkPolicy(r, s.Details) {
+			return
+		}
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Slides: %d\n", topic, videoType, len(slides))
+
+	fmt.Println("🔹 STEP 3: Generating Script (Groq)...")
+	job.Stage("script")
+	scriptData, err := generateSegmentedScript(job, topic, "presentation", videoType, scenes, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	factCheck, ok := runFactCheck(r, job, topic, scriptData)
+	if !ok {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	fmt.Println("🔹 STEP 4: Rendering Segments...")
+	job.Stage("render")
+	offerPreview(r, job, scriptData, slides[0], slides, slides[len(slides)-1], base, nil)
+	segments, err := renderScript(job, scriptData, slides[0], slides, slides[len(slides)-1], base, nil)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (TTS): %v\n", err)
+		r.JSON(502, gin.H{"error": "Narration incomplete: " + err.Error(), "narration_lost": job.LostNarration()})
+		return
+	}
+	segments = withSting(segments, base)
+
+	fmt.Println("🔹 STEP 5: Stitching Video...")
+	job.Stage("stitch")
+	finalVideo := job.Path("final_deck.mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	offerStream(r, job, finalVideo)
+	finish := finishOptionsFromForm(r, job, videoType)
+	applyMood(job, &finish, scriptData.Mood)
+	finish.Title, finish.Chapters = topic, segmentChapters(segments)
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Deck Video Ready.")
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": job.URL(r.base, finalVideo), "stitch": stitch,
+		"narration_url": narrationURL(r.base, job, finish), "thumbnails": thumbnailURLs(r.base, job, finish),
+		"teaser_url": teaserURL(r.base, job, finish), "size_budget": finish.SizeBudget, "fact_check": factCheck,
+		"output": output, "segments": segmentInfo, "slides": len(slides),
+		"chapters": finish.Chapters, "chapters_text": youtubeChapters(finish.Chapters)})
+}
+
+// convertToPDF uses headless LibreOffice and returns the generated PDF path.
+func convertToPDF(src string) (string, error) {
+	outDir := filepath.Dir(src)
+	cmd := exec.Command("soffice", "--headless", "--convert-to", "pdf", "--outdir", outDir, src)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("soffice: %v | Log: %s", err, string(output))
+	}
+	pdfPath := strings.TrimSuffix(src, filepath.Ext(src)) + ".pdf"
+	if _, err := os.Stat(pdfPath); err != nil {
+		return "", fmt.Errorf("no PDF produced")
+	}
+	return pdfPath, nil
+}
+
+// rasterizePDF renders each page to PNG and returns the images in page order.
+func rasterizePDF(pdfPath, prefix string) ([]string, error) {
+	cmd := exec.Command("pdftoppm", "-png", "-r", "150", "-l", fmt.Sprint(maxDeckSlides), pdfPath, prefix)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %v | Log: %s", err, string(output))
+	}
+	pages, _ := filepath.Glob(prefix + "-*.png")
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("deck has no pages")
+	}
+	// pdftoppm zero-pads page numbers to equal width, so lexical order is page order.
+	sort.Strings(pages)
+	return pages, nil
+}
+
+func pdfPageText(pdfPath string, page int) string {
+	out, err := exec.Command("pdftotext", "-f", fmt.Sprint(page), "-l", fmt.Sprint(page), "-layout", pdfPath, "-").Output()
+	if err != nil {
+		return ""
+	}
+	text := strings.Join(strings.Fields(string(out)), " ")
+	if len(text) > 1200 {
+		text = text[:1200]
+	}
+	return text
+}
+
+func firstLine(text string) string {
+	words := strings.Fields(text)
+	if len(words) > 8 {
+		words = words[:8]
+	}
+	if len(words) == 0 {
+		return "Presentation"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- DUPLICATE CONTENT ---
+// Callers identified by an X-Api-Key header (optionally split further with
+// a channel field) get every finished script remembered, and each new
+// script is compared with their earlier ones by embedding similarity
+// before anything is rendered. A script scoring duplicate_threshold (0.9)
+// or more against an earlier video is flagged as "duplicate" in the
+// response; duplicate_check=regenerate also writes it once more, told to
+// take a different angle, and keeps whichever draft is less similar.
+// duplicate_check=off neither checks nor remembers. Embeddings come from
+// an OpenAI-compatible endpoint (EMBEDDINGS_API_KEY, EMBEDDINGS_BASE_URL,
+// EMBEDDINGS_MODEL, default text-embedding-3-small); without a key a
+// hashed bag of words stands in, which still catches near copies. Scripts
+// are only compared with ones embedded the same way. History is stored
+// under a hash of the key, like notification settings, and only the last
+// maxChannelScripts are kept.
+type Similarity struct {
+	JobID       string  `json:"job_id"` // the most similar earlier video
+	Topic       string  `json:"topic,omitempty"`
+	Score       float64 `json:"score"`
+	Threshold   float64 `json:"threshold"`
+	Regenerated bool    `json:"regenerated,omitempty"`
+}
+
+// ChannelHistory is a caller's remembered scripts, one line each.
+type ChannelHistory struct {
+	file      string
+	mode      string // warn or regenerate
+	threshold float64
+}
+
+type channelScript struct {
+	JobID     string    `json:"job_id"`
+	Topic     string    `json:"topic,omitempty"`
+	Created   string    `json:"created"`
+	Model     string    `json:"model"`
+	Embedding []float32 `json:"embedding"`
+}
+
+const (
+	maxChannelScripts = 200
+	localEmbedding    = "hashed-words-512"
+)
+
+// channelMu serializes rewrites of every history file.
+var channelMu sync.Mutex
+
+func channelDir() string {
+	if dir := os.Getenv("CHANNEL_HISTORY_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "channels")
+}
+
+// channelFromForm returns the caller's history, nil when there is no
+// X-Api-Key or the check is off.
+func channelFromForm(f Form) (*ChannelHistory, error) {
+	mode := strings.ToLower(strings.TrimSpace(f.DefaultPostForm("duplicate_check", "warn")))
+	if mode != "warn" && mode != "regenerate" && mode != "off" {
+		return nil, fmt.Errorf("duplicate_check must be warn, regenerate or off")
+	}
+	key := f.GetHeader("X-Api-Key")
+	if mode == "off" || key == "" {
+		return nil, nil
+	}
+	h := &ChannelHistory{mode: mode, threshold: 0.9}
+	if v := f.PostForm("duplicate_threshold"); v != "" {
+		if _, err := fmt.Sscanf(v, "%g", &h.threshold); err != nil || h.threshold < 0.5 || h.threshold > 0.99 {
+			return nil, fmt.Errorf("duplicate_threshold must be between 0.5 and 0.99")
+		}
+	}
+	channel := strings.ToLower(strings.TrimSpace(f.PostForm("channel")))
+	if channel != "" && !slugPattern.MatchString(channel) {
+		return nil, fmt.Errorf("invalid channel %q (lowercase letters, digits, - or _)", channel)
+	}
+	sum := sha256.Sum256([]byte(key + "\x00" + channel))
+	h.file = filepath.Join(channelDir(), hex.EncodeToString(sum[:])+".jsonl")
+	return h, nil
+}
+
+// check compares script with the history, regenerating it if asked.
+func (h *ChannelHistory) check(opts ScriptOptions, script ScriptResponse, gen func(lines string) (ScriptResponse, error)) ScriptResponse {
+	script.embedding, script.embedModel = embedScript(scriptText(script))
+	match := h.closest(script)
+	if match == nil || match.Score < h.threshold {
+		return script
+	}
+	fmt.Printf("🪞 Script is %.2f similar to job %s\n", match.Score, match.JobID)
+	if h.mode == "regenerate" {
+		feedback := fmt.Sprintf("Your previous draft was too close to this channel's earlier video %q. "+
+			"Take a clearly different angle, with other facts, examples and another opening.", match.Topic)
+		if retry, err := gen(opts.promptLines(feedback)); err == nil {
+			retry.embedding, retry.embedModel = embedScript(scriptText(retry))
+			if m := h.closest(retry); m == nil || m.Score < match.Score {
+				script, match = retry, m
+			}
+			if match == nil || match.Score < h.threshold {
+				return script
+			}
+			match.Regenerated = true
+		}
+	}
+	script.Similar = match
+	return script
+}
+
+// closest is the most similar remembered script embedded the same way.
+func (h *ChannelHistory) closest(script ScriptResponse) *Similarity {
+	var best *Similarity
+	readJSONLines(h.file, func(e channelScript) {
+		if e.Model != script.embedModel {
+			return
+		}
+		if score := cosine(script.embedding, e.Embedding); best == nil || score > best.Score {
+			best = &Similarity{JobID: e.JobID, Topic: e.Topic, Score: math.Round(score*1000) / 1000, Threshold: h.threshold}
+		}
+	})
+	return best
+}
+
+// duplicate is the job's script similarity warning, if any.
+func (j *Job) duplicate() *Similarity {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.script == nil {
+		return nil
+	}
+	return j.script.Similar
+}
+
+// rememberScript adds the job's script to the caller's history.
+func rememberScript(form Form, job *Job, topic string) {
+	h, err := channelFromForm(form)
+	job.mu.Lock()
+	script := job.script
+	job.mu.Unlock()
+	if err != nil || h == nil || script == nil {
+		return
+	}
+	if script.embedding == nil {
+		script.embedding, script.embedModel = embedScript(scriptText(*script))
+	}
+	entry := channelScript{JobID: job.ID, Topic: topic, Created: time.Now().UTC().Format(time.RFC3339),
+		Model: script.embedModel, Embedding: script.embedding}
+
+	channelMu.Lock()
+	defer channelMu.Unlock()
+	var kept []channelScript
+	readJSONLines(h.file, func(e channelScript) { kept = append(kept, e) })
+	kept = append(kept, entry)
+	kept = kept[max(0, len(kept)-maxChannelScripts):]
+	os.MkdirAll(channelDir(), 0755)
+	f, err := os.Create(h.file)
+	if err != nil {
+		fmt.Printf("⚠️ Script not remembered: %v\n", err)
+		return
+	}
+	defer f.Close()
+	for _, e := range kept {
+		line, _ := json.Marshal(e)
+		f.Write(append(line, '\n'))
+	}
+}
+
+// embedScript embeds text with the configured model, or locally.
+func embedScript(text string) ([]float32, string) {
+	vectors, model := embedTexts([]string{text})
+	return vectors[0], model
+}
+
+// embedTexts embeds texts in one call, all with the same model.
+func embedTexts(texts []string) ([][]float32, string) {
+	local := func() ([][]float32, string) {
+		vectors := make([][]float32, len(texts))
+		for i, t := range texts {
+			vectors[i] = hashedWords(t)
+		}
+		return vectors, localEmbedding
+	}
+	key := os.Getenv("EMBEDDINGS_API_KEY")
+	if key == "" {
+		return local()
+	}
+	model := os.Getenv("EMBEDDINGS_MODEL")
+	if model == "" {
+		model = string(openai.SmallEmbedding3)
+	}
+	config := openai.DefaultConfig(key)
+	if base := os.Getenv("EMBEDDINGS_BASE_URL"); base != "" {
+		config.BaseURL = base
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	input := make([]string, len(texts))
+	for i, t := range texts {
+		input[i] = strings.Join(strings.Fields(t), " ")
+	}
+	resp, err := openai.NewClientWithConfig(config).CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: input, Model: openai.EmbeddingModel(model)})
+	if err != nil || len(resp.Data) != len(texts) {
+		fmt.Printf("⚠️ Embedding failed, using word hashes: %v\n", err)
+		return local()
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	return vectors, model
+}
+
+// hashedWords counts words and word pairs into 512 hashed buckets.
+func hashedWords(text string) []float32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	v := make([]float32, 512)
+	add := func(s string) {
+		h := fnv.New32a()
+		h.Write([]byte(s))
+		v[h.Sum32()%512]++
+	}
+	for i, w := range words {
+		add(w)
+		if i > 0 {
+			add(words[i-1] + " " + w)
+		}
+	}
+	return v
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
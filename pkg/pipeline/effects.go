@@ -0,0 +1,366 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- EFFECTS & TRANSITIONS ---
+var sceneEffects = map[string]bool{"none": true, "zoom-in": true, "shake": true, "glitch": true, "grayscale": true}
+
+// Subset of ffmpeg xfade transitions; "cut" (or empty) is a hard cut.
+var sceneTransitions = map[string]bool{
+	"cut": true, "fade": true, "fadeblack": true, "fadewhite": true, "dissolve": true,
+	"wipeleft": true, "wiperight": true, "slideleft": true, "slideright": true,
+	"circleopen": true, "circleclose": true, "pixelize": true, "radial": true, "zoomin": true,
+}
+
+const transitionDuration = 0.5
+
+// Color grades applied identically to every segment of a video.
+var colorLooks = map[string]string{
+	"cinematic": "curves=r='0/0 0.5/0.46 1/0.96':g='0/0 0.5/0.5 1/1':b='0/0.06 0.5/0.54 1/1',eq=contrast=1.08:saturation=0.85",
+	"vibrant":   "eq=contrast=1.05:saturation=1.45:gamma=1.02",
+	"vintage":   "curves=preset=vintage,eq=saturation=0.8",
+	"noir":      "hue=s=0,eq=contrast=1.3:brightness=-0.03",
+	"warm":      "colortemperature=temperature=5200,eq=saturation=1.1",
+	"cool":      "colortemperature=temperature=8000",
+}
+
+// renderOptionsFromForm reads the request-wide render settings shared by all generation endpoints.
+func renderOptionsFromForm(f Form, job *Job, videoType string) (RenderOptions, error) {
+	opts := RenderOptions{
+		VideoType:  videoType,
+		Transition: strings.ToLower(strings.TrimSpace(f.PostForm("transition"))),
+		Effect:     strings.ToLower(strings.TrimSpace(f.PostForm("effect"))),
+		Fit:        strings.ToLower(strings.TrimSpace(f.PostForm("fit"))),
+		Look:       strings.ToLower(strings.TrimSpace(f.PostForm("look"))),
+		Avatar:     strings.ToLower(strings.TrimSpace(f.PostForm("avatar"))),
+		AvatarID:   strings.TrimSpace(f.PostForm("avatar_id")),
+		Timings:    job.timings,
+	}
+	if err := checkStyle("request", opts.Effect, opts.Transition, opts.Fit); err != nil {
+		return opts, err
+	}
+	if opts.Look != "" && opts.Look != "none" && colorLooks[opts.Look] == "" {
+		return opts, fmt.Errorf("unknown look %q", opts.Look)
+	}
+	if ms := f.PostForm("sentence_gap_ms"); ms != "" {
+		v, err := strconv.Atoi(ms)
+		if err != nil || v < 0 || v > 2000 {
+			return opts, fmt.Errorf("sentence_gap_ms must be between 0 and 2000")
+		}
+		opts.SentenceGap = float64(v) / 1000
+	}
+	opts.TTSProvider = strings.ToLower(strings.TrimSpace(f.DefaultPostForm("tts_provider", "google")))
+	if !ttsProviders[opts.TTSProvider] {
+		return opts, fmt.Errorf("unknown tts_provider %q", opts.TTSProvider)
+	}
+	opts.VoiceID = strings.TrimSpace(f.PostForm("voice_id"))
+	if err := checkVoiceID(opts.VoiceID); err != nil {
+		return opts, err
+	}
+	if v := f.PostForm("voice_wpm"); v != "" {
+		wpm, err := strconv.Atoi(v)
+		if err != nil || wpm < 80 || wpm > 300 {
+			return opts, fmt.Errorf("voice_wpm must be between 80 and 300")
+		}
+		opts.VoiceWPM = float64(wpm)
+	}
+	opts.Locale = strings.ToLower(strings.TrimSpace(f.PostForm("locale")))
+	if _, ok := googleLocales[opts.Locale]; opts.Locale != "" && !ok && opts.TTSProvider == "google" {
+		return opts, fmt.Errorf("unsupported locale %q", opts.Locale)
+	}
+
+	opts.TTSKey = f.GetHeader("X-ElevenLabs-Key")
+	opts.Lexicon = requestLexicon(f)
+	opts.StrictTTS = f.PostForm("strict_tts") == "true"
+	if opts.Avatar != "" && !avatarProviders[opts.Avatar] {
+		return opts, fmt.Errorf("unknown avatar provider %q", opts.Avatar)
+	}
+
+	opts.Timer = strings.ToLower(strings.TrimSpace(f.PostForm("timer")))
+	if v := f.PostForm("timer_from"); v != "" {
+		opts.TimerFrom, _ = strconv.ParseFloat(v, 64)
+		if opts.TimerFrom <= 0 {
+			return opts, fmt.Errorf("timer_from must be between 0 and %d seconds", maxTimerFrom)
+		}
+	}
+	if err := checkTimer("request", opts.Timer, opts.TimerFrom); err != nil {
+		return opts, err
+	}
+
+	if v := f.PostForm("fps"); v != "" {
+		opts.FPS, _ = strconv.Atoi(v)
+		if !frameRates[opts.FPS] {
+			return opts, fmt.Errorf("fps must be 24, 25, 30 or 60")
+		}
+	}
+
+	opts.Captions = f.PostForm("captions") == "true"
+	opts.Citations = f.PostForm("citations") == "true"
+	opts.UnsplashCredit = f.PostForm("unsplash_credit") == "true"
+	opts.BlurFaces = f.PostForm("blur_faces") == "true"
+	if opts.BlurFaces && os.Getenv("FACE_API_URL") == "" {
+		return opts, fmt.Errorf("blur_faces is not available on this server")
+	}
+	opts.BRoll = f.PostForm("broll") == "true"
+	if opts.BRoll && videoType != "long" {
+		return opts, fmt.Errorf("broll is only available for long videos")
+	}
+	if opts.BRoll && os.Getenv("PEXELS_API_KEY") == "" {
+		return opts, fmt.Errorf("broll is not available on this server")
+	}
+	if err := checkAmbience(strings.ToLower(strings.TrimSpace(f.PostForm("ambience")))); err != nil {
+		return opts, err
+	}
+	opts.CaptionStyle = strings.ToLower(strings.TrimSpace(f.PostForm("caption_style")))
+	if _, ok := captionPresets[opts.CaptionStyle]; opts.CaptionStyle != "" && !ok {
+		return opts, fmt.Errorf("unknown caption_style %q", opts.CaptionStyle)
+	}
+	opts.Platform = strings.ToLower(strings.TrimSpace(f.PostForm("platform")))
+	if _, ok := platformSafeAreas[opts.Platform]; opts.Platform != "" && !ok {
+		return opts, fmt.Errorf("unknown platform %q", opts.Platform)
+	}
+	if f.PostForm("title_cards") == "true" {
+		opts.TitleCard = 1.5
+		if v := f.PostForm("title_card_secs"); v != "" {
+			secs, err := strconv.ParseFloat(v, 64)
+			if err != nil || secs < minTitleCard || secs > maxTitleCard {
+				return opts, fmt.Errorf("title_card_secs must be between %.0f and %.0f", minTitleCard, maxTitleCard)
+			}
+			opts.TitleCard = secs
+		}
+	}
+
+	card, err := cardStyleFromForm(f)
+	if err != nil {
+		return opts, err
+	}
+	opts.Card = card
+
+	if file, err := f.FormFile("sting"); err == nil {
+		if !isVideoFile(file.Filename) {
+			return opts, fmt.Errorf("sting must be a video file")
+		}
+		if opts.StingPath, err = saveMediaUpload(job, file, ".mp4"); err != nil {
+			return opts, fmt.Errorf("could not save sting: %v", err)
+		}
+	} else if id := strings.TrimSpace(f.PostForm("sting_asset")); id != "" {
+		if opts.StingPath, err = assetPath(id, job.owner); err != nil {
+			return opts, fmt.Errorf("sting_asset: %v", err)
+		}
+		job.recordAsset(opts.StingPath, id)
+	} else if f.PostForm("sting") == "template" {
+		opts.StingPath = "template"
+		opts.BrandName = strings.TrimSpace(f.DefaultPostForm("brand_name", f.PostForm("topic")))
+		if opts.BrandName == "" {
+			return opts, fmt.Errorf("template sting needs brand_name")
+		}
+	}
+
+	if file, err := f.FormFile("lut"); err == nil {
+		if strings.ToLower(filepath.Ext(file.Filename)) != ".cube" {
+			return opts, fmt.Errorf("lut must be a .cube file")
+		}
+		opts.LUTPath = job.MediaPath(".cube")
+		if err := saveUpload(file, opts.LUTPath); err != nil {
+			return opts, fmt.Errorf("could not save lut: %v", err)
+		}
+	}
+
+	if file, err := f.FormFile("pip"); err == nil {
+		if !isVideoFile(file.Filename) {
+			return opts, fmt.Errorf("pip must be a video file")
+		}
+		if opts.PiPPath, err = saveMediaUpload(job, file, ".mp4"); err != nil {
+			return opts, fmt.Errorf("could not save pip: %v", err)
+		}
+		opts.PiPDuration, _ = probeDuration(opts.PiPPath)
+		opts.PiPPosition = strings.ToLower(strings.TrimSpace(f.DefaultPostForm("pip_position", "top-right")))
+		switch opts.PiPPosition {
+		case "top-left", "top-right", "bottom-left", "bottom-right":
+		default:
+			return opts, fmt.Errorf("unknown pip_position %q", opts.PiPPosition)
+		}
+		opts.PiPSize, _ = strconv.Atoi(f.DefaultPostForm("pip_size", "30"))
+		if opts.PiPSize < 10 || opts.PiPSize > 50 {
+			return opts, fmt.Errorf("pip_size must be between 10 and 50")
+		}
+	}
+	return opts, nil
+}
+
+func checkStyle(where, effect, transition, fit string) error {
+	if effect != "" && !sceneEffects[effect] {
+		return fmt.Errorf("%s: unknown effect %q", where, effect)
+	}
+	if transition != "" && !sceneTransitions[transition] {
+		return fmt.Errorf("%s: unknown transition %q", where, transition)
+	}
+	if fit != "" && !fitModes[fit] {
+		return fmt.Errorf("%s: unknown fit %q", where, fit)
+	}
+	return nil
+}
+
+func validateSceneStyles(scenes []SceneData) error {
+	for i := range scenes {
+		scenes[i].Effect = strings.ToLower(strings.TrimSpace(scenes[i].Effect))
+		scenes[i].Transition = strings.ToLower(strings.TrimSpace(scenes[i].Transition))
+		scenes[i].Fit = strings.ToLower(strings.TrimSpace(scenes[i].Fit))
+		if err := checkStyle(fmt.Sprintf("scene %d", i), scenes[i].Effect, scenes[i].Transition, scenes[i].Fit); err != nil {
+			return err
+		}
+		scenes[i].Timer = strings.ToLower(strings.TrimSpace(scenes[i].Timer))
+		if err := checkTimer(fmt.Sprintf("scene %d", i), scenes[i].Timer, scenes[i].TimerFrom); err != nil {
+			return err
+		}
+		scenes[i].VideoURL = strings.TrimSpace(scenes[i].VideoURL)
+		if err := checkVideoURL(fmt.Sprintf("scene %d", i), scenes[i].VideoURL); err != nil {
+			return err
+		}
+		if err := checkKeyColor(fmt.Sprintf("scene %d", i), scenes[i].KeyColor); err != nil {
+			return err
+		}
+		if scenes[i].VideoStart < 0 {
+			return fmt.Errorf("scene %d: video_start must not be negative", i)
+		}
+		if err := checkSceneLocale(i, &scenes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sceneRenderOptions resolves the options for each scene from base plus its overrides.
+func sceneRenderOptions(base RenderOptions, scenes []SceneData) []RenderOptions {
+	out := make([]RenderOptions, len(scenes))
+	for i, s := range scenes {
+		opts := base
+		if s.Effect != "" {
+			opts.Effect = s.Effect
+		}
+		if s.Transition != "" {
+			opts.Transition = s.Transition
+		}
+		if s.Fit != "" {
+			opts.Fit = s.Fit
+		}
+		if s.Timer != "" {
+			opts.Timer = s.Timer
+		}
+		if s.TimerFrom > 0 {
+			opts.TimerFrom = s.TimerFrom
+		}
+		opts.KeyColor = s.KeyColor
+		opts.PresenterPosition = s.PresenterPosition
+		if s.Locale != "" {
+			opts.Locale = s.Locale
+		}
+		if s.VoiceID != "" {
+			opts.VoiceID = s.VoiceID
+		}
+		if s.PiP != nil && !*s.PiP {
+			opts.PiPPath = ""
+		}
+		out[i] = opts
+	}
+	return out
+}
+
+// gradeFilter returns the color grade for a segment; an uploaded LUT wins over a preset look.
+func gradeFilter(opts RenderOptions) string {
+	if opts.LUTPath != "" {
+		return "lut3d=file=" + escapeFilterArg(opts.LUTPath)
+	}
+	return colorLooks[opts.Look]
+}
+
+// effectFilter returns the -vf fragment for an effect on a w x h frame at fps.
+func effectFilter(effect string, w, h int, fps string) string {
+	switch effect {
+	case "zoom-in":
+		return fmt.Sprintf("zoompan=z='min(max(zoom,pzoom)+0.0015,1.4)':d=1:x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':s=%dx%d:fps=%s", w, h, fps)
+	case "shake":
+		return fmt.Sprintf("crop=%d:%d:24+20*sin(n*1.7):24+20*cos(n*2.3),scale=%d:%d", w-48, h-48, w, h)
+	case "glitch":
+		return "rgbashift=rh=-8:bh=8:edge=wrap,noise=alls=18:allf=t"
+	case "grayscale":
+		return "hue=s=0"
+	}
+	return ""
+}
+
+// Stitch methods, reported with each job.
+const (
+	stitchCopy     = "copy"     // concat demuxer, streams copied
+	stitchReencode = "reencode" // filter concat, after the stream copy failed
+	stitchXfade    = "xfade"    // filter graph with transitions
+)
+
+// stitchSegments joins segments, using xfade where a transition was requested
+// and the stream-copy concat when every joint is a hard cut. If the copy
+// fails the segments are re-encoded through the filter graph instead.
+func stitchSegments(segments []Segment, outputFile string) (string, error) {
+	files := make([]string, len(segments))
+	hasTransition := false
+	for i, s := range segments {
+		files[i] = s.Path
+		if i > 0 && s.Transition != "" && s.Transition != "cut" {
+			hasTransition = true
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no video segments were created")
+	}
+	files, format, err := conformSegments(files, filepath.Dir(outputFile))
+	if err != nil {
+		return "", err
+	}
+	if hasTransition {
+		return stitchXfade, stitchFiltered(segments, files, format, outputFile)
+	}
+	if err := stitchVideos(files, outputFile); err != nil {
+		fmt.Printf("⚠️ Stream-copy concat failed, re-encoding: %v\n", err)
+		return stitchReencode, stitchFiltered(segments, files, format, outputFile)
+	}
+	return stitchCopy, nil
+}
+
+// stitchFiltered joins the files in one filter graph, scaling every input to
+// the canonical frame so mismatched parameters can't break the concat.
+func stitchFiltered(segments []Segment, files []string, format segmentFormat, outputFile string) error {
+	var tl Timeline
+	for i, f := range files {
+		d, err := probeDuration(f)
+		if err != nil {
+			return fmt.Errorf("probe failed: %v", err)
+		}
+		idx := tl.Input(f)
+		transition := ""
+		if i > 0 {
+			transition = segments[i].Transition
+		}
+		tl.Video.Clips = append(tl.Video.Clips, Clip{Input: idx, Duration: d, Transition: transition,
+			Filter: fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,format=yuv420p,settb=AVTB,fps=%s",
+				format.Width, format.Height, format.Width, format.Height, format.FrameRate)})
+		tl.Narration.Clips = append(tl.Narration.Clips, Clip{Input: idx, Duration: d, Transition: transition, Filter: "aresample=44100"})
+	}
+
+	compiled, err := tl.Args()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-y"}, compiled...)
+	args = append(args, "-c:v", "libx264", "-preset", "ultrafast", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", outputFile)
+	output, err := ffmpegCommand(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Stitch Error: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
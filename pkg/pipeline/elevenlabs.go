@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- ELEVENLABS VOICES ---
+// Callers may pass their own key in X-ElevenLabs-Key so that cloned voices
+// registered under their account are usable; otherwise narration uses the
+// server's ELEVENLABS_API_KEY. Listing and adding voices always needs the
+// caller's key, so nobody can read or fill the operator's voice library.
+const elevenLabsBase = "https://api.elevenlabs.io/v1"
+
+// voiceIDPattern is what ElevenLabs voice IDs look like; anything else is
+// refused before it reaches the request URL.
+var voiceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type ElevenLabsVoice struct {
+	VoiceId  string `json:"voice_id"`
+	Name     string `json:"name"`
+	Category string `json:"category"` // "cloned", "premade", ...
+}
+
+func elevenLabsKey(callerKey string) string {
+	if callerKey != "" {
+		return callerKey
+	}
+	return os.Getenv("ELEVENLABS_API_KEY")
+}
+
+func checkVoiceID(id string) error {
+	if id != "" && !voiceIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid voice_id %q", id)
+	}
+	return nil
+}
+
+func elevenLabsTTS(text, outFile, voiceID, callerKey string) error {
+	apiKey := elevenLabsKey(callerKey)
+	if apiKey == "" {
+		return fmt.Errorf("missing ELEVENLABS_API_KEY")
+	}
+	if voiceID == "" {
+		voiceID = os.Getenv("ELEVENLABS_VOICE_ID")
+	}
+	if voiceID == "" {
+		return fmt.Errorf("missing voice_id")
+	}
+	if err := checkVoiceID(voiceID); err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]string{"text": text, "model_id": "eleven_multilingual_v2"})
+	req, _ := http.NewRequest("POST", elevenLabsBase+"/text-to-speech/"+url.PathEscape(voiceID)+"?output_format=mp3_44100_128", bytes.NewReader(body))
+	req.Header.Set("xi-api-key", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/mpeg")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(msg))
+	}
+
+	out, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// GET /voices
+func handleListVoices(c *gin.Context) {
+	apiKey := c.GetHeader("X-ElevenLabs-Key")
+	if apiKey == "" {
+		c.JSON(401, gin.H{"error": "X-ElevenLabs-Key header is required"})
+		return
+	}
+	var res struct {
+		Voices []ElevenLabsVoice `json:"voices"`
+	}
+	auth := func(req *http.Request) { req.Header.Set("xi-api-key", apiKey) }
+	if err := doJSON("GET", elevenLabsBase+"/voices", nil, auth, &res); err != nil {
+		c.JSON(502, gin.H{"error": "ElevenLabs: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"voices": res.Voices})
+}
+
+// POST /voices registers a cloned voice from uploaded samples (form: name, samples[]).
+func handleAddVoice(c *gin.Context) {
+	apiKey := c.GetHeader("X-ElevenLabs-Key")
+	if apiKey == "" {
+		c.JSON(401, gin.H{"error": "X-ElevenLabs-Key header is required"})
+		return
+	}
+	name := c.PostForm("name")
+	form, err := c.MultipartForm()
+	if name == "" || err != nil || len(form.File["samples"]) == 0 {
+		c.JSON(400, gin.H{"error": "Provide name and at least one samples file"})
+		return
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", name)
+	if desc := c.PostForm("description"); desc != "" {
+		mw.WriteField("description", desc)
+	}
+	for _, fh := range form.File["samples"] {
+		f, err := fh.Open()
+		if err != nil {
+			continue
+		}
+		part, _ := mw.CreateFormFile("files", filepath.Base(fh.Filename))
+		io.Copy(part, f)
+		f.Close()
+	}
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", elevenLabsBase+"/voices/add", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("xi-api-key", apiKey)
+	var created struct {
+		VoiceId string `json:"voice_id"`
+	}
+	if err := sendJSON(req, &created); err != nil {
+		c.JSON(502, gin.H{"error": "ElevenLabs: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "voice_id": created.VoiceId})
+}
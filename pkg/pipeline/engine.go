@@ -0,0 +1,894 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- DATA STRUCTURES ---
+type SceneData struct {
+	Name       string `json:"name"`
+	Details    string `json:"details"`
+	Transition string `json:"transition,omitempty"` // into this scene, overrides the request default
+	Effect     string `json:"effect,omitempty"`
+	Fit        string `json:"fit,omitempty"` // pad, crop or smart
+
+	// Green-screen presenter (uploaded as greenscreen_<i>)
+	KeyColor          string `json:"key_color,omitempty"`          // green, blue or 0xRRGGBB
+	PresenterPosition string `json:"presenter_position,omitempty"` // center, left, right
+
+	PiP *bool `json:"pip,omitempty"` // false hides the request's PiP overlay on this scene
+
+	Timer     string  `json:"timer,omitempty"`      // countdown, stopwatch or none, see timerKinds
+	TimerFrom float64 `json:"timer_from,omitempty"` // countdown over the scene's last N seconds
+
+	// Narration language, see codeswitch.go
+	Locale  string `json:"locale,omitempty"`   // overrides the request's locale, e.g. hi
+	VoiceID string `json:"voice_id,omitempty"` // overrides the request's voice_id
+
+	// Artwork lookup, e.g. a list mixing films and actors
+	Category    string `json:"category,omitempty"`     // overrides the request's category (movie, tv, actor)
+	SearchQuery string `json:"search_query,omitempty"` // search text instead of the scene name
+
+	// Source clip for reaction and commentary scenes, see remotevideo.go
+	VideoURL   string  `json:"video_url,omitempty"`   // YouTube or Vimeo link, used when media_<i> is not uploaded
+	VideoStart float64 `json:"video_start,omitempty"` // clip start in seconds
+}
+
+type ScriptItem struct {
+	Title   string `json:"title"`
+	Details string `json:"details"`
+	Visual  string `json:"visual,omitempty"` // what is on screen, searched for by stock and AI images
+
+	query string // article image search, kept with the item across retries
+}
+
+type ScriptResponse struct {
+	Intro string       `json:"intro"`
+	Items []ScriptItem `json:"items"`
+	Outro string       `json:"outro"`
+	Mood  string       `json:"mood,omitempty"` // suggested music mood, see musicMoods
+
+	Readability *Readability    `json:"readability,omitempty"` // set when an audience was requested
+	Hooks       []HookCandidate `json:"hooks,omitempty"`       // scored intro candidates, best first
+	Similar     *Similarity     `json:"similar,omitempty"`     // close to an earlier video of the caller's, see duplicates.go
+	Consensus   *Consensus      `json:"consensus,omitempty"`   // judged drafts of two models, see consensus.go
+
+	embedding  []float32 // see ChannelHistory.check
+	embedModel string
+}
+
+type TMDBSearchResponse struct {
+	Results []struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"` // movie results
+		Name        string `json:"name"`  // tv and person results
+		PosterPath  string `json:"poster_path"`
+		ProfilePath string `json:"profile_path"` // person results
+	} `json:"results"`
+}
+
+// NewRouter builds the HTTP API. The generation routes serve the generators
+// Pipeline.Generate calls in-process.
+func NewRouter() *gin.Engine {
+	r := gin.Default()
+	r.Static("/videos", "./output")
+	r.Static("/music/files", musicDir())
+	limits := limitsFromEnv()
+	r.MaxMultipartMemory = limits.Memory
+	r.Use(limitUploads(limits), applyBrandKit, enforceBlocklist(blocklistFromEnv()), jobEvents)
+
+	for route, generator := range generators {
+		r.POST(route, serve(generator))
+	}
+	r.GET("/voices", handleListVoices)
+	r.POST("/voices", handleAddVoice)
+	r.GET("/assets", handleListAssets)
+	r.GET("/music", handleListMusic)
+	r.POST("/brand-kits", handleCreateBrandKit)
+	r.GET("/brand-kits/:id", handleGetBrandKit)
+	r.POST("/script-templates", handleCreateScriptTemplate)
+	r.GET("/script-templates", handleListScriptTemplates)
+	r.GET("/script-templates/:id", handleGetScriptTemplate)
+	r.POST("/series/:id", handleUpdateSeries)
+	r.GET("/series/:id", handleGetSeries)
+	r.POST("/v1/quick-video", handleQuickVideo)
+	r.GET("/v1/jobs/:id", handleGetJob)
+	r.GET("/v1/jobs/:id/spec", handleGetSpec)
+	r.POST("/v1/jobs/:id/retry", handleRetryJob)
+	r.GET("/v1/jobs/:id/versions", handleListVersions)
+	r.GET("/v1/jobs/:id/licenses", handleGetLicenses)
+	r.GET("/v1/jobs/:id/script", handleGetScript)
+	r.POST("/v1/jobs/:id/script/reorder", handleReorderScript)
+	r.POST("/v1/jobs/:id/script/items", handleInsertScriptItem)
+	r.DELETE("/v1/jobs/:id/script/items/:index", handleDeleteScriptItem)
+	r.POST("/v1/jobs/:id/script/regenerate", handleRegenerateScript)
+	r.POST("/v1/jobs/:id/rerender", handleRerender)
+	r.POST("/v1/render-spec", handleRenderSpec)
+	r.POST("/connections", handleCreateConnection)
+	r.GET("/connections/:id", handleGetConnection)
+	r.POST("/videos/:id/publish", handlePublishVideo)
+	r.PUT("/notifications", handleSetNotifications)
+	r.GET("/notifications", handleGetNotifications)
+	r.PUT("/pronunciations", handleSetPronunciations)
+	r.GET("/pronunciations", handleGetPronunciations)
+	return r
+}
+
+func handleMultiSceneVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Request Received")
+
+	topic := r.PostForm("topic")
+	category := r.PostForm("category")
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	scenesJson := r.PostForm("scenes")
+	var scenes []SceneData
+	if err := json.Unmarshal([]byte(scenesJson), &scenes); err != nil {
+		fmt.Println("❌ Error: Invalid JSON")
+		r.JSON(400, gin.H{"error": "Invalid scenes JSON"})
+		return
+	}
+	if limit := limitsFromEnv().MaxScenes; len(scenes) > limit {
+		r.JSON(422, gin.H{"error": fmt.Sprintf("Too many scenes (max %d)", limit)})
+		return
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err == nil {
+		err = validateSceneStyles(scenes)
+	}
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Items: %d\n", topic, videoType, len(scenes))
+
+	sources, err := mediaSourcesFromForm(r, sceneMediaSources)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	media := []MediaRequest{
+		{FormKey: "media_intro", Query: topic, Text: topic},
+		{FormKey: "media_outro", Text: "Thanks for watching!"},
+	}
+	for i, s := range scenes {
+		m := MediaRequest{FormKey: fmt.Sprintf("media_%d", i), Query: s.Name, Text: s.Name, Index: i + 1, Category: category}
+		if q := strings.TrimSpace(s.SearchQuery); q != "" {
+			m.Query = q
+		}
+		if cat := strings.ToLower(strings.TrimSpace(s.Category)); cat != "" {
+			m.Category = cat
+		}
+		m.VideoURL, m.VideoStart = s.VideoURL, s.VideoStart
+		m.Context = strings.TrimSpace(s.Name + ". " + s.Details)
+		media = append(media, m)
+	}
+
+	// --- AI SCRIPT ---
+	fmt.Println("🔹 STEP 2: Generating Script (Groq)...")
+	job.Stage("script")
+	scriptData, err := generateSegmentedScript(job, topic, category, videoType, scenes, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	factCheck, ok := runFactCheck(r, job, topic, scriptData)
+	if !ok {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	// Save Media, searched by the script's visual direction unless the scene has a search_query
+	for i, item := range scriptData.Items {
+		if i < len(scenes) && strings.TrimSpace(scenes[i].SearchQuery) == "" {
+			media[i+2].Visual = strings.TrimSpace(item.Visual)
+		}
+	}
+	job.Stage("media")
+	paths := make([]string, len(media))
+	for i, m := range media {
+		if paths[i], err = resolveMedia(r, job, sources, m, videoType, base.Card); err != nil {
+			r.JSON(422, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	introPath, outroPath, scenePaths := paths[0], paths[1], paths[2:]
+
+	// --- RENDER ---
+	fmt.Println("🔹 STEP 3: Rendering Segments...")
+	job.Stage("render")
+	finish := finishOptionsFromForm(r, job, videoType)
+	applyMood(job, &finish, scriptData.Mood)
+	if finish.MusicPath != "" && r.PostForm("beat_sync") == "true" {
+		base.Beats = detectBeats(finish.MusicPath)
+		fmt.Printf("🥁 Beat sync: %d beats detected\n", len(base.Beats))
+	}
+	sceneOpts := sceneRenderOptions(base, scenes)
+	for i := range scenes {
+		if file, err := r.FormFile(fmt.Sprintf("greenscreen_%d", i)); err == nil {
+			if keyPath, err := saveMediaUpload(job, file, ".mp4"); err == nil {
+				sceneOpts[i].ChromaKeyPath = keyPath
+			}
+		}
+	}
+	offerPreview(r, job, scriptData, introPath, scenePaths, outroPath, base, sceneOpts)
+	segments, err := renderScript(job, scriptData, introPath, scenePaths, outroPath, base, sceneOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (TTS): %v\n", err)
+		r.JSON(502, gin.H{"error": "Narration incomplete: " + err.Error(), "narration_lost": job.LostNarration()})
+		return
+	}
+	segments = withSting(segments, base)
+
+	// --- STITCH ---
+	fmt.Println("🔹 STEP 4: Stitching Video...")
+	job.Stage("stitch")
+	finalVideo := job.Path("final_movie.mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	offerStream(r, job, finalVideo)
+	finish.Title, finish.Chapters = topic, segmentChapters(segments)
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Video Ready.")
+	videoUrl := job.URL(r.base, finalVideo)
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": videoUrl, "stitch": stitch,
+		"narration_url": narrationURL(r.base, job, finish), "thumbnails": thumbnailURLs(r.base, job, finish),
+		"teaser_url": teaserURL(r.base, job, finish), "size_budget": finish.SizeBudget, "fact_check": factCheck, "output": output, "segments": segmentInfo,
+		"chapters": finish.Chapters, "chapters_text": youtubeChapters(finish.Chapters)})
+}
+
+// --- 1. AI BRAIN ---
+func newGroqClient() (*openai.Client, error) {
+	apiKey := os.Getenv("GROQ_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("missing GROQ_API_KEY")
+	}
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = "https://api.groq.com/openai/v1"
+	return openai.NewClientWithConfig(config), nil
+}
+
+func generateSegmentedScript(job *Job, topic, category, videoType string, scenes []SceneData, opts ScriptOptions) (ScriptResponse, error) {
+	itemsContext := ""
+	for i, s := range scenes {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("Item %d", i+1)
+		}
+		itemsContext += fmt.Sprintf("\nItem %d: %s\nDetails: %s%s\n", i+1, name, s.Details, sceneLanguageNote(s))
+	}
+
+	minWords, maxWords := wordRange(videoType)
+
+	return generateForAudience(opts, func(audience string) (ScriptResponse, error) {
+		prompt := fmt.Sprintf(`
+    Topic: "%s" (%s mode)
+    Tone: Engaging and professional.%s
+    Constraint: Each item must be between %d and %d words to ensure duration.
+    For each item also give "visual": what should be on screen while it is narrated, as a concrete
+    description of one photo (subject, setting, light) in under 15 words, not the item's name.
+    INPUT ITEMS:
+    %s
+    RETURN JSON ONLY:
+    {
+        "intro": "Hook around 35 words",
+        "items": [
+            { "title": "Title", "details": "Script text between %d and %d words...", "visual": "Photo description" }
+        ],
+        "outro": "Conclusion around 35 words",
+        "mood": "one of: %s"
+    }
+    `, topic, videoType, audience, minWords, maxWords, itemsContext, minWords, maxWords, strings.Join(musicMoods, ", "))
+
+		var result ScriptResponse
+		if err := completeTemplated(job, "script", opts.Template, prompt, &result); err != nil {
+			return ScriptResponse{}, err
+		}
+		return result, nil
+	})
+}
+
+func wordRange(videoType string) (int, int) {
+	if videoType == "long" {
+		return 95, 120 // For ~45s per scene
+	}
+	return 20, 30
+}
+
+// --- 2. RENDER ENGINE ---
+type RenderOptions struct {
+	VideoType    string
+	FPS          int    // output frame rate, see frameRates; 0 is defaultFPS
+	SubtitlePath string // optional .ass captions burned into the segment
+	Captions     bool   // caption the narration (see captionCues) when SubtitlePath is unset
+	CaptionStyle string // see captionPresets; empty is bold-outline
+	Platform     string // see platformSafeAreas; keeps overlays clear of the app's UI
+	Effect       string // see sceneEffects
+	Fit          string // see fitModes; empty pads
+	BlurFaces    bool   // blur faces in the media first, see blurFaces
+	BRoll        bool   // cut in stock clips at sentence boundaries, see broll.go
+	Transition   string // xfade transition into the segment, applied when stitching
+	Look         string // see colorLooks
+	LUTPath      string // uploaded .cube LUT, overrides Look
+
+	ChromaKeyPath     string // green-screen presenter clip composited over the media
+	KeyColor          string
+	PresenterPosition string
+
+	Avatar         string // avatar provider (d-id, heygen); empty disables
+	AvatarID       string // provider avatar / source portrait
+	AvatarClipPath string // set per segment once the talking head is rendered
+
+	PiPPath     string // picture-in-picture clip shown in a corner
+	PiPPosition string // top-left, top-right, bottom-left, bottom-right
+	PiPSize     int    // percent of frame width
+	PiPDuration float64
+	PiPOffset   float64 // where this segment picks up in the PiP clip
+
+	BRollClips []string   // the segment's share of its item's B-roll clips
+	BRollCuts  []brollCut // set per segment once the narration is timed
+
+	Beats []float64 // music beat times; segment ends are held to the next beat
+
+	TitleCard float64 // seconds of title card before each scene, 0 disables
+
+	SentenceGap float64 // seconds of silence between narrated sentences
+
+	TTSProvider string  // google (default) or elevenlabs
+	VoiceID     string  // provider voice, e.g. an ElevenLabs cloned voice
+	TTSKey      string  // caller's provider API key, falls back to the server's
+	Locale      string  // narration language/accent, e.g. en-gb, hi (see googleLocales)
+	VoiceWPM    float64 // the voice's speaking rate for estimates, 0 uses the provider's
+	Lexicon     Lexicon // respellings applied before TTS, see pronounce.go
+
+	Card CardStyle // placeholder card design for scenes without media
+
+	StingPath string // brand sting clip before the intro, or "template"
+	BrandName string // text of the template sting
+
+	Timings *Timings // the job's, receives TTS and encode times; may be nil
+
+	Preview       bool // 360p/15fps preview twin of the segment, see offerPreview
+	KeepNarration bool // leave the narration for a retry, see renderWithFallbacks
+	StrictTTS     bool // any lost narration fails the job, see NarrationGap
+
+	Citations      bool   // credit scene media on screen, see Source
+	UnsplashCredit bool   // credit Unsplash photos only, see unsplash.go
+	Citation       string // set per segment from the job's sources
+
+	Timer       string  // countdown or stopwatch overlay, see timerKinds
+	TimerFrom   float64 // countdown length in seconds, 0 is the whole segment
+	TimerOffset float64 // stopwatch time already shown by the item's earlier beats
+}
+
+type Segment struct {
+	Path       string
+	Transition string
+	Title      string  // chapter title
+	Duration   float64 // measured, 0 if unknown
+	Fallback   string  // degraded retry that produced it, see renderWithFallbacks
+}
+
+func renderSegment(text, mediaPath, outputPath string, opts RenderOptions) error {
+	audioPath := narrationPath(outputPath)
+
+	// The preview already narrated this segment: reuse its audio.
+	if info, err := os.Stat(audioPath); err != nil || info.Size() == 0 {
+		start := time.Now()
+		err := synthesizeSpeech(text, audioPath, opts)
+		opts.Timings.Record(outputPath, "tts", start)
+		if err != nil {
+			return err
+		}
+	}
+
+	// FIX: Validate Audio File Size
+	info, err := os.Stat(audioPath)
+	if err != nil || info.Size() == 0 {
+		return fmt.Errorf("audio file is empty (TTS blocked?)")
+	}
+
+	// Clean up if render fails; a preview keeps it for the full render.
+	if !opts.Preview && !opts.KeepNarration {
+		defer os.Remove(audioPath)
+	}
+
+	if opts.Captions && opts.SubtitlePath == "" {
+		assPath := strings.Replace(outputPath, ".mp4", ".ass", 1)
+		if d, err := probeDuration(audioPath); err == nil {
+			if err := writeASS(captionCues(text, d, captionWords(opts.VideoType)), assPath, opts); err == nil {
+				opts.SubtitlePath = assPath
+				defer os.Remove(assPath)
+			}
+		}
+	}
+
+	if len(opts.BRollClips) > 0 {
+		if d, err := probeDuration(audioPath); err == nil {
+			opts.BRollCuts = brollCuts(text, d, opts.BRollClips)
+		}
+	}
+
+	if opts.Avatar != "" {
+		clipPath := strings.Replace(outputPath, ".mp4", "_avatar.mp4", 1)
+		if err := renderAvatarClip(opts.Avatar, opts.AvatarID, audioPath, clipPath); err != nil {
+			fmt.Printf("⚠️ Avatar skipped for %s: %v\n", outputPath, err)
+		} else {
+			opts.AvatarClipPath = clipPath
+			defer os.Remove(clipPath)
+		}
+	}
+
+	return renderSegmentWithAudio(audioPath, mediaPath, outputPath, opts)
+}
+
+// renderSegmentWithAudio renders media over an existing narration track.
+func renderSegmentWithAudio(audioPath, mediaPath, outputPath string, opts RenderOptions) error {
+	defer opts.Timings.Record(outputPath, "encode", time.Now())
+	w, h := frameSize(opts.VideoType)
+	fps := strconv.Itoa(opts.frameRate())
+	if opts.Preview {
+		w, h = previewFrame(w, h)
+		fps = strconv.Itoa(previewFPS)
+	}
+	safe := safeMargins(opts.Platform, w, h)
+	isVideo := isVideoFile(mediaPath)
+	still := staticStill(mediaPath, opts)
+
+	var tl Timeline
+	media := 0
+	if isVideo {
+		media = tl.Input(mediaPath, "-stream_loop", "-1")
+	} else if still {
+		media = tl.Input(mediaPath, "-framerate", strconv.Itoa(stillInputRate), "-loop", "1")
+	} else {
+		media = tl.Input(mediaPath, "-loop", "1")
+	}
+	narration := tl.Input(audioPath)
+
+	// Background: the scene media fitted to the frame, plus its effect.
+	scale := fitFilter(mediaPath, w, h, opts.Fit)
+	if fx := effectFilter(opts.Effect, w, h, fps); fx != "" {
+		scale += "," + fx
+	}
+	tl.Video.Clips = []Clip{{Input: media, Filter: scale}}
+	tl.Narration.Clips = []Clip{{Input: narration}}
+
+	// Composited layers on top, each fed by an extra looping input.
+	for _, cut := range opts.BRollCuts {
+		tl.Overlays = append(tl.Overlays, Layer{Input: tl.Input(cut.Path, "-t", fmt.Sprintf("%.3f", cut.End-cut.Start)),
+			Compose: func(idx int, in, out string) string { return brollGraph(idx, in, out, cut, w, h) }})
+	}
+	if opts.ChromaKeyPath != "" {
+		tl.Overlays = append(tl.Overlays, Layer{Input: tl.Input(opts.ChromaKeyPath, "-stream_loop", "-1"),
+			Compose: func(idx int, in, out string) string { return chromaKeyGraph(idx, in, out, w, h, opts) }})
+	}
+	if opts.PiPPath != "" {
+		offset := opts.PiPOffset
+		if opts.PiPDuration > 0 {
+			offset = math.Mod(offset, opts.PiPDuration)
+		}
+		tl.Overlays = append(tl.Overlays, Layer{Input: tl.Input(opts.PiPPath, "-stream_loop", "-1", "-ss", fmt.Sprintf("%.3f", offset)),
+			Compose: func(idx int, in, out string) string {
+				return overlayGraph(idx, in, out, w, opts.PiPSize, opts.PiPPosition, safe)
+			}})
+	}
+	if opts.AvatarClipPath != "" {
+		tl.Overlays = append(tl.Overlays, Layer{Input: tl.Input(opts.AvatarClipPath),
+			Compose: func(idx int, in, out string) string { return overlayGraph(idx, in, out, w, 30, "bottom-right", safe) }})
+	}
+
+	// Finishing: grade the whole composite uniformly, then burn captions on top.
+	if grade := gradeFilter(opts); grade != "" {
+		tl.Captions = append(tl.Captions, grade)
+	}
+	tl.Captions = append(tl.Captions, "format=yuv420p")
+	if opts.Citation != "" {
+		textPath := strings.Replace(outputPath, ".mp4", "_cite.txt", 1)
+		if cite, err := citationFilter(opts.Citation, textPath, w, h, safe); err == nil {
+			tl.Captions = append(tl.Captions, cite)
+			defer os.Remove(textPath)
+		}
+	}
+	if still {
+		tl.Captions = append(tl.Captions, "fps="+fps) // static layers above, moving ones below
+	}
+	if opts.Timer != "" && opts.Timer != "none" {
+		textPath := strings.Replace(outputPath, ".mp4", "_timer.txt", 1)
+		d, _ := probeDuration(audioPath)
+		if timer, err := timerFilter(opts, d, textPath, w, h, safe); err == nil && timer != "" {
+			tl.Captions = append(tl.Captions, timer)
+			defer os.Remove(textPath)
+		}
+	}
+	if opts.SubtitlePath != "" {
+		tl.Captions = append(tl.Captions, subtitlesFilter(opts.SubtitlePath, opts.VideoType))
+	}
+
+	compiled, err := tl.Args()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-y"}, compiled...)
+	args = append(args, "-r", fps, "-c:v", "libx264")
+	if !isVideo && len(tl.Overlays) == 0 {
+		args = append(args, "-tune", "stillimage")
+	}
+	args = append(args, "-preset", "ultrafast",
+		"-c:a", "aac", "-b:a", "128k",
+		"-shortest", outputPath)
+
+	output, err := ffmpegCommand(args...).CombinedOutput()
+	if err != nil {
+		fmt.Printf("❌ FFmpeg Error: %s\n", string(output))
+		return err
+	}
+	return nil
+}
+
+// renderScript renders intro, items and outro and returns the segments that succeeded.
+// sceneOpts may be nil; when present, sceneOpts[i] replaces base for item i.
+func renderScript(job *Job, scriptData ScriptResponse, introPath string, scenePaths []string, outroPath string, base RenderOptions, sceneOpts []RenderOptions) ([]Segment, error) {
+	var segments []Segment
+	var specs []SegmentSpec // see saveSpec
+	var strictErr error     // first lost narration under strict_tts, stops the render
+	if !base.Preview {
+		job.setPlan(planScript(scriptData, base, sceneOpts))
+	}
+	sceneOpts = withBRoll(job, scriptData, base, sceneOpts)
+	elapsed := 0.0
+	_, maxWords := wordRange(base.VideoType)
+	path := func(name string) string {
+		if base.Preview {
+			return previewPath(job.Path(name))
+		}
+		return job.Path(name)
+	}
+	render := func(title, text, mediaPath, outPath string, opts RenderOptions) bool {
+		if strictErr != nil {
+			return false
+		}
+		opts.PiPOffset = elapsed
+		if s, ok := job.credit(mediaPath); ok && (opts.Citations || opts.UnsplashCredit && s.Provider == "Unsplash") {
+			opts.Citation = s.attribution()
+		}
+		spec := segmentSpec(title, text, mediaPath, 0, opts)
+		key := segmentKey(spec, opts)
+		fallback, reused := job.reuseSegment(key, outPath)
+		var err error
+		if !reused {
+			fallback, err = renderWithFallbacks(job, title, text, mediaPath, outPath, opts)
+		}
+		if err != nil {
+			var gap *NarrationGap
+			if errors.As(err, &gap) {
+				segment := strings.TrimSuffix(filepath.Base(outPath), ".mp4")
+				fmt.Printf("⚠️ Segment %s dropped: %v\n", segment, err)
+				if !opts.Preview {
+					job.narrationLost(segment, gap.Lost)
+				}
+				if opts.StrictTTS {
+					strictErr = fmt.Errorf("%s: %w", segment, err)
+				}
+			}
+			return false
+		}
+		d, err := probeDuration(outPath)
+		if err == nil && len(opts.Beats) > 0 && !reused {
+			if err := padToBeat(outPath, elapsed, d, opts.Beats); err != nil {
+				fmt.Printf("⚠️ %v\n", err)
+			}
+			d, err = probeDuration(outPath)
+		}
+		if err == nil {
+			elapsed += d
+		}
+		segments = append(segments, Segment{Path: outPath, Transition: opts.Transition, Title: title, Duration: d, Fallback: fallback})
+		spec.Duration, spec.Rendered = d, specRef(outPath)
+		specs = append(specs, spec)
+		if !opts.Preview {
+			job.recordSegment(key, outPath, fallback)
+			job.segmentRendered(len(segments)-1, segments[len(segments)-1])
+		}
+		return true
+	}
+
+	// Render Intro
+	plain := base
+	plain.Timer = ""
+	render("Intro", scriptData.Intro, introPath, path("seg_intro.mp4"), plain)
+
+	// Render Scenes
+	for i, item := range scriptData.Items {
+		if i >= len(scenePaths) {
+			break
+		}
+		opts := base
+		if i < len(sceneOpts) {
+			opts = sceneOpts[i]
+		}
+		beats := splitBeats(item.Details, maxWords)
+		if len(beats) > 1 {
+			fmt.Printf("✂️ Item %d: %d words split into %d beats\n", i+1, len(strings.Fields(item.Details)), len(beats))
+		}
+		first := opts
+		first.BRollClips = brollShare(opts.BRollClips, 0, len(beats))
+		if len(beats) > 1 && opts.Timer == "countdown" {
+			first.Timer = "" // counts down in the last beat
+		}
+		segPath := path(fmt.Sprintf("seg_%d.mp4", i))
+		if opts.TitleCard <= 0 {
+			if !render(item.Title, beats[0], scenePaths[i], segPath, first) {
+				continue
+			}
+		} else {
+			// The title card plays first, so the scene's PiP offset and beat
+			// alignment start after it; the card itself copies the scene's audio format.
+			elapsed += opts.TitleCard
+			if !render("", beats[0], scenePaths[i], segPath, first) {
+				elapsed -= opts.TitleCard
+				continue
+			}
+			last := len(segments) - 1
+			cardPath := path(fmt.Sprintf("card_%d.mp4", i))
+			if err := renderTitleCard(item.Title, scenePaths[i], segPath, cardPath, opts); err != nil {
+				fmt.Printf("⚠️ %v\n", err)
+				elapsed -= opts.TitleCard
+				segments[last].Title = item.Title
+				specs[len(specs)-1].Title = item.Title
+			} else {
+				specs[len(specs)-1].Title, specs[len(specs)-1].TitleCard = item.Title, opts.TitleCard
+				scene := segments[last]
+				segments[last] = Segment{Path: cardPath, Transition: scene.Transition, Title: item.Title, Duration: opts.TitleCard}
+				scene.Transition = "cut"
+				segments = append(segments, scene)
+			}
+		}
+
+		// Further beats cut straight in over the same media and stay in the item's chapter;
+		// a stopwatch keeps counting across them.
+		opts.Transition = "cut"
+		opts.TimerOffset = segments[len(segments)-1].Duration
+		for j, beat := range beats[1:] {
+			beatOpts := opts
+			beatOpts.BRollClips = brollShare(opts.BRollClips, j+1, len(beats))
+			if opts.Timer == "countdown" && j < len(beats)-2 {
+				beatOpts.Timer = ""
+			}
+			if render("", beat, scenePaths[i], path(fmt.Sprintf("seg_%d_%d.mp4", i, j+1)), beatOpts) {
+				opts.TimerOffset += segments[len(segments)-1].Duration
+			}
+		}
+	}
+
+	// Render Outro
+	render("Outro", scriptData.Outro, outroPath, path("seg_outro.mp4"), plain)
+	if strictErr != nil {
+		return nil, strictErr // segments stay for a retry, see handleRetryJob
+	}
+	if !base.Preview {
+		job.saveSpec(base, specs)
+	}
+	return segments, nil
+}
+
+// --- 3. STITCHER ---
+func stitchVideos(files []string, outputFile string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no video segments were created")
+	}
+	var list strings.Builder
+	for _, f := range files {
+		absPath, _ := filepath.Abs(f)
+		entry, err := concatEntry(absPath)
+		if err != nil {
+			return err
+		}
+		list.WriteString(entry)
+	}
+	listPath := filepath.Join(filepath.Dir(outputFile), "list.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return err
+	}
+	os.Remove(outputFile)
+	cmd := ffmpegCommand("-y", "-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy", "-movflags", "+faststart", outputFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Stitch Error: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+// concatEntry quotes a path for the concat demuxer: inside single quotes
+// only ' needs escaping, by closing the quotes around an escaped \'. The
+// list is line based, so paths with line breaks cannot be represented at
+// all.
+func concatEntry(path string) (string, error) {
+	if strings.ContainsAny(path, "\r\n") {
+		return "", fmt.Errorf("segment path contains a line break: %q", path)
+	}
+	return "file '" + strings.ReplaceAll(path, "'", `'\''`) + "'\n", nil
+}
+
+// --- 4. HELPERS ---
+func frameSize(videoType string) (int, int) {
+	if videoType == "long" {
+		return 1920, 1080
+	}
+	return 1080, 1920
+}
+
+func isVideoFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".mp4" || ext == ".mov" || ext == ".avi" || ext == ".webm" || ext == ".mkv"
+}
+
+func probeDuration(path string) (float64, error) {
+	out, err := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+// publicURL maps a file under ./output to its /videos URL.
+func publicURL(base, name string) string {
+	return base + "/videos/" + name
+}
+
+// baseURL is the scheme://host the request reached us on.
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.Request.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// tmdbSearch maps artwork categories to TMDB search endpoints.
+var tmdbSearch = map[string]string{"movie": "movie", "tv": "tv", "actor": "person", "person": "person"}
+
+// maxTMDBMatches is how many matches may fail the quality gate.
+const maxTMDBMatches = 3
+
+// downloadTMDBPoster fetches the poster (or, for people, the profile photo)
+// of the first TMDB match for query in category that passes the quality
+// gate, trying up to maxTMDBMatches.
+func downloadTMDBPoster(category, query string, dest string) (Source, error) {
+	apiKey := os.Getenv("TMDB_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("TMDB_API_TOKEN")
+	}
+	if apiKey == "" {
+		return Source{}, fmt.Errorf("missing key")
+	}
+	safe := url.QueryEscape(query)
+	url := fmt.Sprintf("https://api.themoviedb.org/3/search/%s?api_key=%s&query=%s&include_adult=false", tmdbSearch[category], apiKey, safe)
+	resp, err := http.Get(url)
+	if err != nil {
+		return Source{}, err
+	}
+	defer resp.Body.Close()
+	var res TMDBSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Source{}, err
+	}
+	tried := 0
+	for _, r := range res.Results {
+		if image := r.PosterPath + r.ProfilePath; image != "" {
+			if tried++; tried > maxTMDBMatches {
+				break
+			}
+			source := Source{Provider: "TMDB", Title: r.Title + r.Name,
+				URL: fmt.Sprintf("https://www.themoviedb.org/%s/%d", tmdbSearch[category], r.ID)}
+			// FIX: Use w780 instead of 'original' to save RAM on Render
+			if err := downloadFile("https://image.tmdb.org/t/p/w780"+image, dest); err != nil {
+				fmt.Printf("⚠️ TMDB image for %q: %v\n", query, err)
+				continue
+			}
+			if checkImageQuality(dest) == nil {
+				return source, nil
+			}
+		}
+	}
+	return Source{}, fmt.Errorf("not found")
+}
+
+func downloadFile(urlStr, dest string) error {
+	return download(http.DefaultClient, urlStr, dest)
+}
+
+// downloadPublicFile is downloadFile for a URL taken from a request or a
+// fetched page, see publicClient.
+func downloadPublicFile(urlStr, dest string) error {
+	return download(publicClient, urlStr, dest)
+}
+
+func download(client *http.Client, urlStr, dest string) error {
+	resp, err := client.Get(urlStr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkDownload(resp); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, resp.Body)
+	out.Close()
+	if err == nil && resp.ContentLength > 0 && n < resp.ContentLength {
+		err = fmt.Errorf("download cut short at %d of %d bytes", n, resp.ContentLength)
+	}
+	if err == nil {
+		err = verifyAsset(dest)
+	}
+	if err != nil {
+		os.Remove(dest)
+	}
+	return err
+}
+
+// CopyFile copies src to dest, replacing dest.
+func CopyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,30 @@
+package pipeline
+
+import "testing"
+
+func TestConcatEntry(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "output/jobs/x/seg_0.mp4", want: "file 'output/jobs/x/seg_0.mp4'\n"},
+		{path: "it's.mp4", want: `file 'it'\''s.mp4'` + "\n"},
+		{path: "''", want: `file ''\'''\'''` + "\n"},
+		{path: `a b\c"$(x);#.mp4`, want: `file 'a b\c"$(x);#.mp4'` + "\n"},
+		{path: "/tmp/ünïcode 片.mp4", want: "file '/tmp/ünïcode 片.mp4'\n"},
+		{path: "", want: "file ''\n"},
+		{path: "seg\n.mp4", wantErr: true},
+		{path: "seg\r.mp4", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := concatEntry(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("concatEntry(%q) error = %v, want error %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("concatEntry(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- DURATION ESTIMATES ---
+// Narration length is estimated from the script before anything is
+// synthesized: the word count at the voice's speaking rate plus the pause
+// after each sentence. Estimators are keyed by TTS provider; voice_wpm sets
+// the rate of the selected voice when it is known to read faster or slower.
+// The resulting plan is sent as job.plan_ready and returned as "plan".
+type DurationEstimator interface {
+	Narration(text string, opts RenderOptions) float64
+}
+
+var durationEstimators = map[string]DurationEstimator{
+	"google":     speechRate{WPM: 165, Pause: -1, Locales: map[string]float64{"hi": 140, "ta": 120, "bn": 135, "ar": 140, "es": 175, "ja": 300}},
+	"elevenlabs": speechRate{WPM: 150, Pause: 0.35},
+}
+
+// Languages written without spaces between words, with roughly how many
+// characters make a spoken word.
+var unspacedScripts = map[string]float64{"ja": 2}
+
+// speechRate estimates at a fixed words-per-minute rate, by language when
+// Locales has one. A negative Pause uses the request's sentence gap.
+type speechRate struct {
+	WPM     float64
+	Pause   float64
+	Locales map[string]float64
+}
+
+func (r speechRate) Narration(text string, opts RenderOptions) float64 {
+	lang, _, _ := strings.Cut(opts.Locale, "-")
+	wpm := r.WPM
+	if v, ok := r.Locales[lang]; ok {
+		wpm = v
+	}
+	if opts.VoiceWPM > 0 {
+		wpm = opts.VoiceWPM
+	}
+	words := float64(len(strings.Fields(text)))
+	if perWord, ok := unspacedScripts[lang]; ok {
+		words = float64(utf8.RuneCountInString(text)) / perWord
+	}
+	pause := r.Pause
+	if pause < 0 {
+		pause = defaultSentenceGap
+		if opts.SentenceGap > 0 {
+			pause = opts.SentenceGap
+		}
+	}
+	sentences := strings.Count(text, ". ") + strings.Count(text, "! ") + strings.Count(text, "? ")
+	return words/wpm*60 + float64(sentences)*pause
+}
+
+// estimateNarration is the estimated narration length of text in seconds.
+func estimateNarration(text string, opts RenderOptions) float64 {
+	e, ok := durationEstimators[opts.TTSProvider]
+	if !ok {
+		e = durationEstimators["google"]
+	}
+	return e.Narration(text, opts)
+}
+
+// DurationPlan is the estimated length of each part of the video, laid out
+// the way renderScript renders it. Scenes include their title cards and
+// Total takes off the crossfaded joints; the sting is not counted.
+type DurationPlan struct {
+	Intro  float64   `json:"intro"`
+	Scenes []float64 `json:"scenes"`
+	Outro  float64   `json:"outro"`
+	Total  float64   `json:"total"`
+}
+
+func planScript(script ScriptResponse, base RenderOptions, sceneOpts []RenderOptions) DurationPlan {
+	_, maxWords := wordRange(base.VideoType)
+	plan := DurationPlan{Intro: estimateNarration(script.Intro, base), Outro: estimateNarration(script.Outro, base)}
+	plan.Total = plan.Intro + plan.Outro
+	var joints []string // transitions into each scene and the outro
+	for i, item := range script.Items {
+		opts := base
+		if i < len(sceneOpts) {
+			opts = sceneOpts[i]
+		}
+		d := opts.TitleCard
+		for _, beat := range splitBeats(item.Details, maxWords) {
+			d += estimateNarration(beat, opts)
+		}
+		plan.Scenes = append(plan.Scenes, math.Round(d*10)/10)
+		plan.Total += d
+		joints = append(joints, opts.Transition)
+	}
+	for _, t := range append(joints, base.Transition) {
+		if t != "" && t != "cut" {
+			plan.Total -= transitionDuration
+		}
+	}
+	plan.Intro, plan.Outro = math.Round(plan.Intro*10)/10, math.Round(plan.Outro*10)/10
+	plan.Total = math.Round(max(plan.Total, 0)*10) / 10
+	return plan
+}
+
+// setPlan records the job's plan and announces it.
+func (j *Job) setPlan(plan DurationPlan) {
+	j.mu.Lock()
+	j.plan = &plan
+	j.mu.Unlock()
+	fmt.Printf("⏱️ Planned length: ~%.0fs (%d scenes)\n", plan.Total, len(plan.Scenes))
+	j.Emit("job.plan_ready", plan)
+}
+
+func (j *Job) Plan() *DurationPlan {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.plan
+}
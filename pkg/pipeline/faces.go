@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- FACE BLUR ---
+// blur_faces=true blurs every face in the scene media (uploads, stock and
+// fetched footage alike) before the scene is rendered, for street footage
+// and releases nobody signed. FACE_API_URL is posted each image, or one
+// frame every FACE_SAMPLE_SECS (1) of a clip, as multipart "file" and
+// answers {"faces": [{"x", "y", "width", "height"}]} in pixels;
+// FACE_API_KEY is sent as a bearer token. Each box is grown by a fifth and
+// blurred for as long as its frame is on screen. When detection fails the
+// whole frame is blurred instead, so a face is never shown because the
+// detector was down. The blurred copy stays in the workspace and replaces
+// the media for that render only; the spec keeps the original.
+type faceBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// timedFace is a box shown from Start to End seconds; End 0 is always.
+type timedFace struct {
+	faceBox
+	Start, End float64
+}
+
+// blurFaces returns mediaPath with its faces blurred, or mediaPath itself
+// when nothing was found.
+func blurFaces(job *Job, mediaPath string) string {
+	dest := job.Path("faces_" + filepath.Base(mediaPath))
+	if _, err := os.Stat(dest); err == nil {
+		return dest
+	}
+	faces, err := detectMediaFaces(job, mediaPath)
+	if err != nil {
+		fmt.Printf("⚠️ Face detection failed, blurring the whole frame: %v\n", err)
+	} else if len(faces) == 0 {
+		return mediaPath
+	}
+	if err := renderFaceBlur(mediaPath, dest, faces, err != nil); err != nil {
+		fmt.Printf("❌ Face blur failed: %v\n", err)
+		return mediaPath
+	}
+	fmt.Printf("🙈 Blurred %d face(s) in %s\n", len(faces), filepath.Base(mediaPath))
+	return dest
+}
+
+func detectMediaFaces(job *Job, mediaPath string) ([]timedFace, error) {
+	if os.Getenv("FACE_API_URL") == "" {
+		return nil, fmt.Errorf("FACE_API_URL is not set")
+	}
+	if !isVideoFile(mediaPath) {
+		boxes, err := detectFaces(mediaPath)
+		var faces []timedFace
+		for _, b := range boxes {
+			faces = append(faces, timedFace{faceBox: b})
+		}
+		return faces, err
+	}
+
+	step := float64(envInt("FACE_SAMPLE_SECS", 1))
+	dir := job.Path("faces_" + strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath)))
+	os.MkdirAll(dir, 0755)
+	defer os.RemoveAll(dir)
+	output, err := ffmpegCommand("-y", "-i", mediaPath, "-vf", fmt.Sprintf("fps=1/%g", step), "-q:v", "3",
+		filepath.Join(dir, "%05d.jpg")).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%v | Log: %s", err, string(output))
+	}
+	frames, _ := filepath.Glob(filepath.Join(dir, "*.jpg"))
+	var faces []timedFace
+	for i, frame := range frames {
+		boxes, err := detectFaces(frame)
+		if err != nil {
+			return nil, err
+		}
+		// A frame stands for the half step either side of it.
+		t := float64(i) * step
+		for _, b := range boxes {
+			faces = append(faces, timedFace{faceBox: b, Start: max(0, t-step/2), End: t + step/2})
+		}
+	}
+	return faces, nil
+}
+
+func detectFaces(path string) ([]faceBox, error) {
+	var res struct {
+		Faces []faceBox `json:"faces"`
+	}
+	auth := func(req *http.Request) {
+		if key := os.Getenv("FACE_API_KEY"); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+	if err := postMultipartFile(os.Getenv("FACE_API_URL"), "file", path, auth, &res); err != nil {
+		return nil, fmt.Errorf("face API: %v", err)
+	}
+	return res.Faces, nil
+}
+
+// renderFaceBlur overlays a blurred crop of each face, or blurs the whole
+// frame when all is set.
+func renderFaceBlur(mediaPath, dest string, faces []timedFace, all bool) error {
+	var graph string
+	if all {
+		graph = "[0:v]boxblur=luma_radius='min(w,h)/20':luma_power=3[out]"
+	} else {
+		labels := "[base]"
+		for i := range faces {
+			labels += fmt.Sprintf("[s%d]", i)
+		}
+		graph = fmt.Sprintf("[0:v]split=%d%s", len(faces)+1, labels)
+		last := "base"
+		for i, f := range faces {
+			padX, padY := f.Width/10, f.Height/10
+			x, y := max(0, f.X-padX), max(0, f.Y-padY)
+			crop := fmt.Sprintf("crop=w='min(%d,iw-%d)':h='min(%d,ih-%d)':x=%d:y=%d", f.Width+2*padX, x, f.Height+2*padY, y, x, y)
+			enable := ""
+			if f.End > 0 {
+				enable = fmt.Sprintf(":enable='between(t,%.2f,%.2f)'", f.Start, f.End)
+			}
+			next := fmt.Sprintf("o%d", i)
+			if i == len(faces)-1 {
+				next = "out"
+			}
+			graph += fmt.Sprintf(";[s%d]%s,boxblur=luma_radius='min(w,h)/4':luma_power=3[f%d];[%s][f%d]overlay=%d:%d%s[%s]",
+				i, crop, i, last, i, x, y, enable, next)
+			last = next
+		}
+	}
+	args := []string{"-y", "-i", mediaPath, "-filter_complex", graph, "-map", "[out]"}
+	if isVideoFile(mediaPath) {
+		args = append(args, "-map", "0:a?", "-c:v", "libx264", "-preset", "ultrafast", "-c:a", "copy", dest)
+	} else {
+		args = append(args, "-frames:v", "1", "-q:v", "2", dest)
+	}
+	output, err := ffmpegCommand(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v | Log: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- FACT CHECK ---
+// fact_check=true adds a verification pass after script generation: each
+// item's Wikipedia intro is fetched as context and a second LLM call flags
+// claims that contradict it or that it cannot support. The warnings are
+// returned as "fact_check" with the video. fact_check=strict stops before
+// rendering when there are any, answering 422 with the script and the
+// warnings so the creator can fix the text first.
+const maxWikiChars = 1500
+
+type FactWarning struct {
+	Item     int    `json:"item"` // 1-based script item, 0 for intro/outro
+	Claim    string `json:"claim"`
+	Issue    string `json:"issue"`
+	Severity string `json:"severity"` // low, medium or high
+}
+
+// runFactCheck runs the pass the request asked for. ok is false when it
+// has already answered the request (strict mode with warnings).
+func runFactCheck(r *request, job *Job, topic string, script ScriptResponse) (warnings []FactWarning, ok bool) {
+	mode := strings.ToLower(strings.TrimSpace(r.PostForm("fact_check")))
+	if mode != "true" && mode != "strict" {
+		return nil, true
+	}
+	job.Stage("fact_check")
+	warnings, err := factCheckScript(job, topic, script)
+	if err != nil {
+		fmt.Printf("⚠️ Fact check skipped: %v\n", err)
+		return nil, true
+	}
+	fmt.Printf("🔎 Fact check: %d warnings\n", len(warnings))
+	if mode == "strict" && len(warnings) > 0 {
+		r.JSON(422, gin.H{"error": "Fact check flagged the script", "job_id": job.ID, "script": script, "fact_check": warnings})
+		return warnings, false
+	}
+	return warnings, true
+}
+
+func factCheckScript(job *Job, topic string, script ScriptResponse) ([]FactWarning, error) {
+	var items strings.Builder
+	fmt.Fprintf(&items, "Item 0 (intro): %s\n", script.Intro)
+	for i, it := range script.Items {
+		fmt.Fprintf(&items, "\nItem %d: %s\nScript: %s\n", i+1, it.Title, it.Details)
+		if page, ctx, err := wikipediaExtract(it.Title + " " + topic); err == nil && ctx != "" {
+			fmt.Fprintf(&items, "Wikipedia: %s\n", ctx)
+			job.AddSource("", Source{Provider: "Wikipedia", Title: page,
+				URL: "https://en.wikipedia.org/wiki/" + url.PathEscape(strings.ReplaceAll(page, " ", "_"))})
+		}
+	}
+	fmt.Fprintf(&items, "\nItem 0 (outro): %s\n", script.Outro)
+
+	prompt := fmt.Sprintf(`
+    You are fact-checking a narration script about "%s".
+    For every item, compare the script with the Wikipedia context given for it (if any) and your own knowledge.
+    Flag only specific factual claims (names, dates, numbers, records, quotes) that are wrong, doubtful or unsupported.
+    Do not flag opinions, tone or style. Return an empty list if nothing is dubious.
+    SCRIPT:
+    %s
+    RETURN JSON ONLY:
+    {
+        "warnings": [
+            { "item": 1, "claim": "the exact claim", "issue": "why it is dubious", "severity": "low|medium|high" }
+        ]
+    }
+    `, topic, items.String())
+
+	var result struct {
+		Warnings []FactWarning `json:"warnings"`
+	}
+	if err := completeJSON(job, "fact_check", prompt, &result); err != nil {
+		return nil, err
+	}
+	return result.Warnings, nil
+}
+
+// wikipediaExtract returns the title and plain-text intro of the best
+// English Wikipedia match for query, the intro trimmed to maxWikiChars.
+func wikipediaExtract(query string) (title, text string, err error) {
+	q := url.Values{
+		"action": {"query"}, "format": {"json"}, "redirects": {"1"},
+		"generator": {"search"}, "gsrsearch": {query}, "gsrlimit": {"1"},
+		"prop": {"extracts"}, "exintro": {"1"}, "explaintext": {"1"},
+	}
+	req, _ := http.NewRequest("GET", "https://en.wikipedia.org/w/api.php?"+q.Encode(), nil)
+	req.Header.Set("User-Agent", "vixio-backend/1.0 (fact check)")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", "", fmt.Errorf("wikipedia status %d", resp.StatusCode)
+	}
+	var res struct {
+		Query struct {
+			Pages map[string]struct {
+				Title   string `json:"title"`
+				Extract string `json:"extract"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", "", err
+	}
+	for _, p := range res.Query.Pages {
+		text = strings.Join(strings.Fields(p.Extract), " ")
+		if len(text) > maxWikiChars {
+			text = strings.ToValidUTF8(text[:maxWikiChars], "") + "..."
+		}
+		return p.Title, text, nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,317 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- FINISHING (post-stitch passes over the final video) ---
+type FinishOptions struct {
+	VideoType     string
+	ProgressBar   bool
+	ProgressColor string
+	Platform      string // see platformSafeAreas
+	MusicPath     string
+	MusicVolume   float64
+	AutoMusic     bool // no music chosen: pick a library track for the script's mood
+	Seed          int  // the job's, see seedFromForm
+
+	Ambience       string // bed mixed when there is no music, see ambienceBeds
+	AmbienceVolume float64
+	Mastering      bool // see mastering.go
+
+	// Audio-only copy of the narration (.mp3 or .m4a), taken before the
+	// music is mixed in unless NarrationMusic is set.
+	NarrationPath  string
+	NarrationMusic bool
+
+	// Scrub-preview sprite sheet and its WebVTT track, see writeScrubThumbnails.
+	ThumbnailSprite   string
+	ThumbnailVTT      string
+	ThumbnailInterval float64
+
+	// Short silent loop for social previews, .gif or .mp4, see writeTeaser.
+	TeaserPath   string
+	TeaserLength float64
+
+	SizeBudget *SizeBudget // max_file_size, filled in by fitFileSize; nil if unset
+
+	// Container metadata, written last so no re-encode drops it.
+	Title    string
+	Comment  string
+	Chapters []Chapter
+}
+
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+func finishOptionsFromForm(f Form, job *Job, videoType string) FinishOptions {
+	opts := FinishOptions{
+		VideoType:     videoType,
+		ProgressBar:   f.PostForm("progress_bar") == "true",
+		ProgressColor: f.DefaultPostForm("progress_color", "white"),
+		Platform:      strings.ToLower(strings.TrimSpace(f.PostForm("platform"))),
+		MusicVolume:   0.15,
+		Comment:       "job " + job.ID,
+		Seed:          job.seed,
+	}
+	if file, err := f.FormFile("music"); err == nil {
+		opts.MusicPath, _ = saveMediaUpload(job, file, ".mp3")
+	} else if id := f.PostForm("music_id"); id != "" && id != "none" {
+		if path, err := musicTrackPath(job, id); err == nil {
+			opts.MusicPath = path
+		} else {
+			fmt.Printf("⚠️ Music skipped: %v\n", err)
+		}
+	} else if id := f.PostForm("music_asset"); id != "" {
+		if opts.MusicPath, _ = assetPath(id, job.owner); opts.MusicPath != "" {
+			job.recordAsset(opts.MusicPath, id)
+		}
+	}
+	opts.AutoMusic = opts.MusicPath == "" && f.PostForm("music_id") != "none"
+	if v, err := strconv.ParseFloat(f.PostForm("music_volume"), 64); err == nil && v >= 0 && v <= 1 {
+		opts.MusicVolume = v
+	}
+	opts.Ambience, opts.AmbienceVolume = ambienceFromForm(f)
+	opts.Mastering = f.PostForm("mastering") == "true"
+	switch format := strings.ToLower(strings.TrimSpace(f.PostForm("narration_audio"))); format {
+	case "":
+	case "mp3", "m4a":
+		opts.NarrationPath = job.Path("narration." + format)
+		opts.NarrationMusic = f.PostForm("narration_music") == "true"
+	default:
+		fmt.Printf("⚠️ Narration export skipped: unknown format %q\n", format)
+	}
+	if f.PostForm("thumbnails") == "true" {
+		opts.ThumbnailSprite, opts.ThumbnailVTT = job.Path("thumbnails.jpg"), job.Path("thumbnails.vtt")
+		opts.ThumbnailInterval, _ = strconv.ParseFloat(f.PostForm("thumbnail_interval"), 64)
+	}
+	switch format := strings.ToLower(strings.TrimSpace(f.PostForm("teaser"))); format {
+	case "":
+	case "gif", "mp4":
+		opts.TeaserPath = job.Path("teaser." + format)
+		opts.TeaserLength = teaserLength(f.PostForm("teaser_length"))
+	default:
+		fmt.Printf("⚠️ Teaser skipped: unknown format %q\n", format)
+	}
+	opts.SizeBudget = sizeBudgetFromForm(f.PostForm("max_file_size"))
+	return opts
+}
+
+// finishVideo applies the enabled post-stitch passes to the video in place.
+func finishVideo(path string, opts FinishOptions) error {
+	if opts.NarrationPath != "" && !opts.NarrationMusic {
+		exportNarration(path, opts.NarrationPath)
+	}
+	if opts.MusicPath != "" {
+		if err := mixMusic(path, opts); err != nil {
+			return err
+		}
+	} else if opts.Ambience != "" {
+		if err := mixAmbience(path, opts); err != nil {
+			fmt.Printf("⚠️ Ambience skipped: %v\n", err)
+		}
+	} else if opts.Mastering {
+		if err := masterAudio(path); err != nil {
+			fmt.Printf("⚠️ Mastering skipped: %v\n", err)
+		}
+	}
+	if opts.NarrationPath != "" && opts.NarrationMusic {
+		exportNarration(path, opts.NarrationPath)
+	}
+	if opts.ProgressBar {
+		if err := addProgressBar(path, opts); err != nil {
+			return err
+		}
+	}
+	if opts.SizeBudget != nil {
+		if err := fitFileSize(path, opts.SizeBudget); err != nil {
+			opts.SizeBudget.Error = err.Error()
+			fmt.Printf("⚠️ %v\n", err)
+		}
+	}
+	if err := writeMetadata(path, opts); err != nil {
+		return err
+	}
+	// Side outputs from the finished video; the video itself is done.
+	if opts.ThumbnailVTT != "" {
+		if err := writeScrubThumbnails(path, opts.ThumbnailSprite, opts.ThumbnailVTT, opts.ThumbnailInterval); err != nil {
+			fmt.Printf("⚠️ Thumbnails skipped: %v\n", err)
+		}
+	}
+	if opts.TeaserPath != "" {
+		if err := writeTeaser(path, opts.TeaserPath, opts.TeaserLength); err != nil {
+			fmt.Printf("⚠️ Teaser skipped: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// exportNarration writes the video's audio track on its own. The stitched
+// track already has the sentence gaps, title-card silences and crossfades.
+func exportNarration(video, dest string) {
+	args := []string{"-y", "-i", video, "-vn", "-c:a", "copy", dest}
+	if strings.HasSuffix(dest, ".mp3") {
+		args = []string{"-y", "-i", video, "-vn", "-c:a", "libmp3lame", "-q:a", "2", dest}
+	}
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		os.Remove(dest)
+		fmt.Printf("⚠️ Narration export failed: %v | Log: %s\n", err, string(output))
+	}
+}
+
+// narrationURL is the address of the exported narration, "" if none was written.
+func narrationURL(base string, job *Job, opts FinishOptions) string {
+	if opts.NarrationPath == "" {
+		return ""
+	}
+	if _, err := os.Stat(opts.NarrationPath); err != nil {
+		return ""
+	}
+	return job.URL(base, opts.NarrationPath)
+}
+
+// segmentChapters lays out one chapter per segment from the measured
+// durations, following stitchSegments: a crossfaded joint overlaps the two
+// segments by transitionDuration, and the chapter starts where the fade does.
+func segmentChapters(segments []Segment) []Chapter {
+	var chapters []Chapter
+	t := 0.0
+	for i, s := range segments {
+		d := s.Duration
+		if d <= 0 {
+			var err error
+			if d, err = probeDuration(s.Path); err != nil {
+				return nil
+			}
+		}
+		if i > 0 && s.Transition != "" && s.Transition != "cut" {
+			t -= transitionDuration
+			if len(chapters) > 0 {
+				chapters[len(chapters)-1].End = t
+			}
+		}
+		switch {
+		case s.Title != "":
+			start := t
+			if len(chapters) == 0 {
+				start = 0 // a leading untitled segment (the brand sting) opens the first chapter
+			}
+			chapters = append(chapters, Chapter{Title: s.Title, Start: start, End: t + d})
+		case len(chapters) > 0:
+			// Untitled segments continue the previous chapter (a scene after its title card).
+			chapters[len(chapters)-1].End = t + d
+		}
+		t += d
+	}
+	return chapters
+}
+
+// YouTube only turns a description's timestamps into chapters when there
+// are at least three, the first at 0:00, each at least 10 seconds long.
+const minYouTubeChapter = 10.0
+
+// youtubeChapters formats chapters as a description block ("0:00 Intro"),
+// folding chapters that are too short into their predecessor. It returns ""
+// when the video can't have YouTube chapters.
+func youtubeChapters(chapters []Chapter) string {
+	var merged []Chapter
+	for _, ch := range chapters {
+		if len(merged) > 0 && (ch.End-ch.Start < minYouTubeChapter || merged[len(merged)-1].End-merged[len(merged)-1].Start < minYouTubeChapter) {
+			merged[len(merged)-1].End = ch.End
+			continue
+		}
+		merged = append(merged, ch)
+	}
+	if len(merged) < 3 {
+		return ""
+	}
+	var lines []string
+	for i, ch := range merged {
+		start := ch.Start
+		if i == 0 {
+			start = 0
+		}
+		lines = append(lines, youtubeTime(start)+" "+ch.Title)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func youtubeTime(sec float64) string {
+	s := int(sec)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// writeMetadata stores title, comment, creation time and chapters in the
+// container via an FFMETADATA file. Streams are copied.
+func writeMetadata(path string, opts FinishOptions) error {
+	var meta strings.Builder
+	meta.WriteString(";FFMETADATA1\n")
+	if opts.Title != "" {
+		meta.WriteString("title=" + escapeMetadata(opts.Title) + "\n")
+	}
+	if opts.Comment != "" {
+		meta.WriteString("comment=" + escapeMetadata(opts.Comment) + "\n")
+	}
+	meta.WriteString("creation_time=" + time.Now().UTC().Format(time.RFC3339) + "\n")
+	for _, ch := range opts.Chapters {
+		fmt.Fprintf(&meta, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(ch.Start*1000), int64(ch.End*1000), escapeMetadata(ch.Title))
+	}
+
+	metaPath := strings.TrimSuffix(path, ".mp4") + "_meta.txt"
+	if err := os.WriteFile(metaPath, []byte(meta.String()), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(metaPath)
+	tmp := strings.TrimSuffix(path, ".mp4") + "_tagged.mp4"
+	cmd := ffmpegCommand("-y", "-i", path, "-i", metaPath,
+		"-map", "0", "-map_metadata", "1", "-map_chapters", "1", "-c", "copy", "-movflags", "+faststart", tmp)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("metadata: %v | Log: %s", err, string(output))
+	}
+	return os.Rename(tmp, path)
+}
+
+// escapeMetadata escapes FFMETADATA special characters.
+func escapeMetadata(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n").Replace(v)
+}
+
+// addProgressBar draws a bar along the bottom that fills over the video's
+// full duration. Audio is copied untouched.
+func addProgressBar(path string, opts FinishOptions) error {
+	total, err := probeDuration(path)
+	if err != nil || total <= 0 {
+		return fmt.Errorf("progress bar: cannot read duration")
+	}
+	w, h := frameSize(opts.VideoType)
+	barH := h / 120
+	color := opts.ProgressColor
+	if strings.HasPrefix(color, "#") {
+		color = "0x" + color[1:]
+	}
+
+	// On platforms with bottom UI the bar sits just above it.
+	graph := fmt.Sprintf("color=c=%s@0.9:s=%dx%d:r=30[bar];[0:v][bar]overlay=x='-w+w*t/%.3f':y=H-h-%d:shortest=1[v]",
+		escapeFilterArg(color), w, barH, total, safeMargins(opts.Platform, w, h).Bottom)
+	tmp := strings.TrimSuffix(path, ".mp4") + "_bar.mp4"
+	cmd := ffmpegCommand("-y", "-i", path, "-filter_complex", graph,
+		"-map", "[v]", "-map", "0:a?", "-c:v", "libx264", "-preset", "ultrafast", "-pix_fmt", "yuv420p",
+		"-c:a", "copy", "-movflags", "+faststart", tmp)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("progress bar: %v | Log: %s", err, string(output))
+	}
+	return os.Rename(tmp, path)
+}
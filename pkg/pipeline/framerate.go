@@ -0,0 +1,47 @@
+package pipeline
+
+import "fmt"
+
+// --- FRAME RATE ---
+// fps=24|25|30|60 sets the frame rate of every rendered segment (30 by
+// default); stitching conforms to the first segment's rate. A still image
+// whose scene adds no moving layers is fed to ffmpeg at stillInputRate and
+// duplicated up to the output rate by an fps filter placed after the static
+// filters, so scaling, grading and the citation run once per input frame
+// instead of once per output frame. Timers and captions come after the fps
+// filter and still change on exact frames.
+const (
+	defaultFPS     = 30
+	stillInputRate = 1
+)
+
+var frameRates = map[int]bool{24: true, 25: true, 30: true, 60: true}
+
+// frameRate is the segment frame rate: FPS, or defaultFPS when unset.
+func (o RenderOptions) frameRate() int {
+	if o.FPS == 0 {
+		return defaultFPS
+	}
+	return o.FPS
+}
+
+// staticEffects leave an unchanging frame, so they can run at stillInputRate.
+var staticEffects = map[string]bool{"": true, "none": true, "grayscale": true}
+
+// staticStill reports whether a segment over mediaPath can use the low
+// input rate: a still image, a static effect and no video overlays.
+func staticStill(mediaPath string, opts RenderOptions) bool {
+	return !isVideoFile(mediaPath) && staticEffects[opts.Effect] &&
+		opts.ChromaKeyPath == "" && opts.PiPPath == "" && opts.AvatarClipPath == "" && len(opts.BRollCuts) == 0
+}
+
+// knownRate reports whether an ffprobe r_frame_rate is one we render at,
+// including previews.
+func knownRate(rate string) bool {
+	for fps := range frameRates {
+		if rate == fmt.Sprintf("%d/1", fps) {
+			return true
+		}
+	}
+	return rate == fmt.Sprintf("%d/1", previewFPS)
+}
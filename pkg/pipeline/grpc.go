@@ -0,0 +1,240 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	vixiov1 "video-factory-backend/proto/vixio/v1"
+)
+
+// --- gRPC API ---
+// The VideoFactory service (proto/vixio/v1) for internal services driving
+// renders at volume. Generate turns its request into a Spec, asset IDs
+// included, runs it through Pipeline.Generate and streams the job's stages
+// as JobEvents, the last one carrying the result. GetJob answers as
+// GET /v1/jobs/:id does. The caller's X-Api-Key is the x-api-key metadata;
+// URLs in results start with PUBLIC_URL (default http://localhost).
+type grpcServer struct {
+	vixiov1.UnimplementedVideoFactoryServer
+}
+
+// NewGRPCServer builds the gRPC API.
+func NewGRPCServer() *grpc.Server {
+	s := grpc.NewServer()
+	vixiov1.RegisterVideoFactoryServer(s, grpcServer{})
+	return s
+}
+
+var grpcKinds = map[vixiov1.Kind]string{
+	vixiov1.Kind_KIND_UNSPECIFIED: "",
+	vixiov1.Kind_KIND_MULTI_SCENE: "",
+	vixiov1.Kind_KIND_PODCAST:     "podcast",
+	vixiov1.Kind_KIND_DECK:        "deck",
+	vixiov1.Kind_KIND_ARTICLE:     "article",
+	vixiov1.Kind_KIND_NEWS:        "news",
+	vixiov1.Kind_KIND_SPORTS:      "sports",
+	vixiov1.Kind_KIND_MARKET:      "market",
+	vixiov1.Kind_KIND_WEATHER:     "weather",
+	vixiov1.Kind_KIND_REDDIT:      "reddit",
+	vixiov1.Kind_KIND_QUOTE:       "quote",
+}
+
+// grpcStages maps pipeline stages onto JobEvent stages; the rest (hooks,
+// headlines, transcribe, ...) are sent as STAGE_UNSPECIFIED with the name
+// in the message.
+var grpcStages = map[string]vixiov1.JobEvent_Stage{
+	"script":     vixiov1.JobEvent_STAGE_SCRIPT,
+	"fact_check": vixiov1.JobEvent_STAGE_SCRIPT,
+	"media":      vixiov1.JobEvent_STAGE_RENDER,
+	"render":     vixiov1.JobEvent_STAGE_RENDER,
+	"preview":    vixiov1.JobEvent_STAGE_RENDER,
+	"stitch":     vixiov1.JobEvent_STAGE_STITCH,
+	"chapters":   vixiov1.JobEvent_STAGE_FINISH,
+	"finish":     vixiov1.JobEvent_STAGE_FINISH,
+}
+
+func (grpcServer) Generate(req *vixiov1.GenerateRequest, stream grpc.ServerStreamingServer[vixiov1.JobEvent]) error {
+	ctx := stream.Context()
+	apiKey := grpcAPIKey(ctx)
+	spec, err := specFromGRPC(req, keyOwner(apiKey))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	progress := make(chan Progress, 64)
+	p := &Pipeline{BaseURL: os.Getenv("PUBLIC_URL"), APIKey: apiKey, Progress: func(e Progress) {
+		select {
+		case progress <- e:
+		case <-ctx.Done(): // the caller left; the render goes on
+		}
+	}}
+	type outcome struct {
+		result Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := p.Generate(ctx, spec)
+		done <- outcome{result, err}
+	}()
+
+	var jobID string
+	var segmentsDone, segmentsTotal int32
+	send := func(e Progress) error {
+		jobID = e.JobID
+		ev := &vixiov1.JobEvent{JobId: e.JobID, Message: e.Stage}
+		switch {
+		case e.Stage != "":
+			ev.Stage = grpcStages[e.Stage]
+		case e.Event == "job.created":
+			ev.Stage, ev.Message = vixiov1.JobEvent_STAGE_RECEIVED, e.Event
+		case e.Event == "job.script_ready":
+			if script, ok := e.Data.(ScriptResponse); ok {
+				segmentsTotal = int32(len(script.Items) + 2) // intro, scenes, outro
+			}
+			ev.Stage, ev.Message = vixiov1.JobEvent_STAGE_SCRIPT, e.Event
+		case e.Event == "job.segment_rendered":
+			segmentsDone++
+			ev.Stage, ev.Message = vixiov1.JobEvent_STAGE_RENDER, e.Event
+		default:
+			return nil // completed and failed arrive as the result
+		}
+		ev.SegmentsDone, ev.SegmentsTotal = segmentsDone, segmentsTotal
+		return stream.Send(ev)
+	}
+	for {
+		select {
+		case e := <-progress:
+			if err := send(e); err != nil {
+				return err
+			}
+		case o := <-done:
+			for len(progress) > 0 {
+				if err := send(<-progress); err != nil {
+					return err
+				}
+			}
+			final := &vixiov1.JobEvent{JobId: o.result.JobID, Stage: vixiov1.JobEvent_STAGE_DONE, Message: o.result.Status,
+				SegmentsDone: segmentsDone, SegmentsTotal: segmentsTotal, Result: grpcJob(o.result, "")}
+			if o.err != nil {
+				var failed *Error
+				if !errors.As(o.err, &failed) {
+					if ctx.Err() != nil {
+						return status.FromContextError(ctx.Err()).Err()
+					}
+					return status.Error(codes.InvalidArgument, o.err.Error())
+				}
+				final = &vixiov1.JobEvent{JobId: jobID, Stage: vixiov1.JobEvent_STAGE_FAILED, Message: failed.Message,
+					SegmentsDone: segmentsDone, SegmentsTotal: segmentsTotal,
+					Result: &vixiov1.Job{JobId: jobID, Status: "failed", Error: failed.Message}}
+			}
+			return stream.Send(final)
+		}
+	}
+}
+
+func (grpcServer) GetJob(ctx context.Context, req *vixiov1.GetJobRequest) (*vixiov1.Job, error) {
+	job, result, err := openJob(req.GetJobId())
+	if err == errJobNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if result == nil {
+		result = streamState(job)
+	}
+	if result == nil {
+		return &vixiov1.Job{JobId: job.ID, Status: "running"}, nil
+	}
+	var saved struct {
+		Result
+		Error string `json:"error"`
+	}
+	data, _ := json.Marshal(result)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, status.Error(codes.Internal, "unreadable job result")
+	}
+	if saved.JobID == "" {
+		saved.JobID = job.ID
+	}
+	return grpcJob(saved.Result, saved.Error), nil
+}
+
+// specFromGRPC maps req onto the form fields and uploads of its HTTP route.
+// Scene, intro and outro assets are passed as their <field>_asset IDs;
+// green-screen clips, audio and decks, which the generators only take as
+// uploads, are read from owner's assets in the library.
+func specFromGRPC(req *vixiov1.GenerateRequest, owner string) (Spec, error) {
+	kind, ok := grpcKinds[req.GetKind()]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown kind %v", req.GetKind())
+	}
+	spec := Spec{Kind: kind, Topic: req.GetTopic(), Category: req.GetCategory(), Type: req.GetType(),
+		Options: map[string]string{}, Files: map[string]string{}}
+	for k, v := range req.GetOptions() {
+		spec.Options[k] = v
+	}
+	fields := map[string]string{"media_intro_asset": req.GetIntroAsset(), "media_outro_asset": req.GetOutroAsset(),
+		"url": req.GetUrl(), "markdown": req.GetMarkdown(), "brand_kit": req.GetBrandKit()}
+	uploads := map[string]string{"audio": req.GetAudioAsset(), "deck": req.GetDeckAsset()}
+	for i, s := range req.GetScenes() {
+		scene := SceneSpec{SceneData: SceneData{Name: s.GetName(), Details: s.GetDetails(), Transition: s.GetTransition(),
+			Effect: s.GetEffect(), Fit: s.GetFit(), KeyColor: s.GetKeyColor(), PresenterPosition: s.GetPresenterPosition(), PiP: s.Pip}}
+		spec.Scenes = append(spec.Scenes, scene)
+		fields[fmt.Sprintf("media_%d_asset", i)] = s.GetMediaAsset()
+		uploads[fmt.Sprintf("greenscreen_%d", i)] = s.GetGreenscreenAsset()
+	}
+	for k, v := range fields {
+		if v != "" {
+			spec.Options[k] = v
+		}
+	}
+	for field, id := range uploads {
+		if id == "" {
+			continue
+		}
+		path, err := assetPath(id, owner)
+		if err != nil {
+			return Spec{}, fmt.Errorf("%s: %v", field, err)
+		}
+		spec.Files[field] = path
+	}
+	return spec, nil
+}
+
+func grpcAPIKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func grpcJob(r Result, errMsg string) *vixiov1.Job {
+	job := &vixiov1.Job{JobId: r.JobID, Status: r.Status, Error: errMsg, VideoUrl: r.VideoURL, NarrationUrl: r.NarrationURL,
+		TeaserUrl: r.TeaserURL, Stitch: r.Stitch, ChaptersText: r.ChaptersText}
+	if r.Output != (MediaInfo{}) {
+		job.Output = grpcMediaInfo(r.Output)
+	}
+	for _, s := range r.Segments {
+		job.Segments = append(job.Segments, grpcMediaInfo(s))
+	}
+	for _, ch := range r.Chapters {
+		job.Chapters = append(job.Chapters, &vixiov1.Chapter{Title: ch.Title, Start: ch.Start, End: ch.End})
+	}
+	return job
+}
+
+func grpcMediaInfo(m MediaInfo) *vixiov1.MediaInfo {
+	return &vixiov1.MediaInfo{Title: m.Title, Duration: m.Duration, Width: int32(m.Width), Height: int32(m.Height), Fps: m.FPS,
+		Size: m.Size, VideoCodec: m.VideoCodec, PixFmt: m.PixFmt, AudioCodec: m.AudioCodec,
+		SampleRate: int32(m.SampleRate), Channels: int32(m.Channels), Url: m.URL}
+}
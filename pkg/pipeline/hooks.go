@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- HOOK OPTIMIZATION ---
+// The intro's first line decides whether a viewer keeps watching. hooks=auto
+// asks for hookCandidates alternative intros once the script is written,
+// scores them with a second LLM pass for curiosity and retention and renders
+// the best; the scored list is returned as script.hooks. hooks=all stops
+// after scoring and answers with the candidates instead of rendering, so the
+// creator can pick one and resend the request with hook=<chosen text>, which
+// replaces the generated intro as is.
+const hookCandidates = 3
+
+type HookCandidate struct {
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"` // 0-10
+	Reason string  `json:"reason,omitempty"`
+}
+
+// applyHook handles the request's hook options for a generated script. ok is
+// false when it has already answered the request (hooks=all).
+func applyHook(r *request, job *Job, topic string, script *ScriptResponse, opts ScriptOptions) (ok bool) {
+	if opts.Hook != "" {
+		script.Intro = opts.Hook
+		return true
+	}
+	if opts.Hooks == "" {
+		return true
+	}
+	job.Stage("hooks")
+	hooks, err := optimizeHook(job, topic, *script, opts)
+	if err != nil {
+		fmt.Printf("⚠️ Hook optimization skipped: %v\n", err)
+		return true
+	}
+	script.Hooks = hooks
+	if opts.Hooks == "all" {
+		r.JSON(200, gin.H{"status": "hooks_ready", "job_id": job.ID, "script": script, "hooks": hooks})
+		return false
+	}
+	fmt.Printf("🪝 Hook picked (%.1f): %s\n", hooks[0].Score, hooks[0].Text)
+	script.Intro = hooks[0].Text
+	return true
+}
+
+// optimizeHook writes the candidates and returns them best first.
+func optimizeHook(job *Job, topic string, script ScriptResponse, opts ScriptOptions) ([]HookCandidate, error) {
+	titles := make([]string, len(script.Items))
+	for i, it := range script.Items {
+		titles[i] = it.Title
+	}
+
+	prompt := fmt.Sprintf(`
+    Topic: "%s"%s
+    The video covers: %s
+    Current intro: "%s"
+    Write %d alternative intros of around 35 words for this video. Each must open with a hook line that
+    creates curiosity in the first 3 seconds (a surprising fact, a bold claim, a question or an open loop)
+    and must not promise anything the video does not cover. Make the %d clearly different in approach.
+    RETURN JSON ONLY:
+    { "hooks": ["intro text", "..."] }
+    `, topic, opts.promptLines(""), strings.Join(titles, "; "), script.Intro, hookCandidates, hookCandidates)
+
+	var written struct {
+		Hooks []string `json:"hooks"`
+	}
+	if err := completeJSON(job, "hooks", prompt, &written); err != nil {
+		return nil, err
+	}
+	var candidates []HookCandidate
+	for _, h := range written.Hooks {
+		if h = strings.TrimSpace(h); h != "" && len(candidates) < hookCandidates {
+			candidates = append(candidates, HookCandidate{Text: h})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no hooks returned")
+	}
+
+	var list strings.Builder
+	for i, h := range candidates {
+		fmt.Fprintf(&list, "\n    %d: %s", i+1, h.Text)
+	}
+	prompt = fmt.Sprintf(`
+    You judge intros of short videos about "%s".
+    Score each intro from 0 to 10 for how likely a viewer is to keep watching past the first 3 seconds:
+    curiosity, clarity and emotional pull of the opening line count most; clickbait that the video cannot
+    deliver counts against it.
+    INTROS:%s
+    RETURN JSON ONLY:
+    { "scores": [ { "index": 1, "score": 7.5, "reason": "short reason" } ] }
+    `, topic, list.String())
+
+	var scored struct {
+		Scores []struct {
+			Index  int     `json:"index"`
+			Score  float64 `json:"score"`
+			Reason string  `json:"reason"`
+		} `json:"scores"`
+	}
+	if err := completeJSON(job, "hook_scores", prompt, &scored); err != nil {
+		return nil, err
+	}
+	for _, s := range scored.Scores {
+		if s.Index >= 1 && s.Index <= len(candidates) {
+			candidates[s.Index-1].Score = min(max(s.Score, 0), 10)
+			candidates[s.Index-1].Reason = s.Reason
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates, nil
+}
@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// --- JOB WORKSPACE ---
+// Every request renders inside its own output/jobs/<id> directory. Stored
+// media get generated names; the only part of a client filename that is
+// kept is a sanitized extension.
+type Job struct {
+	ID  string
+	Dir string
+
+	mu      sync.Mutex
+	fetched map[string]string // download key -> file already in the workspace
+	sources []Source          // see AddSource
+	credits map[string]Source // scene media path -> where it came from
+
+	lostNarration []LostNarration // see NarrationGap
+	plan          *DurationPlan   // see setPlan
+	script        *ScriptResponse // see scriptReady
+	llm           *chatModel      // model LLM calls use, nil for Groq; see useModel
+
+	hook     *webhook       // lifecycle events, see newRequestJob
+	debug    *Debug         // nil unless the request asked for debug=true
+	stream   *scriptStream  // server-sent events, see openStream
+	progress func(Progress) // see Pipeline.Progress
+
+	timings *Timings
+	seed    int    // see seedFromForm
+	owner   string // the caller, see keyOwner; owns the assets the job stores
+
+	parentJob string // the job this re-renders, see addVersion
+
+	// A retry's reusable work from the failed job, see handleRetryJob.
+	retryOf      string
+	llmReplay    map[string]string
+	segmentReuse map[string]reusedSegment
+}
+
+func newJob() (*Job, error) {
+	id := newUUID()
+	dir := filepath.Join("output", "jobs", id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create job workspace: %v", err)
+	}
+	return &Job{ID: id, Dir: dir, fetched: map[string]string{}, timings: &Timings{}}, nil
+}
+
+// Fetch runs fetch into a new media path unless a file was already fetched
+// for key in this job, in which case that file is reused. Scenes only ever
+// read their media, so sharing one copy is safe. Failures are not cached.
+// Keys are "<source>:<label>"; fetched files go into the asset library.
+func (j *Job) Fetch(key, ext string, fetch func(dest string) error) (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if path, ok := j.fetched[key]; ok {
+		return path, nil
+	}
+	dest := j.MediaPath(ext)
+	if err := fetch(dest); err != nil {
+		os.Remove(dest)
+		return dest, err
+	}
+	source, label, _ := strings.Cut(key, ":")
+	if stored, err := storeAsset(dest, source, label, j.owner); err == nil {
+		dest = stored
+	}
+	j.appendJSONLine("licenses.jsonl", mediaUse{Media: mediaRef(dest), Origin: source, Label: label})
+	j.fetched[key] = dest
+	return dest, nil
+}
+
+// Path returns a server-chosen file name inside the workspace.
+func (j *Job) Path(name string) string {
+	return filepath.Join(j.Dir, name)
+}
+
+// MediaPath returns a fresh opaque path for stored media with the given extension.
+func (j *Job) MediaPath(ext string) string {
+	return j.Path(newUUID() + ext)
+}
+
+// RemoveSegments deletes the rendered segments and the stitcher's
+// intermediates once the final video exists. Segments outside the workspace
+// (cached stings) are shared and kept.
+func (j *Job) RemoveSegments(segments []Segment) {
+	for _, s := range segments {
+		if filepath.Dir(s.Path) == j.Dir {
+			os.Remove(s.Path)
+		}
+	}
+	norm, _ := filepath.Glob(j.Path("norm_*.mp4"))
+	for _, f := range append(norm, j.Path("list.txt")) {
+		os.Remove(f)
+	}
+}
+
+// SaveResult records the job's final response as result.json, served by GET /v1/jobs/:id.
+func (j *Job) SaveResult(result map[string]any) {
+	data, _ := json.MarshalIndent(result, "", "  ")
+	os.WriteFile(j.Path("result.json"), data, 0644)
+}
+
+var errJobNotFound = errors.New("job not found")
+
+// openJob returns an existing job's workspace and its saved result, which is
+// nil while the job is still running.
+func openJob(id string) (*Job, map[string]any, error) {
+	dir := filepath.Join("output", "jobs", id)
+	if !uuidPattern.MatchString(id) {
+		return nil, nil, errJobNotFound
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil, errJobNotFound
+	}
+	job := &Job{ID: id, Dir: dir, fetched: map[string]string{}, timings: &Timings{}}
+	data, err := os.ReadFile(job.Path("result.json"))
+	if err != nil {
+		return job, nil, nil
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return job, nil, fmt.Errorf("unreadable job result")
+	}
+	return job, result, nil
+}
+
+// URL is the public address of a file inside the workspace; base is the
+// server's scheme://host, see baseURL.
+func (j *Job) URL(base, path string) string {
+	return publicURL(base, "jobs/"+j.ID+"/"+filepath.Base(path))
+}
+
+var uuidPattern = regexp.MustCompile(`^[a-f0-9-]{36}$`)
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// safeExt returns filename's extension lowercased if it is short and
+// alphanumeric, otherwise fallback.
+func safeExt(filename, fallback string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(ext) < 2 || len(ext) > 6 {
+		return fallback
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return fallback
+		}
+	}
+	return ext
+}
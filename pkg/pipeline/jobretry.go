@@ -0,0 +1,239 @@
+package pipeline
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- JOB RETRY ---
+// POST /v1/jobs/:id/retry re-runs a failed generation request. Every
+// request is kept in its workspace as request.json, with copies of its
+// uploads and the seed it ran with, and the retry replays it: fields sent
+// to the retry (e.g. tts_provider=elevenlabs) override the saved ones and
+// files replace the saved file of their field. By default the retry reuses
+// what the failed job produced: LLM answers to identical prompts, so the
+// script stays the same, and segments rendered with identical options.
+// reuse_script=false or reuse_segments=false opt out. The response is the
+// new job's, with "retry_of" set.
+type savedRequest struct {
+	Path   string              `json:"path"`
+	Fields map[string][]string `json:"fields"`
+	Files  map[string][]string `json:"files"` // form field -> files in the workspace
+}
+
+// retrySource is what a retry may reuse, carried by the replayed request
+// to newRequestJob.
+type retrySource struct {
+	Job      *Job
+	Script   bool
+	Segments bool
+}
+
+// reusedSegment is a line of segments.jsonl.
+type reusedSegment struct {
+	Key      string `json:"key"`
+	File     string `json:"file"`
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// llmAnswer is a line of llm.jsonl.
+type llmAnswer struct {
+	Key    string `json:"key"`
+	Answer string `json:"answer"`
+}
+
+// saveRequest records the job's form fields and uploads for a retry.
+func (j *Job) saveRequest(r *request) {
+	fields, uploads := r.values()
+	if len(fields) == 0 {
+		return // JSON bodies are not replayable
+	}
+	req := savedRequest{Path: r.path, Fields: map[string][]string{}, Files: map[string][]string{}}
+	for k, v := range fields {
+		req.Fields[k] = v
+	}
+	if _, ok := req.Fields["seed"]; !ok {
+		req.Fields["seed"] = []string{strconv.Itoa(j.seed)}
+	}
+	n := 0
+	for field, files := range uploads {
+		for _, u := range files {
+			name := fmt.Sprintf("request_%d%s", n, safeExt(u.Filename, ""))
+			n++
+			if err := u.save(j.Path(name)); err != nil {
+				fmt.Printf("⚠️ Upload %s not kept for retries: %v\n", field, err)
+				continue
+			}
+			req.Files[field] = append(req.Files[field], name)
+		}
+	}
+	data, _ := json.MarshalIndent(req, "", "  ")
+	os.WriteFile(j.Path("request.json"), data, 0644)
+}
+
+// loadReuse takes the failed job's LLM answers and segments, as asked.
+func (j *Job) loadReuse(from retrySource) {
+	j.retryOf, j.parentJob = from.Job.ID, from.Job.ID
+	if from.Script {
+		j.llmReplay = map[string]string{}
+		readJSONLines(from.Job.Path("llm.jsonl"), func(a llmAnswer) { j.llmReplay[a.Key] = a.Answer })
+	}
+	if from.Segments {
+		j.segmentReuse = map[string]reusedSegment{}
+		readJSONLines(from.Job.Path("segments.jsonl"), func(s reusedSegment) {
+			s.File = from.Job.Path(filepath.Base(s.File))
+			j.segmentReuse[s.Key] = s
+		})
+	}
+	fmt.Printf("🔁 Retry of job %s: %d LLM answers, %d segments to reuse\n", from.Job.ID, len(j.llmReplay), len(j.segmentReuse))
+}
+
+func readJSONLines[T any](path string, each func(T)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 4<<20)
+	for scanner.Scan() {
+		var v T
+		if json.Unmarshal(scanner.Bytes(), &v) == nil {
+			each(v)
+		}
+	}
+}
+
+// appendJSONLine adds v to a .jsonl file in the workspace; callers hold j.mu.
+func (j *Job) appendJSONLine(name string, v any) {
+	f, err := os.OpenFile(j.Path(name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	line, _ := json.Marshal(v)
+	f.Write(append(line, '\n'))
+}
+
+func llmKey(name, prompt string) string {
+	sum := sha256.Sum256([]byte(name + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// replayedAnswer is the failed job's answer to the same call, if reused.
+func (j *Job) replayedAnswer(key string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	answer, ok := j.llmReplay[key]
+	return answer, ok
+}
+
+func (j *Job) recordAnswer(key, answer string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.appendJSONLine("llm.jsonl", llmAnswer{Key: key, Answer: answer})
+}
+
+// segmentKey identifies a segment render by everything that shapes it.
+func segmentKey(spec SegmentSpec, opts RenderOptions) string {
+	data, _ := json.Marshal(struct {
+		Spec    SegmentSpec
+		Type    string
+		FPS     int
+		Card    CardStyle
+		Beats   []float64
+		Preview bool
+		Lexicon Lexicon
+	}{spec, opts.VideoType, opts.frameRate(), opts.Card, opts.Beats, opts.Preview, opts.Lexicon})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// reuseSegment links the failed job's render of key to outPath.
+func (j *Job) reuseSegment(key, outPath string) (string, bool) {
+	j.mu.Lock()
+	s, ok := j.segmentReuse[key]
+	j.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	if err := os.Link(s.File, outPath); err != nil && CopyFile(s.File, outPath) != nil {
+		return "", false
+	}
+	fmt.Printf("♻️ Reused %s for %s\n", filepath.Base(s.File), filepath.Base(outPath))
+	return s.Fallback, true
+}
+
+func (j *Job) recordSegment(key, outPath, fallback string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.appendJSONLine("segments.jsonl", reusedSegment{Key: key, File: filepath.Base(outPath), Fallback: fallback})
+}
+
+// POST /v1/jobs/:id/retry
+func handleRetryJob(c *gin.Context) {
+	old, result, err := openJob(c.Param("id"))
+	if err == errJobNotFound {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(409, gin.H{"error": "Job is still running"})
+		return
+	}
+	if result["status"] != "failed" {
+		c.JSON(409, gin.H{"error": "Only failed jobs can be retried"})
+		return
+	}
+	data, err := os.ReadFile(old.Path("request.json"))
+	var saved savedRequest
+	if err != nil || json.Unmarshal(data, &saved) != nil {
+		c.JSON(409, gin.H{"error": "This job's request was not kept and cannot be retried"})
+		return
+	}
+
+	source := retrySource{Job: old, Script: c.DefaultPostForm("reuse_script", "true") != "false",
+		Segments: c.DefaultPostForm("reuse_segments", "true") != "false"}
+	fields := url.Values(saved.Fields)
+	for k, v := range c.Request.PostForm {
+		if k != "reuse_script" && k != "reuse_segments" {
+			fields[k] = v
+		}
+	}
+	files := map[string]*Upload{}
+	if form := c.Request.MultipartForm; form != nil {
+		for field, fhs := range form.File {
+			files[field] = multipartUpload(fhs[0])
+		}
+	}
+	for field, names := range saved.Files {
+		if _, replaced := files[field]; replaced || len(names) == 0 {
+			continue
+		}
+		u, err := fileUpload(old.Path(filepath.Base(names[0])))
+		if err != nil {
+			c.JSON(409, gin.H{"error": "Saved upload missing: " + field})
+			return
+		}
+		files[field] = u
+	}
+
+	fmt.Printf("🔁 Retrying job %s (%s)\n", old.ID, saved.Path)
+	r := newSpecRequest(saved.Path, baseURL(c), specForm{fields: fields, files: files, header: c.Request.Header.Clone()})
+	r.retry = &source
+	run(r)
+	c.JSON(r.code, r.body)
+}
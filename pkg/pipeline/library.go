@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- MUSIC LIBRARY ---
+// Operators mount royalty-free tracks in MUSIC_DIR (default ./music). An
+// optional library.json there lists each track's file and tags:
+//
+//	[{"id": "sunrise", "title": "Sunrise", "file": "sunrise.mp3",
+//	  "moods": ["upbeat"], "genres": ["acoustic"],
+//	  "license": "CC BY 4.0, Artist Name"}]
+//
+// Without it every audio file is a track whose ID is its file name.
+type MusicTrack struct {
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	File   string   `json:"file"`
+	Moods  []string `json:"moods"`
+	Genres []string `json:"genres"`
+	URL    string   `json:"url,omitempty"`
+
+	License string `json:"license,omitempty"` // listed in the job's license report
+}
+
+func musicDir() string {
+	if dir := os.Getenv("MUSIC_DIR"); dir != "" {
+		return dir
+	}
+	return "music"
+}
+
+func loadMusicLibrary() ([]MusicTrack, error) {
+	dir := musicDir()
+	var tracks []MusicTrack
+	if data, err := os.ReadFile(filepath.Join(dir, "library.json")); err == nil {
+		if err := json.Unmarshal(data, &tracks); err != nil {
+			return nil, fmt.Errorf("library.json: %v", err)
+		}
+		// Entries must point at files inside the library.
+		valid := tracks[:0]
+		for _, t := range tracks {
+			t.File = filepath.Base(t.File)
+			if t.ID != "" && assetKind(strings.ToLower(filepath.Ext(t.File))) == "audio" {
+				valid = append(valid, t)
+			}
+		}
+		return valid, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || assetKind(ext) != "audio" {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		tracks = append(tracks, MusicTrack{ID: id, Title: id, File: e.Name()})
+	}
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].ID < tracks[j].ID })
+	return tracks, nil
+}
+
+// musicTrackPath resolves a music_id to the track's file.
+func musicTrackPath(job *Job, id string) (string, error) {
+	tracks, err := loadMusicLibrary()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tracks {
+		if t.ID == id {
+			return job.useTrack(t), nil
+		}
+	}
+	return "", fmt.Errorf("unknown music_id %q", id)
+}
+
+// useTrack logs the track for the job's license report and returns its path.
+func (j *Job) useTrack(t MusicTrack) string {
+	path := filepath.Join(musicDir(), t.File)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.appendJSONLine("licenses.jsonl", mediaUse{Media: "music/" + t.File, Origin: "music", Label: t.ID, License: t.License})
+	return path
+}
+
+// Moods the script generator may suggest; library tracks are tagged with these.
+var musicMoods = []string{"upbeat", "suspenseful", "emotional", "calm", "epic", "playful"}
+
+// applyMood picks a library track tagged with the script's mood, by the
+// job's seed, when the caller didn't choose music. Nothing is picked if no
+// track matches.
+func applyMood(job *Job, opts *FinishOptions, mood string) {
+	if !opts.AutoMusic || mood == "" {
+		return
+	}
+	tracks, _ := loadMusicLibrary()
+	var matches []MusicTrack
+	for _, t := range tracks {
+		if hasTag(t.Moods, mood) {
+			matches = append(matches, t)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	t := matches[seededIndex(opts.Seed, "music:"+mood, len(matches))]
+	opts.MusicPath = job.useTrack(t)
+	fmt.Printf("🎵 Mood %q: using %s\n", mood, t.ID)
+}
+
+func hasTag(tags []string, want string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /music?mood=upbeat&genre=electronic
+func handleListMusic(c *gin.Context) {
+	tracks, err := loadMusicLibrary()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	mood, genre := c.Query("mood"), c.Query("genre")
+	out := []MusicTrack{}
+	for _, t := range tracks {
+		if (mood != "" && !hasTag(t.Moods, mood)) || (genre != "" && !hasTag(t.Genres, genre)) {
+			continue
+		}
+		t.URL = baseURL(c) + "/music/files/" + t.File
+		out = append(out, t)
+	}
+	c.JSON(200, gin.H{"tracks": out})
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- LICENSES ---
+// Every file a job takes in as media (uploads, library assets, TMDB
+// artwork, stock and Unsplash photos, maps, AI images, remote clips,
+// generated cards, music) is logged in its workspace's licenses.jsonl
+// with where it came from. GET /v1/jobs/:id/licenses turns the log into a
+// report for answering copyright claims: each file with its origin, the
+// license it is used under, the credited author and page, and the segments
+// of the video showing it. Entries with "review": true carry no license
+// from their provider, e.g. studio artwork or someone's video, and need
+// the creator's own justification.
+type MediaLicense struct {
+	Media     string `json:"media"` // spec reference, see specRef
+	Origin    string `json:"origin"`
+	Label     string `json:"label,omitempty"` // file name, search query or track ID
+	License   string `json:"license"`
+	Review    bool   `json:"review,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Author    string `json:"author,omitempty"`
+	AuthorURL string `json:"author_url,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
+	Segments  []int  `json:"segments,omitempty"`
+}
+
+// mediaUse is a line of licenses.jsonl; sources are logged separately, by
+// AddSource, and merged by Media.
+type mediaUse struct {
+	Media   string  `json:"media"`
+	Origin  string  `json:"origin,omitempty"`
+	Label   string  `json:"label,omitempty"`
+	License string  `json:"license,omitempty"` // set where the origin doesn't say, e.g. a music track's
+	Source  *Source `json:"source,omitempty"`
+}
+
+// mediaLicenses is the license each origin implies; review marks the ones
+// granting no reuse rights.
+var mediaLicenses = map[string]struct {
+	License string
+	Review  bool
+}{
+	"upload":   {"Supplied by the requester, who holds or has cleared the rights", false},
+	"s3":       {"Supplied by the requester from their storage, who holds or has cleared the rights", false},
+	"tmdb":     {"Copyrighted artwork found through TMDB; TMDB grants no license to the image", true},
+	"stock":    {"Pexels License (https://www.pexels.com/license/)", false},
+	"unsplash": {"Unsplash License (https://unsplash.com/license)", false},
+	"broll":    {"Pexels License (https://www.pexels.com/license/)", false},
+	"maps":     {"Google Maps Platform Terms of Service; attribution to Google required", false},
+	"ai":       {"AI-generated by Pollinations for this job", false},
+	"video":    {"Clip of a third-party video; rights remain with its owner", true},
+	"url":      {"Image from a linked page or feed; rights remain with its publisher", true},
+	"card":     {"Generated by this service", false},
+	"quote":    {"Generated by this service", false},
+	"match":    {"Generated by this service from club crests listed separately", false},
+	"music":    {"Music library track (license not recorded)", true},
+}
+
+func mediaRef(path string) string {
+	if ref := specRef(path); ref != "" {
+		return ref
+	}
+	return filepath.Base(path)
+}
+
+// recordMedia logs a file the job uses; callers must not hold j.mu.
+func (j *Job) recordMedia(path, origin, label string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.appendJSONLine("licenses.jsonl", mediaUse{Media: mediaRef(path), Origin: origin, Label: label})
+}
+
+// recordAsset logs a library asset under the source it was stored from.
+func (j *Job) recordAsset(path, id string) {
+	origin := "asset"
+	if a, err := readAsset(strings.ToLower(strings.TrimSpace(id))); err == nil && a.Source != "" {
+		origin = a.Source
+	}
+	j.recordMedia(path, origin, id)
+}
+
+// licenseReport merges the job's log into one entry per file, in first use
+// order, with the segments of spec.json showing it.
+func (j *Job) licenseReport() []MediaLicense {
+	var order []string
+	byMedia := map[string]*MediaLicense{}
+	readJSONLines(j.Path("licenses.jsonl"), func(u mediaUse) {
+		e, ok := byMedia[u.Media]
+		if !ok {
+			e = &MediaLicense{Media: u.Media}
+			byMedia[u.Media] = e
+			order = append(order, u.Media)
+		}
+		if u.Origin != "" && e.Origin == "" {
+			e.Origin, e.Label = u.Origin, u.Label
+			terms, known := mediaLicenses[u.Origin]
+			e.License, e.Review = terms.License, terms.Review || !known
+			if !known {
+				e.License = "Unknown origin " + u.Origin
+			}
+		}
+		if u.License != "" {
+			e.License, e.Review = u.License, false
+		}
+		if s := u.Source; s != nil {
+			e.Provider, e.Author, e.AuthorURL, e.SourceURL = s.Provider, s.Author, s.AuthorURL, s.URL
+		}
+	})
+
+	var spec RenderSpec
+	if readSpec(j.Path("spec.json"), &spec) {
+		for i, s := range spec.Segments {
+			for _, ref := range append([]string{s.Media, s.PiP, s.ChromaKey}, s.BRoll...) {
+				if e, ok := byMedia[ref]; ok && ref != "" {
+					e.Segments = append(e.Segments, i)
+				}
+			}
+		}
+	}
+
+	report := make([]MediaLicense, 0, len(order))
+	for _, m := range order {
+		e := byMedia[m]
+		if e.Origin == "" {
+			e.Origin, e.License, e.Review = "unknown", "Unknown origin", true
+		}
+		sort.Ints(e.Segments)
+		report = append(report, *e)
+	}
+	return report
+}
+
+// GET /v1/jobs/:id/licenses
+func handleGetLicenses(c *gin.Context) {
+	job, _, err := openJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	report := job.licenseReport()
+	review := 0
+	for _, e := range report {
+		if e.Review {
+			review++
+		}
+	}
+	c.JSON(200, gin.H{"job_id": job.ID, "media": report,
+		"summary": fmt.Sprintf("%d media files, %d need review", len(report), review)})
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- REQUEST LIMITS ---
+// MAX_UPLOAD_MB caps each uploaded file, MAX_REQUEST_MB the whole request
+// body and MAX_SCENES the number of scenes; MULTIPART_MEMORY_MB is how much
+// of a form is buffered in memory before spilling to temp files.
+type Limits struct {
+	MaxFile    int64
+	MaxRequest int64
+	MaxScenes  int
+	Memory     int64
+}
+
+func limitsFromEnv() Limits {
+	return Limits{
+		MaxFile:    envInt("MAX_UPLOAD_MB", 100) << 20,
+		MaxRequest: envInt("MAX_REQUEST_MB", 300) << 20,
+		MaxScenes:  int(envInt("MAX_SCENES", 25)),
+		Memory:     envInt("MULTIPART_MEMORY_MB", 100) << 20,
+	}
+}
+
+func envInt(key string, def int64) int64 {
+	if v, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// uploadMSKey holds how long reading the multipart body took, reported as
+// the job's upload stage.
+const uploadMSKey = "upload_ms"
+
+// limitUploads rejects oversized requests and files with 413 before the
+// handler runs, so no rendering work starts on a request that can't succeed.
+func limitUploads(limits Limits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limits.MaxRequest {
+			c.AbortWithStatusJSON(413, gin.H{"error": fmt.Sprintf("Request exceeds %d MB", limits.MaxRequest>>20)})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxRequest)
+
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			start := time.Now()
+			form, err := c.MultipartForm()
+			c.Set(uploadMSKey, time.Since(start).Milliseconds())
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				c.AbortWithStatusJSON(413, gin.H{"error": fmt.Sprintf("Request exceeds %d MB", limits.MaxRequest>>20)})
+				return
+			}
+			if err == nil {
+				for field, files := range form.File {
+					for _, f := range files {
+						if f.Size > limits.MaxFile {
+							c.AbortWithStatusJSON(413, gin.H{"error": fmt.Sprintf("%s exceeds %d MB", field, limits.MaxFile>>20)})
+							return
+						}
+					}
+				}
+			}
+		}
+		c.Next()
+	}
+}
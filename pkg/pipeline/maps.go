@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// --- MAPS ---
+// The "maps" media source gives scenes about places (category place,
+// travel, city or landmark, per request or per scene) a Google Street View
+// image of the place, or a Static Maps map where Street View has no
+// coverage. maps_view=map or satellite skips Street View and picks the map
+// type. It needs GOOGLE_MAPS_API_KEY with both APIs enabled; images are
+// credited to Google Maps.
+var mapCategories = map[string]bool{"place": true, "travel": true, "city": true, "landmark": true}
+
+var mapViews = map[string]bool{"street": true, "map": true, "satellite": true}
+
+func mapsView(v string) string {
+	view := strings.ToLower(strings.TrimSpace(v))
+	if !mapViews[view] {
+		return "street"
+	}
+	return view
+}
+
+// downloadPlaceImage fetches the street-level image or map of place.
+func downloadPlaceImage(place, view, dest, videoType string) (Source, error) {
+	key := os.Getenv("GOOGLE_MAPS_API_KEY")
+	if key == "" {
+		return Source{}, fmt.Errorf("missing key")
+	}
+	// Both APIs cap images at 640px a side; Static Maps doubles it with scale=2.
+	size := "360x640"
+	if videoType == "long" {
+		size = "640x360"
+	}
+	source := Source{Provider: "Google Maps", Title: place,
+		URL: "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(place)}
+
+	if view == "street" {
+		if ok, err := hasStreetView(place, key); err != nil {
+			fmt.Printf("⚠️ Street View lookup for %q failed: %v\n", place, err)
+		} else if ok {
+			q := url.Values{"location": {place}, "size": {size}, "fov": {"90"}, "source": {"outdoor"}, "key": {key}}
+			return source, fetchMapsImage("https://maps.googleapis.com/maps/api/streetview?"+q.Encode(), dest)
+		}
+		view = "map"
+	}
+	mapType := "roadmap"
+	if view == "satellite" {
+		mapType = "hybrid"
+	}
+	q := url.Values{"center": {place}, "zoom": {"13"}, "size": {size}, "scale": {"2"}, "format": {"jpg"},
+		"maptype": {mapType}, "markers": {"color:red|" + place}, "key": {key}}
+	return source, fetchMapsImage("https://maps.googleapis.com/maps/api/staticmap?"+q.Encode(), dest)
+}
+
+// hasStreetView checks coverage with the metadata endpoint, which is free,
+// so places without it get a map instead of a grey "no imagery" tile.
+func hasStreetView(place, key string) (bool, error) {
+	q := url.Values{"location": {place}, "source": {"outdoor"}, "key": {key}}
+	resp, err := http.Get("https://maps.googleapis.com/maps/api/streetview/metadata?" + q.Encode())
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	var meta struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return false, err
+	}
+	switch meta.Status {
+	case "OK":
+		return true, nil
+	case "ZERO_RESULTS", "NOT_FOUND":
+		return false, nil
+	}
+	return false, fmt.Errorf("status %s", meta.Status)
+}
+
+// fetchMapsImage downloads an image, rejecting the error pages the Maps
+// APIs return with a non-200 status.
+func fetchMapsImage(apiURL, dest string) error {
+	resp, err := http.Get(apiURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("maps status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		return fmt.Errorf("maps returned %s", ct)
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
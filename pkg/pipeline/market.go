@@ -0,0 +1,305 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- MARKET MODE ---
+// POST /generate-market-video recaps tickers=AAPL,MSFT,BTC with one chart
+// scene each: days (30, 5-365) of daily prices drawn as chart=candlestick
+// (default) or line, narrated from the computed moves. Stocks come from
+// Stooq (a bare symbol means a US listing; "vod.uk" picks another market),
+// crypto from CoinGecko: the symbols in cryptoIDs or crypto:<coingecko id>.
+const maxTickers = 8
+
+var cryptoIDs = map[string]string{
+	"BTC": "bitcoin", "ETH": "ethereum", "SOL": "solana", "XRP": "ripple", "DOGE": "dogecoin",
+	"ADA": "cardano", "BNB": "binancecoin", "LTC": "litecoin", "DOT": "polkadot", "AVAX": "avalanche-2",
+}
+
+// Quote is a ticker's price history, oldest bar first.
+type Quote struct {
+	Symbol string
+	Crypto bool
+	Bars   []Bar
+}
+
+func (q Quote) last() float64 { return q.Bars[len(q.Bars)-1].Close }
+
+// DayChange is the last close against the one before, in percent.
+func (q Quote) DayChange() float64 {
+	return pctChange(q.Bars[len(q.Bars)-2].Close, q.last())
+}
+
+// PeriodChange is the last close against the first open, in percent.
+func (q Quote) PeriodChange() float64 {
+	return pctChange(q.Bars[0].Open, q.last())
+}
+
+func pctChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
+
+func (q Quote) Label() string {
+	return fmt.Sprintf("%s %+.1f%%", q.Symbol, q.DayChange())
+}
+
+func handleMarketVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Market Request Received")
+
+	var tickers []string
+	for _, t := range strings.Split(r.PostForm("tickers"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tickers = append(tickers, t)
+		}
+	}
+	if len(tickers) == 0 || len(tickers) > maxTickers {
+		r.JSON(400, gin.H{"error": fmt.Sprintf("tickers must list 1 to %d symbols", maxTickers)})
+		return
+	}
+	days := 30
+	if v := r.PostForm("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 5 || n > 365 {
+			r.JSON(400, gin.H{"error": "days must be between 5 and 365"})
+			return
+		}
+		days = n
+	}
+	style := strings.ToLower(strings.TrimSpace(r.DefaultPostForm("chart", "candlestick")))
+	if !chartStyles[style] {
+		r.JSON(400, gin.H{"error": "chart must be candlestick or line"})
+		return
+	}
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Fetching Prices...")
+	job.Stage("prices")
+	client := &http.Client{Timeout: newsTimeout}
+	var quotes []Quote
+	for _, t := range tickers {
+		q, err := fetchQuote(client, t, days)
+		if err != nil {
+			fmt.Printf("❌ CRITICAL ERROR (Market): %v\n", err)
+			r.JSON(502, gin.H{"error": fmt.Sprintf("Could not fetch %s: %v", t, err)})
+			return
+		}
+		quotes = append(quotes, q)
+		provider, link := "Stooq", "https://stooq.com/q/?s="+stooqSymbol(t)
+		if q.Crypto {
+			provider, link = "CoinGecko", "https://www.coingecko.com/en/coins/"+cryptoID(t)
+		}
+		job.AddSource("", Source{Provider: provider, Title: q.Symbol, URL: link})
+	}
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	if topic == "" {
+		topic = "Market recap"
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Tickers: %d\n", topic, videoType, len(quotes))
+
+	fmt.Println("🔹 STEP 3: Narrating Moves (Groq)...")
+	job.Stage("script")
+	scriptData, err := narrateMarket(job, topic, quotes, days, videoType, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	job.Stage("media")
+	introPath := placeholderCard(job, topic, 0, videoType, base.Card)
+	scenePaths := make([]string, len(scriptData.Items))
+	for i := range scriptData.Items {
+		q := quotes[i]
+		labels := ChartLabels{Title: q.Symbol, Subtitle: fmt.Sprintf("%s  %+.1f%% today  %+.1f%% in %d days",
+			formatPrice(q.last()), q.DayChange(), q.PeriodChange(), days)}
+		path, err := job.Fetch(fmt.Sprintf("chart:%s:%d:%s", q.Symbol, days, style), ".jpg", func(dest string) error {
+			return renderPriceChart(q.Bars, style, labels, dest, videoType, base.Card)
+		})
+		if err != nil {
+			fmt.Printf("⚠️ Chart for %s failed: %v\n", q.Symbol, err)
+			path = placeholderCard(job, q.Label(), i+1, videoType, base.Card)
+		}
+		scenePaths[i] = path
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 4, roundup{Name: "market", Topic: topic, VideoType: videoType, Script: scriptData,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+func formatPrice(v float64) string {
+	if v >= 100 {
+		return fmt.Sprintf("$%.2f", v)
+	}
+	return "$" + strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func cryptoID(ticker string) string {
+	if id, ok := strings.CutPrefix(strings.ToLower(ticker), "crypto:"); ok {
+		return id
+	}
+	return cryptoIDs[strings.ToUpper(ticker)]
+}
+
+func stooqSymbol(ticker string) string {
+	s := strings.ToLower(ticker)
+	if !strings.Contains(s, ".") {
+		s += ".us"
+	}
+	return s
+}
+
+// fetchQuote loads the last days daily bars for ticker.
+func fetchQuote(client *http.Client, ticker string, days int) (Quote, error) {
+	var q Quote
+	var err error
+	if id := cryptoID(ticker); id != "" {
+		q = Quote{Symbol: strings.ToUpper(strings.TrimPrefix(strings.ToLower(ticker), "crypto:")), Crypto: true}
+		q.Bars, err = coinGeckoBars(client, id, days)
+	} else {
+		q = Quote{Symbol: strings.ToUpper(ticker)}
+		q.Bars, err = stooqBars(client, stooqSymbol(ticker), days)
+	}
+	if err == nil && len(q.Bars) < 2 {
+		err = fmt.Errorf("not enough price data")
+	}
+	return q, err
+}
+
+// stooqBars reads Stooq's daily CSV (Date,Open,High,Low,Close,Volume).
+func stooqBars(client *http.Client, symbol string, days int) ([]Bar, error) {
+	resp, err := client.Get("https://stooq.com/q/d/l/?" + url.Values{"s": {symbol}, "i": {"d"}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("stooq status %d", resp.StatusCode)
+	}
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil || len(rows) < 2 {
+		return nil, fmt.Errorf("unknown symbol %s", symbol)
+	}
+	var bars []Bar
+	for _, row := range rows[1:] {
+		if len(row) < 5 {
+			continue
+		}
+		var v [4]float64
+		ok := true
+		for i := range v {
+			if v[i], err = strconv.ParseFloat(row[i+1], 64); err != nil {
+				ok = false
+			}
+		}
+		if ok {
+			bars = append(bars, Bar{Open: v[0], High: v[1], Low: v[2], Close: v[3]})
+		}
+	}
+	if len(bars) > days {
+		bars = bars[len(bars)-days:]
+	}
+	return bars, nil
+}
+
+// coinGeckoBars builds daily bars from CoinGecko's daily prices: each day
+// opens at the previous close, so high and low span the two.
+func coinGeckoBars(client *http.Client, id string, days int) ([]Bar, error) {
+	var res struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	q := url.Values{"vs_currency": {"usd"}, "days": {strconv.Itoa(days)}, "interval": {"daily"}}
+	if err := callJSON(client, "GET", "https://api.coingecko.com/api/v3/coins/"+url.PathEscape(id)+"/market_chart?"+q.Encode(), "", nil, &res); err != nil {
+		return nil, err
+	}
+	var bars []Bar
+	for i := 1; i < len(res.Prices); i++ {
+		o, cl := res.Prices[i-1][1], res.Prices[i][1]
+		bars = append(bars, Bar{Open: o, Close: cl, High: max(o, cl), Low: min(o, cl)})
+	}
+	return bars, nil
+}
+
+// narrateMarket scripts one item per ticker from the computed figures.
+func narrateMarket(job *Job, topic string, quotes []Quote, days int, videoType string, opts ScriptOptions) (ScriptResponse, error) {
+	minWords, maxWords := wordRange(videoType)
+	var list strings.Builder
+	for i, q := range quotes {
+		hi, lo := q.Bars[0].High, q.Bars[0].Low
+		for _, b := range q.Bars {
+			hi, lo = max(hi, b.High), min(lo, b.Low)
+		}
+		fmt.Fprintf(&list, "\nTicker %d: %s, last close %s, %+.2f%% on the day, %+.2f%% over %d days, range %s to %s",
+			i+1, q.Symbol, formatPrice(q.last()), q.DayChange(), q.PeriodChange(), days, formatPrice(lo), formatPrice(hi))
+	}
+
+	return generateForAudience(opts, func(audience string) (ScriptResponse, error) {
+		prompt := fmt.Sprintf(`
+    Topic: "%s" (%s mode)
+    Tone: Clear and calm, like a market reporter.%s
+    Write exactly %d items, one per ticker below and in the same order, describing its moves.
+    Use only the figures given; do not invent news or causes, and do not give investment advice.
+    Constraint: Each item must be between %d and %d words to ensure duration.
+    TICKERS:%s
+    RETURN JSON ONLY:
+    {
+        "intro": "Hook around 35 words",
+        "items": [
+            { "title": "Ticker", "details": "Script text between %d and %d words..." }
+        ],
+        "outro": "Conclusion around 35 words, noting this is not financial advice",
+        "mood": "one of: %s"
+    }
+    `, topic, videoType, audience, len(quotes), minWords, maxWords, list.String(), minWords, maxWords, strings.Join(musicMoods, ", "))
+
+		var result ScriptResponse
+		if err := completeTemplated(job, "market_narration", opts.Template, prompt, &result); err != nil {
+			return ScriptResponse{}, err
+		}
+		if len(result.Items) == 0 {
+			return ScriptResponse{}, fmt.Errorf("no tickers returned")
+		}
+		if len(result.Items) > len(quotes) {
+			result.Items = result.Items[:len(quotes)]
+		}
+		for i := range result.Items {
+			result.Items[i].Title = quotes[i].Label()
+		}
+		return result, nil
+	})
+}
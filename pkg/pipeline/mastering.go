@@ -0,0 +1,23 @@
+package pipeline
+
+// --- MASTERING ---
+// mastering=true runs the final mix through a mastering chain instead of
+// leaving it as joined TTS chunks under a raw music file. The narration is
+// folded to mono and centered, cleaned up (rumble cut, less boxiness, a
+// little presence) and lightly compressed so quiet and loud chunks sit
+// together; music and ambience beds are widened and dipped where the voice
+// lives, so the narration stays clear between them. The mix is then glued
+// with gentle compression and normalized to -14 LUFS with -1 dBTP peaks,
+// the loudness YouTube, TikTok and Spotify play at. Without a bed the
+// narration chain and the master still apply, in a pass of their own.
+const (
+	masterNarration = "aformat=channel_layouts=mono,highpass=f=80,equalizer=f=250:t=q:w=1:g=-2,equalizer=f=3500:t=q:w=1:g=2," +
+		"acompressor=threshold=-20dB:ratio=3:attack=5:release=80:makeup=2,pan=stereo|c0=c0|c1=c0"
+	masterBed = "aformat=channel_layouts=stereo,extrastereo=m=1.6,equalizer=f=2500:t=q:w=1.5:g=-4"
+	masterBus = "acompressor=threshold=-14dB:ratio=2:attack=20:release=250,loudnorm=I=-14:TP=-1:LRA=11,aresample=48000"
+)
+
+// masterAudio masters a video's audio in place when it has no bed to mix.
+func masterAudio(path string) error {
+	return mixBed(path, "master", 0, nil, true)
+}
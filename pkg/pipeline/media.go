@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- UPLOADED MEDIA ---
+// saveMediaUpload stores an uploaded media file in the job, normalizes it
+// and moves it into the asset library.
+func saveMediaUpload(job *Job, file *Upload, fallbackExt string) (string, error) {
+	path := job.MediaPath(safeExt(file.Filename, fallbackExt))
+	if err := saveUpload(file, path); err != nil {
+		return "", err
+	}
+	if err := normalizeMedia(path); err != nil {
+		fmt.Printf("⚠️ %s: %v\n", file.Filename, err)
+	}
+	if stored, err := storeAsset(path, "upload", "", job.owner); err == nil {
+		path = stored
+	}
+	job.recordMedia(path, "upload", file.Filename)
+	return path, nil
+}
+
+// normalizeMedia prepares an uploaded scene file for rendering.
+func normalizeMedia(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".jpg" || ext == ".jpeg" {
+		return applyEXIFOrientation(path)
+	}
+	return nil
+}
+
+// applyEXIFOrientation bakes the EXIF rotation of a phone photo into its
+// pixels. ffmpeg ignores the tag on still images, so portrait shots would
+// otherwise render sideways.
+func applyEXIFOrientation(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	orientation := exifOrientation(bufio.NewReader(f))
+	if orientation <= 1 || orientation > 8 {
+		f.Close()
+		return nil
+	}
+	f.Seek(0, io.SeekStart)
+	img, err := jpeg.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("orientation: %v", err)
+	}
+
+	fmt.Printf("🔄 Applying EXIF orientation %d to %s\n", orientation, path)
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return jpeg.Encode(out, orient(img, orientation), &jpeg.Options{Quality: 92})
+}
+
+// exifOrientation returns the Orientation tag from a JPEG's APP1 segment, or 0.
+func exifOrientation(r *bufio.Reader) int {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return 0
+	}
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil || hdr[0] != 0xFF {
+			return 0
+		}
+		marker, size := hdr[1], int(binary.BigEndian.Uint16(hdr[2:]))-2
+		if marker == 0xDA || size < 0 { // start of scan: no more metadata
+			return 0
+		}
+		seg := make([]byte, size)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return 0
+		}
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+	}
+}
+
+// tiffOrientation reads the Orientation tag from the first IFD of a TIFF
+// header, or 0.
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(t[4:]))
+	if ifd+2 > len(t) {
+		return 0
+	}
+	n := int(order.Uint16(t[ifd:]))
+	for i := 0; i < n; i++ {
+		e := ifd + 2 + i*12
+		if e+12 > len(t) {
+			return 0
+		}
+		if order.Uint16(t[e:]) == 0x0112 {
+			return int(order.Uint16(t[e+8:]))
+		}
+	}
+	return 0
+}
+
+// orient maps a stored image to its display orientation (EXIF values 2-8).
+func orient(src image.Image, o int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// tiffWithOrientation is a TIFF header whose first IFD holds an unrelated
+// tag and then Orientation o.
+func tiffWithOrientation(order binary.ByteOrder, o uint16) []byte {
+	var b bytes.Buffer
+	if order == binary.LittleEndian {
+		b.WriteString("II")
+	} else {
+		b.WriteString("MM")
+	}
+	binary.Write(&b, order, uint16(42))
+	binary.Write(&b, order, uint32(8)) // IFD offset
+	binary.Write(&b, order, uint16(2)) // entries
+	for _, e := range [][4]uint16{{0x010F, 2, 0, 0}, {0x0112, 3, 0, o}} {
+		binary.Write(&b, order, e[0])      // tag
+		binary.Write(&b, order, e[1])      // type
+		binary.Write(&b, order, uint32(1)) // count
+		binary.Write(&b, order, e[3])      // value
+		binary.Write(&b, order, uint16(0))
+	}
+	return b.Bytes()
+}
+
+// jpegWithEXIF wraps tiff in an APP1 segment between SOI and SOS.
+func jpegWithEXIF(tiff []byte) []byte {
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	b := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00} // SOI, empty APP0
+	b = append(b, 0xFF, 0xE1)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(seg)+2))
+	b = append(b, seg...)
+	return append(b, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestEXIFOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := 1; o <= 8; o++ {
+			data := jpegWithEXIF(tiffWithOrientation(order, uint16(o)))
+			if got := exifOrientation(bufio.NewReader(bytes.NewReader(data))); got != o {
+				t.Errorf("%v orientation %d: got %d", order, o, got)
+			}
+		}
+	}
+
+	full := jpegWithEXIF(tiffWithOrientation(binary.BigEndian, 6))
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a jpeg", []byte("GIF89a")},
+		{"soi only", full[:2]},
+		{"no exif", []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}},
+		{"bad marker", []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x10}},
+	}
+	for n := 3; n < len(full)-4; n++ { // cut anywhere inside the APP1 segment
+		tests = append(tests, struct {
+			name string
+			data []byte
+		}{"truncated", full[:n]})
+	}
+	for _, tt := range tests {
+		if got := exifOrientation(bufio.NewReader(bytes.NewReader(tt.data))); got != 0 {
+			t.Errorf("%s (%d bytes): got %d, want 0", tt.name, len(tt.data), got)
+		}
+	}
+}
+
+func TestTIFFOrientation(t *testing.T) {
+	full := tiffWithOrientation(binary.LittleEndian, 3)
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"little endian", full, 3},
+		{"big endian", tiffWithOrientation(binary.BigEndian, 8), 8},
+		{"empty", nil, 0},
+		{"byte order only", []byte("II"), 0},
+		{"header only", full[:8], 0},
+		{"cut in first entry", full[:15], 0},
+		{"cut in orientation entry", full[:28], 0},
+		{"unknown byte order", append([]byte("XX"), full[2:]...), 0},
+		{"ifd past end", append(append([]byte{}, full[:4]...), 0xFF, 0xFF, 0, 0), 0},
+	}
+	for _, tt := range tests {
+		if got := tiffOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
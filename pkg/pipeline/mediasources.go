@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- MEDIA SOURCE CHAIN ---
+// Scene media is taken from the first source in the chain that yields an
+// image. media_sources (also a brand kit setting) replaces the route's
+// default chain, e.g. "upload,stock,placeholder"; a source left out is
+// disabled. Without "placeholder" a scene no source can fill fails the
+// request instead of getting a card.
+//
+//	upload       the scene's upload field, its <field>_asset library ID or
+//	             its <field>_url S3 object (s3://bucket/key); for scenes
+//	             also video_url, see remotevideo.go
+//	tmdb         TMDB poster or profile photo (category movie, tv or actor)
+//	maps         Street View image or map (category place, travel, city or
+//	             landmark), see maps.go
+//	stock        Pexels photo for the scene's search query
+//	unsplash     Unsplash photo for the search query, see unsplash.go
+//	ai           generated image for the search query
+//	placeholder  generated title card
+var mediaSources = map[string]bool{"upload": true, "tmdb": true, "maps": true, "stock": true, "unsplash": true, "ai": true, "placeholder": true}
+
+var (
+	sceneMediaSources = []string{"upload", "tmdb", "maps", "placeholder"} // multi-scene
+	queryMediaSources = []string{"stock", "ai", "placeholder"}            // article and podcast scenes
+)
+
+// MediaRequest describes one scene's media for resolveMedia.
+type MediaRequest struct {
+	FormKey  string // upload field; "" if the scene has none
+	Query    string // search text for tmdb, maps, stock, unsplash and ai; "" skips them
+	Visual   string // the script's on-screen direction, replaces Query for stock, unsplash and ai
+	Text     string // placeholder card text
+	Index    int    // placeholder color index
+	Category string // TMDB applies for the categories in tmdbSearch, maps for mapCategories
+	Context  string // scene narration stock results are ranked against, see rankCandidates
+
+	VideoURL   string // remote clip for the upload source when nothing is uploaded
+	VideoStart float64
+}
+
+// search is the stock and AI image query: the visual direction if any.
+func (m MediaRequest) search() string {
+	if m.Visual != "" {
+		return m.Visual
+	}
+	return m.Query
+}
+
+func mediaSourcesFromForm(f Form, def []string) ([]string, error) {
+	list := strings.TrimSpace(f.PostForm("media_sources"))
+	if list == "" {
+		return def, nil
+	}
+	var chain []string
+	for _, s := range strings.Split(list, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !mediaSources[s] {
+			return nil, fmt.Errorf("unknown media source %q (use upload, tmdb, maps, stock, unsplash, ai, placeholder)", s)
+		}
+		chain = append(chain, s)
+	}
+	return chain, nil
+}
+
+// resolveMedia walks the chain and returns the first media found, as a path
+// in the job workspace or the asset library.
+func resolveMedia(f Form, job *Job, chain []string, m MediaRequest, videoType string, card CardStyle) (string, error) {
+	for _, source := range chain {
+		switch source {
+		case "upload":
+			if m.FormKey == "" && m.VideoURL == "" {
+				continue
+			}
+			if file, err := f.FormFile(m.FormKey); err == nil {
+				path, err := saveMediaUpload(job, file, ".jpg")
+				if err == nil {
+					return path, nil
+				}
+				fmt.Printf("⚠️ %s not used: %v\n", m.FormKey, err)
+			} else if id := f.PostForm(m.FormKey + "_asset"); id != "" {
+				if path, err := assetPath(id, job.owner); err == nil {
+					job.recordAsset(path, id)
+					return path, nil
+				}
+				fmt.Printf("⚠️ %s_asset %q not found\n", m.FormKey, id)
+			} else if ref := f.PostForm(m.FormKey + "_url"); ref != "" {
+				path, err := fetchS3Media(job, ref)
+				if err == nil {
+					return path, nil
+				}
+				fmt.Printf("⚠️ %s_url not used: %v\n", m.FormKey, err)
+			} else if m.VideoURL != "" {
+				path, err := fetchRemoteVideo(job, m.VideoURL, m.VideoStart)
+				if err == nil {
+					return path, nil
+				}
+				fmt.Printf("⚠️ video_url not used: %v\n", err)
+			}
+		case "tmdb":
+			if tmdbSearch[m.Category] == "" || m.Query == "" {
+				continue
+			}
+			var source Source
+			if path, err := job.Fetch("tmdb:"+m.Category+":"+strings.ToLower(m.Query), ".jpg", gated(func(dest string) (err error) {
+				source, err = downloadTMDBPoster(m.Category, m.Query, dest)
+				return err
+			})); err == nil {
+				job.AddSource(path, source)
+				return path, nil
+			}
+		case "maps":
+			if !mapCategories[m.Category] || m.Query == "" {
+				continue
+			}
+			view := mapsView(f.PostForm("maps_view"))
+			var source Source
+			if path, err := job.Fetch("maps:"+view+":"+strings.ToLower(m.Query), ".jpg", func(dest string) (err error) {
+				source, err = downloadPlaceImage(m.Query, view, dest, videoType)
+				return err
+			}); err == nil {
+				job.AddSource(path, source)
+				return path, nil
+			}
+		case "stock":
+			if m.Query == "" {
+				continue
+			}
+			var source Source
+			if path, err := job.Fetch("stock:"+m.search(), ".jpg", gated(func(dest string) (err error) {
+				source, err = downloadStockPhoto(m.search(), m.rankText(), dest, videoType)
+				return err
+			})); err == nil {
+				job.AddSource(path, source)
+				return path, nil
+			}
+		case "unsplash":
+			if m.Query == "" {
+				continue
+			}
+			var source Source
+			if path, err := job.Fetch("unsplash:"+m.search(), ".jpg", gated(func(dest string) (err error) {
+				source, err = downloadUnsplashPhoto(m.search(), m.rankText(), dest, videoType)
+				return err
+			})); err == nil {
+				job.AddSource(path, source)
+				return path, nil
+			}
+		case "ai":
+			if m.Query == "" {
+				continue
+			}
+			if path, err := job.Fetch("ai:"+m.search(), ".jpg", screened(func(dest string) error {
+				return downloadAIImage(m.search(), dest, videoType, job.seed)
+			})); err == nil {
+				return path, nil
+			}
+		case "placeholder":
+			text := m.Text
+			if text == "" {
+				text = "Scene"
+			}
+			return placeholderCard(job, text, m.Index, videoType, card), nil
+		}
+	}
+	return "", fmt.Errorf("no media source (%s) found an image for %q", strings.Join(chain, ", "), m.Text)
+}
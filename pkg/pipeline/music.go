@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --- BACKGROUND MUSIC ---
+const (
+	beatSampleRate = 11025
+	beatHop        = 256  // ~23ms analysis frames
+	maxBeatPad     = 1.0  // never hold a scene more than this to reach a beat
+	minBeatGap     = 0.25 // seconds between detected beats
+)
+
+// mixMusic lays the music bed under the narration, looping it to the video's
+// length and fading it out over the last two seconds.
+func mixMusic(path string, opts FinishOptions) error {
+	return mixBed(path, "music", opts.MusicVolume, func(tl *Timeline) int {
+		return tl.Input(opts.MusicPath, "-stream_loop", "-1")
+	}, opts.Mastering)
+}
+
+// mixBed mixes the endless audio input bed adds under the video's audio,
+// if any, and masters the result if asked.
+func mixBed(path, name string, volume float64, bed func(tl *Timeline) int, master bool) error {
+	total, err := probeDuration(path)
+	if err != nil {
+		return fmt.Errorf("%s: cannot read duration", name)
+	}
+	fadeStart := math.Max(0, total-2)
+	var tl Timeline
+	video := tl.Input(path)
+	tl.Video.Clips = []Clip{{Input: video}}
+	tl.Narration.Clips = []Clip{{Input: video}}
+	if bed != nil {
+		tl.Music = &MusicBed{Input: bed(&tl),
+			Filter: fmt.Sprintf("volume=%.2f,afade=t=out:st=%.3f:d=2", volume, fadeStart)}
+	}
+	if master {
+		tl.Narration.Clips[0].Filter = masterNarration
+		if tl.Music != nil {
+			tl.Music.Filter = masterBed + "," + tl.Music.Filter
+		}
+		tl.Master = []string{masterBus}
+	}
+	compiled, err := tl.Args()
+	if err != nil {
+		return err
+	}
+	tmp := strings.TrimSuffix(path, ".mp4") + "_" + name + ".mp4"
+	args := append([]string{"-y"}, compiled...)
+	args = append(args, "-c:v", "copy", "-c:a", "aac", "-b:a", "192k", "-shortest", "-movflags", "+faststart", tmp)
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s mix: %v | Log: %s", name, err, string(output))
+	}
+	return os.Rename(tmp, path)
+}
+
+// detectBeats returns beat times in seconds, preferring aubio when installed
+// and falling back to onset analysis of the decoded audio.
+func detectBeats(musicPath string) []float64 {
+	if out, err := exec.Command("aubio", "beat", "-i", musicPath).Output(); err == nil {
+		var beats []float64
+		for _, line := range strings.Fields(string(out)) {
+			if t, err := strconv.ParseFloat(line, 64); err == nil {
+				beats = append(beats, t)
+			}
+		}
+		if len(beats) > 0 {
+			return beats
+		}
+	}
+	beats, err := detectOnsets(musicPath)
+	if err != nil {
+		fmt.Printf("⚠️ Beat detection failed: %v\n", err)
+	}
+	return beats
+}
+
+// detectOnsets picks peaks in the positive energy flux of mono PCM against
+// a local adaptive threshold.
+func detectOnsets(musicPath string) ([]float64, error) {
+	pcm, err := ffmpegCommand("-v", "error", "-i", musicPath, "-ac", "1", "-ar", strconv.Itoa(beatSampleRate), "-f", "s16le", "-").Output()
+	if err != nil {
+		return nil, err
+	}
+	n := len(pcm) / 2
+	var energy []float64
+	for start := 0; start+beatHop <= n; start += beatHop {
+		sum := 0.0
+		for i := start; i < start+beatHop; i++ {
+			s := float64(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / 32768
+			sum += s * s
+		}
+		energy = append(energy, math.Sqrt(sum/beatHop))
+	}
+	if len(energy) < 3 {
+		return nil, fmt.Errorf("audio too short")
+	}
+
+	flux := make([]float64, len(energy))
+	for i := 1; i < len(energy); i++ {
+		flux[i] = math.Max(0, energy[i]-energy[i-1])
+	}
+
+	frameSec := float64(beatHop) / beatSampleRate
+	window := 16
+	var beats []float64
+	last := -minBeatGap
+	for i := 1; i+1 < len(flux); i++ {
+		if flux[i] <= flux[i-1] || flux[i] < flux[i+1] {
+			continue
+		}
+		lo, hi := max(0, i-window), min(len(flux), i+window)
+		mean := 0.0
+		for _, f := range flux[lo:hi] {
+			mean += f
+		}
+		mean /= float64(hi - lo)
+		t := float64(i) * frameSec
+		if flux[i] > mean*1.5+0.002 && t-last >= minBeatGap {
+			beats = append(beats, t)
+			last = t
+		}
+	}
+	return beats, nil
+}
+
+// padToBeat holds the segment's last frame (and silence) so that it ends on
+// the first beat after its natural end, if one is close enough.
+func padToBeat(segPath string, start, duration float64, beats []float64) error {
+	end := start + duration
+	i := sort.SearchFloat64s(beats, end)
+	if i >= len(beats) {
+		return nil
+	}
+	pad := beats[i] - end
+	if pad <= 0.02 || pad > maxBeatPad {
+		return nil
+	}
+	tmp := strings.TrimSuffix(segPath, ".mp4") + "_beat.mp4"
+	cmd := ffmpegCommand("-y", "-i", segPath,
+		"-vf", fmt.Sprintf("tpad=stop_mode=clone:stop_duration=%.3f", pad),
+		"-af", fmt.Sprintf("apad=pad_dur=%.3f", pad),
+		"-c:v", "libx264", "-preset", "ultrafast", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k", tmp)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("beat pad: %v | Log: %s", err, string(output))
+	}
+	return os.Rename(tmp, segPath)
+}
@@ -0,0 +1,456 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- NEWS MODE ---
+// POST /generate-news-video renders a roundup of recent headlines: rss=<feed
+// URL> takes a feed's latest items, topic=<query> searches NewsAPI
+// (NEWSAPI_KEY) or, without a key, GDELT. The feed and the images it links
+// are fetched from public addresses only (see publicClient). since_hours
+// (24) drops older stories and stories (5, long videos 8, at most 10) caps
+// how many become scenes. Each story is narrated from its summary, or the
+// article text when the feed has little, and shown with its own image where
+// it has one.
+const (
+	maxStories    = 10
+	maxStoryChars = 2500
+	newsTimeout   = 15 * time.Second
+)
+
+type Headline struct {
+	Title     string
+	Summary   string
+	URL       string
+	Image     string
+	Source    string
+	Published time.Time
+}
+
+func handleNewsVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: News Request Received")
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	feed := strings.TrimSpace(r.PostForm("rss"))
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+	if topic == "" && feed == "" {
+		r.JSON(400, gin.H{"error": "Provide either topic or rss"})
+		return
+	}
+	count := 5
+	if videoType == "long" {
+		count = 8
+	}
+	if v := r.PostForm("stories"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStories {
+			r.JSON(400, gin.H{"error": fmt.Sprintf("stories must be between 1 and %d", maxStories)})
+			return
+		}
+		count = n
+	}
+	since := 24
+	if v := r.PostForm("since_hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 24*30 {
+			r.JSON(400, gin.H{"error": "since_hours must be between 1 and 720"})
+			return
+		}
+		since = n
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sources, err := mediaSourcesFromForm(r, queryMediaSources)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Fetching Headlines...")
+	job.Stage("headlines")
+	cutoff := time.Now().Add(-time.Duration(since) * time.Hour)
+	var headlines []Headline
+	if feed != "" {
+		var title string
+		title, headlines, err = fetchRSSHeadlines(feed, cutoff)
+		if topic == "" {
+			topic = title
+		}
+	} else {
+		headlines, err = searchHeadlines(topic, cutoff, count)
+	}
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (News): %v\n", err)
+		r.JSON(502, gin.H{"error": "Could not fetch headlines: " + err.Error()})
+		return
+	}
+	if len(headlines) == 0 {
+		r.JSON(422, gin.H{"error": fmt.Sprintf("No stories from the last %d hours", since)})
+		return
+	}
+	if len(headlines) > count {
+		headlines = headlines[:count]
+	}
+	if topic == "" {
+		topic = "Today's headlines"
+	}
+	for i := range headlines {
+		enrichHeadline(&headlines[i])
+		if !checkPolicy(r, headlines[i].Title+"\n"+headlines[i].Summary) {
+			return
+		}
+		job.AddSource("", headlineSource(headlines[i]))
+	}
+
+	fmt.Println("🔹 STEP 3: Summarizing Stories (Groq)...")
+	job.Stage("script")
+	scriptData, err := summarizeHeadlines(job, topic, headlines, videoType, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Stories: %d\n", topic, videoType, len(scriptData.Items))
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	factCheck, ok := runFactCheck(r, job, topic, scriptData)
+	if !ok {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	// Each story's own image first, the media source chain otherwise.
+	job.Stage("media")
+	introPath := placeholderCard(job, topic+"\n"+time.Now().Format("January 2, 2006"), 0, videoType, base.Card)
+	scenePaths := make([]string, len(scriptData.Items))
+	for i, item := range scriptData.Items {
+		h := headlines[i]
+		if h.Image != "" {
+			if path, err := job.Fetch("url:"+h.Image, ".jpg", gated(func(dest string) error {
+				return downloadPublicFile(h.Image, dest)
+			})); err == nil {
+				job.AddSource(path, headlineSource(h))
+				scenePaths[i] = path
+				continue
+			}
+		}
+		scenePaths[i], err = resolveMedia(r, job, sources, MediaRequest{Query: h.Title, Text: item.Title, Context: item.Title + ". " + item.Details, Index: i + 1}, videoType, base.Card)
+		if err != nil {
+			r.JSON(422, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 4, roundup{Name: "news", Topic: topic, VideoType: videoType, Script: scriptData, FactCheck: factCheck,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+func headlineSource(h Headline) Source {
+	return Source{Provider: h.Source, Title: h.Title, URL: h.URL}
+}
+
+// summarizeHeadlines writes one scene per story, in the stories' order.
+func summarizeHeadlines(job *Job, topic string, headlines []Headline, videoType string, opts ScriptOptions) (ScriptResponse, error) {
+	minWords, maxWords := wordRange(videoType)
+	var stories strings.Builder
+	for i, h := range headlines {
+		fmt.Fprintf(&stories, "\nStory %d: %s\nSource: %s\nText: %s\n", i+1, h.Title, h.Source, h.Summary)
+	}
+
+	return generateForAudience(opts, func(audience string) (ScriptResponse, error) {
+		prompt := fmt.Sprintf(`
+    Topic: "%s" (%s mode), a news roundup for %s
+    Tone: Neutral and factual, like a news anchor.%s
+    Write exactly %d items, one per story below and in the same order.
+    Report only what each story's text says; never invent details, quotes or numbers.
+    Constraint: Each item must be between %d and %d words to ensure duration.
+    STORIES:
+    %s
+    RETURN JSON ONLY:
+    {
+        "intro": "Hook around 35 words teasing today's top stories",
+        "items": [
+            { "title": "Short headline", "details": "Script text between %d and %d words..." }
+        ],
+        "outro": "Conclusion around 35 words",
+        "mood": "one of: %s"
+    }
+    `, topic, videoType, time.Now().Format("January 2, 2006"), audience, len(headlines), minWords, maxWords,
+			stories.String(), minWords, maxWords, strings.Join(musicMoods, ", "))
+
+		var result ScriptResponse
+		if err := completeTemplated(job, "news_summary", opts.Template, prompt, &result); err != nil {
+			return ScriptResponse{}, err
+		}
+		if len(result.Items) == 0 {
+			return ScriptResponse{}, fmt.Errorf("no stories returned")
+		}
+		if len(result.Items) > len(headlines) {
+			result.Items = result.Items[:len(headlines)]
+		}
+		return result, nil
+	})
+}
+
+// enrichHeadline fills a thin summary or a missing image from the article.
+func enrichHeadline(h *Headline) {
+	if len(h.Summary) >= 300 && h.Image != "" {
+		return
+	}
+	article, err := fetchArticle(h.URL)
+	if err != nil {
+		return
+	}
+	if len(h.Summary) < 300 && len(article.Text) > len(h.Summary) {
+		h.Summary = article.Text
+	}
+	if h.Image == "" && len(article.Images) > 0 {
+		h.Image = article.Images[0]
+	}
+	if len(h.Summary) > maxStoryChars {
+		h.Summary = strings.ToValidUTF8(h.Summary[:maxStoryChars], "")
+	}
+}
+
+type rssFeed struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string      `xml:"title"` // Atom
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Enclosure   struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Media []struct {
+		URL string `xml:"url,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnail []struct {
+		URL string `xml:"url,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// fetchRSSHeadlines reads an RSS 2.0 or Atom feed and returns its title and
+// the items published after cutoff, newest first. Undated items are kept.
+func fetchRSSHeadlines(feedURL string, cutoff time.Time) (string, []Headline, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", nil, fmt.Errorf("invalid rss url")
+	}
+	if err := checkPublicURL(feedURL); err != nil {
+		return "", nil, err
+	}
+	req, _ := http.NewRequest("GET", feedURL, nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	client := &http.Client{Timeout: newsTimeout, Transport: publicClient.Transport, CheckRedirect: publicClient.CheckRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", nil, fmt.Errorf("rss status %d", resp.StatusCode)
+	}
+	var f rssFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return "", nil, fmt.Errorf("invalid feed: %v", err)
+	}
+
+	site := strings.TrimPrefix(u.Hostname(), "www.")
+	var headlines []Headline
+	for _, it := range f.Channel.Items {
+		h := Headline{Title: plainText(it.Title), Summary: plainText(it.Description), URL: strings.TrimSpace(it.Link),
+			Source: site, Published: parseFeedTime(it.PubDate)}
+		switch {
+		case len(it.Media) > 0:
+			h.Image = it.Media[0].URL
+		case len(it.Thumbnail) > 0:
+			h.Image = it.Thumbnail[0].URL
+		case strings.HasPrefix(it.Enclosure.Type, "image/"):
+			h.Image = it.Enclosure.URL
+		}
+		headlines = append(headlines, h)
+	}
+	for _, e := range f.Entries {
+		h := Headline{Title: plainText(e.Title), Summary: plainText(e.Summary), Source: site,
+			Published: parseFeedTime(e.Published)}
+		if h.Summary == "" {
+			h.Summary = plainText(e.Content)
+		}
+		if h.Published.IsZero() {
+			h.Published = parseFeedTime(e.Updated)
+		}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				h.URL = l.Href
+				break
+			}
+		}
+		headlines = append(headlines, h)
+	}
+
+	title := plainText(f.Channel.Title)
+	if title == "" {
+		title = plainText(f.Title)
+	}
+	return title, recentHeadlines(headlines, cutoff), nil
+}
+
+// searchHeadlines finds recent stories about query on NewsAPI, or on GDELT
+// without a NEWSAPI_KEY.
+func searchHeadlines(query string, cutoff time.Time, count int) ([]Headline, error) {
+	client := &http.Client{Timeout: newsTimeout}
+	if key := os.Getenv("NEWSAPI_KEY"); key != "" {
+		q := url.Values{"q": {query}, "from": {cutoff.UTC().Format(time.RFC3339)}, "sortBy": {"publishedAt"},
+			"language": {"en"}, "pageSize": {strconv.Itoa(count)}}
+		req, _ := http.NewRequest("GET", "https://newsapi.org/v2/everything?"+q.Encode(), nil)
+		req.Header.Set("X-Api-Key", key)
+		var res struct {
+			Articles []struct {
+				Source struct {
+					Name string `json:"name"`
+				} `json:"source"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Content     string `json:"content"`
+				URL         string `json:"url"`
+				URLToImage  string `json:"urlToImage"`
+				PublishedAt string `json:"publishedAt"`
+			} `json:"articles"`
+		}
+		if err := getNewsJSON(client, req, &res); err != nil {
+			return nil, err
+		}
+		var headlines []Headline
+		for _, a := range res.Articles {
+			headlines = append(headlines, Headline{Title: a.Title, Summary: plainText(a.Description), URL: a.URL,
+				Image: a.URLToImage, Source: a.Source.Name, Published: parseFeedTime(a.PublishedAt)})
+		}
+		return recentHeadlines(headlines, cutoff), nil
+	}
+
+	hours := max(int(time.Since(cutoff).Hours()), 1)
+	q := url.Values{"query": {query + " sourcelang:english"}, "mode": {"ArtList"}, "format": {"json"},
+		"maxrecords": {strconv.Itoa(count)}, "timespan": {fmt.Sprintf("%dh", hours)}, "sort": {"DateDesc"}}
+	req, _ := http.NewRequest("GET", "https://api.gdeltproject.org/api/v2/doc/doc?"+q.Encode(), nil)
+	var res struct {
+		Articles []struct {
+			URL         string `json:"url"`
+			Title       string `json:"title"`
+			SeenDate    string `json:"seendate"`
+			SocialImage string `json:"socialimage"`
+			Domain      string `json:"domain"`
+		} `json:"articles"`
+	}
+	if err := getNewsJSON(client, req, &res); err != nil {
+		return nil, err
+	}
+	var headlines []Headline
+	for _, a := range res.Articles {
+		seen, _ := time.Parse("20060102T150405Z", a.SeenDate)
+		headlines = append(headlines, Headline{Title: a.Title, URL: a.URL, Image: a.SocialImage, Source: a.Domain, Published: seen})
+	}
+	return recentHeadlines(headlines, cutoff), nil
+}
+
+func getNewsJSON(client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("User-Agent", "vixio-backend/1.0 (news)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s status %d", req.URL.Host, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// recentHeadlines drops untitled, link-less and duplicate stories and those
+// published before cutoff, newest first.
+func recentHeadlines(headlines []Headline, cutoff time.Time) []Headline {
+	seen := map[string]bool{}
+	var kept []Headline
+	for _, h := range headlines {
+		key := strings.ToLower(h.Title)
+		if h.Title == "" || h.URL == "" || seen[key] || (!h.Published.IsZero() && h.Published.Before(cutoff)) {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, h)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Published.After(kept[j].Published) })
+	return kept
+}
+
+var feedTimeLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"}
+
+func parseFeedTime(v string) time.Time {
+	v = strings.TrimSpace(v)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText strips markup and entities from feed text.
+func plainText(v string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(v, " "))), " ")
+}
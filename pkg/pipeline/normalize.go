@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// --- NORMALIZATION ---
+// The stream-copy concat only produces a valid file when every segment has
+// the same codecs, frame size, pixel format, frame rate, timebase and audio
+// layout. Segments come from several renderers (scenes, title cards, stings,
+// podcast chapters) fed by uploads with odd sizes, variable frame rates or
+// rotation metadata, so before stitching each one is probed and any that
+// differ from the canonical format are re-encoded to it.
+type segmentFormat struct {
+	VCodec, PixFmt, FrameRate, AvgRate, TimeBase string
+	Width, Height, Rotation                      int
+	ACodec, SampleRate                           string
+	Channels                                     int
+}
+
+const canonicalRate = "30/1"
+
+func probeSegmentFormat(path string) (segmentFormat, error) {
+	var f segmentFormat
+	out, err := exec.Command("ffprobe", "-v", "error", "-show_entries",
+		"stream=codec_type,codec_name,width,height,pix_fmt,r_frame_rate,avg_frame_rate,time_base,sample_rate,channels:stream_side_data=rotation",
+		"-of", "json", path).Output()
+	if err != nil {
+		return f, fmt.Errorf("probe %s: %v", filepath.Base(path), err)
+	}
+	var probe struct {
+		Streams []struct {
+			CodecType  string `json:"codec_type"`
+			CodecName  string `json:"codec_name"`
+			Width      int    `json:"width"`
+			Height     int    `json:"height"`
+			PixFmt     string `json:"pix_fmt"`
+			RFrameRate string `json:"r_frame_rate"`
+			AvgRate    string `json:"avg_frame_rate"`
+			TimeBase   string `json:"time_base"`
+			SampleRate string `json:"sample_rate"`
+			Channels   int    `json:"channels"`
+			SideData   []struct {
+				Rotation int `json:"rotation"`
+			} `json:"side_data_list"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return f, err
+	}
+	for _, s := range probe.Streams {
+		switch {
+		case s.CodecType == "video" && f.VCodec == "":
+			f.VCodec, f.PixFmt, f.FrameRate, f.AvgRate, f.TimeBase = s.CodecName, s.PixFmt, s.RFrameRate, s.AvgRate, s.TimeBase
+			f.Width, f.Height = s.Width, s.Height
+			for _, sd := range s.SideData {
+				f.Rotation = sd.Rotation
+			}
+		case s.CodecType == "audio" && f.ACodec == "":
+			f.ACodec, f.SampleRate, f.Channels = s.CodecName, s.SampleRate, s.Channels
+		}
+	}
+	if f.VCodec == "" {
+		return f, fmt.Errorf("%s has no video stream", filepath.Base(path))
+	}
+	return f, nil
+}
+
+// canonicalFormat is the target every segment is conformed to: the first
+// segment's frame size (made even, rotation applied), frame rate when it is
+// one we render at, and audio format, as a constant-frame-rate H.264/yuv420p
+// stream.
+func canonicalFormat(first segmentFormat) segmentFormat {
+	w, h := first.Width, first.Height
+	if first.Rotation == 90 || first.Rotation == -90 || first.Rotation == 270 || first.Rotation == -270 {
+		w, h = h, w
+	}
+	rate := canonicalRate
+	if knownRate(first.FrameRate) {
+		rate = first.FrameRate
+	}
+	target := segmentFormat{
+		VCodec: "h264", PixFmt: "yuv420p", FrameRate: rate, AvgRate: rate, TimeBase: "1/15360",
+		Width: w &^ 1, Height: h &^ 1,
+		ACodec: "aac", SampleRate: first.SampleRate, Channels: first.Channels,
+	}
+	if target.SampleRate == "" {
+		target.SampleRate, target.Channels = "44100", 2
+	}
+	return target
+}
+
+// conformSegments returns the files to concatenate and their format,
+// re-encoding any segment that doesn't match the canonical format into dir.
+// Originals are left alone (stings are shared across jobs).
+func conformSegments(files []string, dir string) ([]string, segmentFormat, error) {
+	formats := make([]segmentFormat, len(files))
+	for i, f := range files {
+		var err error
+		if formats[i], err = probeSegmentFormat(f); err != nil {
+			return nil, segmentFormat{}, err
+		}
+	}
+	target := canonicalFormat(formats[0])
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f
+		if formats[i] == target {
+			continue
+		}
+		dest := filepath.Join(dir, fmt.Sprintf("norm_%d.mp4", i))
+		fmt.Printf("⚠️ Segment %d format differs (%dx%d %s@%s, audio %s/%s/%d), re-encoding\n", i, formats[i].Width, formats[i].Height,
+			formats[i].PixFmt, formats[i].AvgRate, formats[i].ACodec, formats[i].SampleRate, formats[i].Channels)
+		if err := reencodeSegment(f, dest, formats[i].ACodec != "", target); err != nil {
+			return nil, target, err
+		}
+		out[i] = dest
+	}
+	return out, target, nil
+}
+
+func reencodeSegment(src, dest string, hasAudio bool, t segmentFormat) error {
+	layout := "stereo"
+	if t.Channels == 1 {
+		layout = "mono"
+	}
+	args := []string{"-y", "-i", src}
+	aMap := "0:a"
+	if !hasAudio {
+		args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("anullsrc=r=%s:cl=%s", t.SampleRate, layout))
+		aMap = "1:a"
+	}
+	args = append(args,
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%s,format=yuv420p", t.Width, t.Height, t.Width, t.Height, t.FrameRate),
+		"-af", fmt.Sprintf("aresample=%s,aformat=channel_layouts=%s", t.SampleRate, layout),
+		"-map", "0:v:0", "-map", aMap, "-shortest",
+		"-c:v", "libx264", "-preset", "ultrafast", "-video_track_timescale", "15360",
+		"-c:a", "aac", "-b:a", "128k", dest)
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("normalize %s: %v | Log: %s", filepath.Base(src), err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- NOTIFICATIONS ---
+// Callers identified by an X-Api-Key header can save where job outcomes
+// should be announced: PUT /notifications with
+//
+//	{"slack_webhook": "https://hooks.slack.com/...", "discord_webhook": "https://discord.com/api/webhooks/...",
+//	 "email": "team@example.com", "on": "completed,failed"}
+//
+// Every later request sent with the same key posts a short message with the
+// video link (or the error) to each target when its job ends. Slack hooks
+// must be on hooks.slack.com and Discord hooks under
+// discord.com/api/webhooks/; both are posted to public addresses only (see
+// publicClient). Email goes through the server's SMTP_HOST, SMTP_PORT
+// (587), SMTP_USER, SMTP_PASS and SMTP_FROM. Settings are stored under a
+// hash of the key, never the key.
+type NotifySettings struct {
+	SlackWebhook   string `json:"slack_webhook,omitempty"`
+	DiscordWebhook string `json:"discord_webhook,omitempty"`
+	Email          string `json:"email,omitempty"`
+	On             string `json:"on,omitempty"` // comma separated: completed, failed; empty: both
+}
+
+func notifyDir() string {
+	if dir := os.Getenv("NOTIFY_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "notifications")
+}
+
+func notifyFile(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return filepath.Join(notifyDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+func loadNotifySettings(apiKey string) (NotifySettings, bool) {
+	var s NotifySettings
+	if apiKey == "" {
+		return s, false
+	}
+	data, err := os.ReadFile(notifyFile(apiKey))
+	if err != nil || json.Unmarshal(data, &s) != nil {
+		return s, false
+	}
+	return s, true
+}
+
+// checkChatWebhook accepts an https URL on host under path prefix, e.g. a
+// Slack or Discord incoming webhook, that resolves to public addresses.
+func checkChatWebhook(raw, host, prefix string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host != host || !strings.HasPrefix(u.Path, prefix) {
+		return fmt.Errorf("must be an https://%s%s... URL", host, prefix)
+	}
+	return checkPublicURL(raw)
+}
+
+// PUT /notifications
+func handleSetNotifications(c *gin.Context) {
+	key := c.GetHeader("X-Api-Key")
+	if key == "" {
+		c.JSON(401, gin.H{"error": "X-Api-Key header is required"})
+		return
+	}
+	var s NotifySettings
+	if err := c.ShouldBindJSON(&s); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	if s.SlackWebhook != "" {
+		if err := checkChatWebhook(s.SlackWebhook, "hooks.slack.com", "/services/"); err != nil {
+			c.JSON(400, gin.H{"error": "slack_webhook: " + err.Error()})
+			return
+		}
+	}
+	if s.DiscordWebhook != "" {
+		if err := checkChatWebhook(s.DiscordWebhook, "discord.com", "/api/webhooks/"); err != nil {
+			c.JSON(400, gin.H{"error": "discord_webhook: " + err.Error()})
+			return
+		}
+	}
+	if s.Email != "" {
+		addr, err := mail.ParseAddress(s.Email)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid email"})
+			return
+		}
+		s.Email = addr.Address
+	}
+
+	os.MkdirAll(notifyDir(), 0700)
+	data, _ := json.MarshalIndent(s, "", "  ")
+	if err := os.WriteFile(notifyFile(key), data, 0600); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save notification settings"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "notifications": s})
+}
+
+// GET /notifications
+func handleGetNotifications(c *gin.Context) {
+	s, ok := loadNotifySettings(c.GetHeader("X-Api-Key"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "no notification settings for this key"})
+		return
+	}
+	c.JSON(200, gin.H{"notifications": s})
+}
+
+// notifyJobDone announces a finished job to the caller's targets in the
+// background. outcome is "completed" or "failed".
+func notifyJobDone(apiKey, jobID, topic, outcome string, result map[string]any) {
+	s, ok := loadNotifySettings(apiKey)
+	if !ok || (s.On != "" && !strings.Contains(s.On, outcome)) {
+		return
+	}
+	subject := fmt.Sprintf("✅ Video ready: %s", topic)
+	text := fmt.Sprintf("%s\n%v", subject, result["video_url"])
+	if outcome == "failed" {
+		subject = fmt.Sprintf("❌ Video failed: %s", topic)
+		text = fmt.Sprintf("%s\n%v", subject, result["error"])
+	}
+	text += "\nJob " + jobID
+
+	go func() {
+		// Checked when saved; publicClient keeps the targets public.
+		client := &http.Client{Timeout: 10 * time.Second, Transport: publicClient.Transport, CheckRedirect: publicClient.CheckRedirect}
+		if s.SlackWebhook != "" {
+			if err := postChat(client, s.SlackWebhook, gin.H{"text": text}); err != nil {
+				fmt.Printf("⚠️ Slack notification failed: %v\n", err)
+			}
+		}
+		if s.DiscordWebhook != "" {
+			if err := postChat(client, s.DiscordWebhook, gin.H{"content": text}); err != nil {
+				fmt.Printf("⚠️ Discord notification failed: %v\n", err)
+			}
+		}
+		if s.Email != "" {
+			if err := sendEmail(s.Email, subject, text); err != nil {
+				fmt.Printf("⚠️ Email notification failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+func postChat(client *http.Client, hook string, payload gin.H) error {
+	body, _ := json.Marshal(payload)
+	resp, err := client.Post(hook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendEmail(to, subject, text string) error {
+	host := os.Getenv("SMTP_HOST")
+	from := os.Getenv("SMTP_FROM")
+	if host == "" || from == "" {
+		return fmt.Errorf("missing SMTP_HOST or SMTP_FROM")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USER"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASS"), host)
+	}
+	msg := "From: " + from + "\r\nTo: " + to + "\r\nSubject: " + mime.QEncoding.Encode("UTF-8", subject) +
+		"\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n" + strings.ReplaceAll(text, "\n", "\r\n") + "\r\n"
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(msg))
+}
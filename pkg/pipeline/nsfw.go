@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// --- NSFW FILTER ---
+// Fetched images (TMDB, stock, Unsplash, article and headline images) and
+// AI-generated ones are classified before a scene may use them.
+// NSFW_API_URL posts the image as multipart "file" to a classifier (a local
+// model server, say) and expects {"nsfw": bool, "score": 0-1, "label":
+// "..."}, rejecting it when nsfw is set or the score reaches NSFW_THRESHOLD
+// percent (80); NSFW_API_KEY is sent as a bearer token. NSFW_OPENAI_KEY
+// uses OpenAI's omni-moderation model instead and rejects images flagged
+// sexual or graphically violent. A flagged image, or one the classifier
+// couldn't judge, is quarantined and the scene moves on to the next media
+// source. With no classifier images pass, unless NSFW_FILTER=required,
+// which turns auto-sourced imagery off until one is configured.
+var nsfwCategories = []string{"sexual", "sexual/minors", "violence/graphic"}
+
+func checkImageSafe(path string) error {
+	var err error
+	switch {
+	case os.Getenv("NSFW_API_URL") != "":
+		err = apiNSFW(os.Getenv("NSFW_API_URL"), path)
+	case os.Getenv("NSFW_OPENAI_KEY") != "":
+		err = openaiNSFW(os.Getenv("NSFW_OPENAI_KEY"), path)
+	case os.Getenv("NSFW_FILTER") == "required":
+		return fmt.Errorf("no NSFW classifier configured")
+	default:
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("🔞 Rejected image: %v\n", err)
+		quarantine(path)
+	}
+	return err
+}
+
+// screened runs fetch and then the NSFW filter.
+func screened(fetch func(dest string) error) func(dest string) error {
+	return func(dest string) error {
+		if err := fetch(dest); err != nil {
+			return err
+		}
+		return checkImageSafe(dest)
+	}
+}
+
+func apiNSFW(apiURL, path string) error {
+	var res struct {
+		NSFW  bool    `json:"nsfw"`
+		Score float64 `json:"score"`
+		Label string  `json:"label"`
+	}
+	auth := func(req *http.Request) {
+		if key := os.Getenv("NSFW_API_KEY"); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+	if err := postMultipartFile(apiURL, "file", path, auth, &res); err != nil {
+		return fmt.Errorf("NSFW classifier: %v", err)
+	}
+	if res.NSFW || res.Score*100 >= float64(envInt("NSFW_THRESHOLD", 80)) {
+		return fmt.Errorf("NSFW classifier flagged image: %s (%.2f)", res.Label, res.Score)
+	}
+	return nil
+}
+
+func openaiNSFW(apiKey, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	image := "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+	body, _ := json.Marshal(map[string]any{
+		"model": "omni-moderation-latest",
+		"input": []map[string]any{{"type": "image_url", "image_url": map[string]string{"url": image}}},
+	})
+	req, _ := http.NewRequest("POST", "https://api.openai.com/v1/moderations", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	var res struct {
+		Results []struct {
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := sendJSON(req, &res); err != nil {
+		return fmt.Errorf("moderation API: %v", err)
+	}
+	if len(res.Results) == 0 {
+		return fmt.Errorf("moderation API returned no result")
+	}
+	var flagged []string
+	for _, category := range nsfwCategories {
+		if res.Results[0].Categories[category] {
+			flagged = append(flagged, category)
+		}
+	}
+	if len(flagged) > 0 {
+		return fmt.Errorf("moderation API flagged image: %s", strings.Join(flagged, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- LOCAL MODELS ---
+// LLM_PROVIDER=ollama sends every LLM call to a self-hosted model instead
+// of Groq: Ollama's OpenAI-compatible API at OLLAMA_BASE_URL (default
+// http://localhost:11434/v1), or a llama.cpp server at its /v1, running
+// OLLAMA_MODEL (default llama3.1:8b). Small local models are looser about
+// JSON mode, so they are told up front to answer with one JSON object, the
+// object is cut out of any prose around it, and an answer that still won't
+// parse is sent back once with the parse error. Podcast transcription goes
+// to WHISPER_BASE_URL (an OpenAI-compatible whisper server, WHISPER_MODEL)
+// when set, so nothing needs an external AI API.
+const (
+	maxLocalAttempts = 2
+	localJSONPrompt  = "You are a JSON API. Answer with exactly one JSON object in the shape the user asks for. " +
+		"No prose before or after it, no markdown fences, no comments, no trailing commas."
+)
+
+// defaultChatClient is the client for LLM_PROVIDER: Groq unless ollama.
+func defaultChatClient() (*openai.Client, chatModel, error) {
+	switch p := strings.ToLower(os.Getenv("LLM_PROVIDER")); p {
+	case "", "groq":
+		client, err := newGroqClient()
+		return client, chatModel{Name: scriptModel}, err
+	case "ollama":
+		m := chatModel{Name: os.Getenv("OLLAMA_MODEL"), BaseURL: os.Getenv("OLLAMA_BASE_URL"), Key: "ollama", Local: true}
+		if m.Name == "" {
+			m.Name = "llama3.1:8b"
+		}
+		if m.BaseURL == "" {
+			m.BaseURL = "http://localhost:11434/v1"
+		}
+		config := openai.DefaultConfig(m.Key)
+		config.BaseURL = m.BaseURL
+		return openai.NewClientWithConfig(config), m, nil
+	default:
+		return nil, chatModel{Name: p}, fmt.Errorf("unknown LLM_PROVIDER %q (use groq or ollama)", p)
+	}
+}
+
+// messages adds the local JSON instructions ahead of msgs.
+func (m chatModel) messages(msgs []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	if !m.Local {
+		return msgs
+	}
+	return append([]openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: localJSONPrompt}}, msgs...)
+}
+
+// jsonObject cuts the outermost {...} out of text, or returns it as is.
+func jsonObject(text string) string {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+// localRepair is the follow-up turn for an answer that didn't parse.
+func localRepair(answer string, err error) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleAssistant, Content: answer},
+		{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("That is not valid JSON (%v). Answer again with only the corrected JSON object.", err)},
+	}
+}
+
+// transcriptionClient is a local whisper server when configured, else Groq.
+func transcriptionClient() (*openai.Client, string, error) {
+	if base := os.Getenv("WHISPER_BASE_URL"); base != "" {
+		config := openai.DefaultConfig(os.Getenv("WHISPER_API_KEY"))
+		config.BaseURL = base
+		model := os.Getenv("WHISPER_MODEL")
+		if model == "" {
+			model = "whisper-1"
+		}
+		return openai.NewClientWithConfig(config), model, nil
+	}
+	client, err := newGroqClient()
+	return client, "whisper-large-v3", err
+}
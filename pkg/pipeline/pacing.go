@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// --- PACING ---
+// The prompt asks for a word range per item but the model overshoots often
+// enough that the maximum is enforced after generation: long narration is
+// split into beats, consecutive segments over the same media that each
+// narrate one chunk. With captions on, every beat is further broken into
+// short caption chunks so each screen stays readable on a phone.
+
+// captionWords is the most words shown on screen at once.
+func captionWords(videoType string) int {
+	if videoType == "long" {
+		return 10
+	}
+	return 6
+}
+
+// splitBeats breaks narration into beats of at most maxWords words, at
+// sentence ends where possible. A sentence longer than maxWords is cut into
+// even parts.
+func splitBeats(text string, maxWords int) []string {
+	var beats, current []string
+	flush := func() {
+		if len(current) > 0 {
+			beats = append(beats, strings.Join(current, " "))
+			current = nil
+		}
+	}
+	for _, sentence := range sentences(text) {
+		words := strings.Fields(sentence)
+		if len(current)+len(words) > maxWords {
+			flush()
+		}
+		if len(words) <= maxWords {
+			current = append(current, words...)
+			continue
+		}
+		parts := evenChunks(words, maxWords)
+		beats = append(beats, parts[:len(parts)-1]...)
+		current = strings.Fields(parts[len(parts)-1])
+	}
+	flush()
+	if len(beats) == 0 {
+		return []string{text}
+	}
+	return beats
+}
+
+// evenChunks cuts words into the fewest chunks of at most limit words, sized
+// evenly so no chunk is left with a word or two.
+func evenChunks(words []string, limit int) []string {
+	parts := (len(words) + limit - 1) / limit
+	size := (len(words) + parts - 1) / parts
+	var chunks []string
+	for len(words) > size {
+		chunks = append(chunks, strings.Join(words[:size], " "))
+		words = words[size:]
+	}
+	return append(chunks, strings.Join(words, " "))
+}
+
+// sentences splits text after sentence-ending punctuation.
+func sentences(text string) []string {
+	var out []string
+	var b strings.Builder
+	for _, w := range strings.Fields(text) {
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(w)
+		if last, _ := utf8.DecodeLastRuneInString(w); strings.ContainsRune(".!?。！？", last) {
+			out = append(out, b.String())
+			b.Reset()
+		}
+	}
+	if b.Len() > 0 {
+		out = append(out, b.String())
+	}
+	return out
+}
+
+// captionCues spreads the narration over duration seconds in chunks of at
+// most maxWords words, timed by each chunk's share of the characters.
+func captionCues(text string, duration float64, maxWords int) []Cue {
+	var chunks []string
+	for _, s := range sentences(text) {
+		chunks = append(chunks, evenChunks(strings.Fields(s), maxWords)...)
+	}
+	total := 0
+	for _, ch := range chunks {
+		total += utf8.RuneCountInString(ch)
+	}
+	var cues []Cue
+	t := 0.0
+	for _, ch := range chunks {
+		d := duration * float64(utf8.RuneCountInString(ch)) / float64(total)
+		cues = append(cues, Cue{Start: t, End: t + d, Text: ch})
+		t += d
+	}
+	return cues
+}
@@ -0,0 +1,236 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PIPELINE API ---
+// Pipeline.Generate runs a generation mode in-process for callers that
+// don't want HTTP: the CLI, quick videos, job retries and other services.
+// The Spec is read through the same Form the HTTP handlers read, so it goes
+// through the same generator (script, media, render, stitch, finish) and
+// the job is completed as jobEvents completes it; the response is returned
+// instead of written.
+type Pipeline struct {
+	BaseURL string // scheme://host used in returned URLs, default http://localhost
+	APIKey  string // the caller's X-Api-Key: their pronunciations, notifications and assets
+
+	// Progress, when set, is called as the job enters each stage and for
+	// each lifecycle event, whether or not the request has a webhook. It
+	// runs on the render's goroutines, which wait for it to return.
+	Progress func(Progress)
+}
+
+// Progress is a stage change (Stage set) or a lifecycle event (Event set,
+// with the data its webhook would carry) of job JobID.
+type Progress struct {
+	JobID string
+	Stage string
+	Event string
+	Data  any
+}
+
+// SceneSpec is a scene plus local files for its media and green-screen clip.
+type SceneSpec struct {
+	SceneData
+	Media       string `json:"media,omitempty"`
+	Greenscreen string `json:"greenscreen,omitempty"`
+}
+
+// Spec is one generation request. Kind picks the mode: "" (multi-scene)
+// renders Scenes; podcast, deck, article, news, sports, market, weather,
+// reddit and quote take the fields and uploads of their HTTP form through
+// Options and Files.
+type Spec struct {
+	Kind     string
+	Topic    string
+	Category string
+	Type     string // short or long
+	Scenes   []SceneSpec
+	Intro    string            // intro media file
+	Outro    string            // outro media file
+	Options  map[string]string // any other form field, e.g. look, captions, music_id
+	Files    map[string]string // any other upload as a local file, e.g. audio, deck
+}
+
+type Result struct {
+	Status       string       `json:"status"` // success, or hooks_ready (see hooks.go)
+	JobID        string       `json:"job_id"`
+	VideoPath    string       `json:"-"`
+	VideoURL     string       `json:"video_url"`
+	NarrationURL string       `json:"narration_url"`
+	TeaserURL    string       `json:"teaser_url"`
+	Stitch       string       `json:"stitch"`
+	Output       MediaInfo    `json:"output"`
+	Segments     []MediaInfo  `json:"segments"`
+	Chapters     []Chapter    `json:"chapters"`
+	ChaptersText string       `json:"chapters_text"`
+	Timings      TimingReport `json:"timings"`
+}
+
+// Error is a request the generator refused or failed, with the HTTP status
+// its route would have answered.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("render failed (%d): %s", e.Code, e.Message)
+}
+
+// generators are the generation modes by route; Spec.Kind is the route
+// without "/generate-" and "-video".
+var generators = map[string]func(r *request){
+	"/generate-multi-scene":   handleMultiSceneVideo,
+	"/generate-podcast-video": handlePodcastVideo,
+	"/generate-deck-video":    handleDeckVideo,
+	"/generate-article-video": handleArticleVideo,
+	"/generate-news-video":    handleNewsVideo,
+	"/generate-sports-video":  handleSportsVideo,
+	"/generate-market-video":  handleMarketVideo,
+	"/generate-weather-video": handleWeatherVideo,
+	"/generate-reddit-video":  handleRedditVideo,
+	"/generate-quote-video":   handleQuoteVideo,
+}
+
+func kindRoute(kind string) string {
+	if kind == "" || kind == "multi-scene" {
+		return "/generate-multi-scene"
+	}
+	return "/generate-" + kind + "-video"
+}
+
+// pipelineBlocklist is the router's BLOCKLIST, loaded once for in-process requests.
+var pipelineBlocklist = sync.OnceValue(blocklistFromEnv)
+
+// Generate renders spec and returns the finished job. The video is left in
+// the job workspace at Result.VideoPath. ctx is checked before the render
+// starts; a render in progress runs to completion.
+func (p *Pipeline) Generate(ctx context.Context, spec Spec) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	r, err := p.request(spec)
+	if err != nil {
+		return Result{}, err
+	}
+	return generate(r)
+}
+
+// request turns spec into the form its route takes.
+func (p *Pipeline) request(spec Spec) (*request, error) {
+	route := kindRoute(spec.Kind)
+	if generators[route] == nil {
+		return nil, fmt.Errorf("unknown kind %q", spec.Kind)
+	}
+	fields := url.Values{}
+	paths := map[string]string{"media_intro": spec.Intro, "media_outro": spec.Outro}
+	if route == "/generate-multi-scene" {
+		scenes := make([]SceneData, len(spec.Scenes))
+		for i, s := range spec.Scenes {
+			scenes[i] = s.SceneData
+			paths[fmt.Sprintf("media_%d", i)] = s.Media
+			paths[fmt.Sprintf("greenscreen_%d", i)] = s.Greenscreen
+		}
+		scenesJSON, _ := json.Marshal(scenes)
+		fields.Set("scenes", string(scenesJSON))
+	}
+	for k, v := range map[string]string{"topic": spec.Topic, "category": spec.Category, "type": spec.Type} {
+		if v != "" {
+			fields.Set(k, v)
+		}
+	}
+	for k, v := range spec.Options {
+		fields.Set(k, v)
+	}
+	fields.Del("preview") // Generate waits for the full render anyway
+	maps.Copy(paths, spec.Files)
+
+	files := map[string]*Upload{}
+	for field, file := range paths {
+		if file == "" {
+			continue
+		}
+		u, err := fileUpload(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", field, err)
+		}
+		files[field] = u
+	}
+	header := http.Header{}
+	if p.APIKey != "" {
+		header.Set("X-Api-Key", p.APIKey)
+	}
+	base := p.BaseURL
+	if base == "" {
+		base = "http://localhost"
+	}
+	r := newSpecRequest(route, base, specForm{fields: fields, files: files, header: header})
+	r.progress = p.Progress
+	return r, nil
+}
+
+func newSpecRequest(route, base string, form specForm) *request {
+	return &request{Form: form, path: route, base: base, blocklist: pipelineBlocklist()}
+}
+
+// generate runs r and reads its answer as a Result.
+func generate(r *request) (Result, error) {
+	var result Result
+	run(r)
+	if r.code != 200 {
+		msg, _ := r.body["error"].(string)
+		return result, &Error{Code: r.code, Message: msg}
+	}
+	data, _ := json.Marshal(r.body)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, err
+	}
+	if result.VideoURL != "" {
+		result.VideoPath = r.job.Path(path.Base(result.VideoURL))
+	}
+	return result, nil
+}
+
+// run answers r with the generator for its route, applying the brand kit
+// and content policy first as the router's middleware does, then completes
+// its job as jobEvents does.
+func run(r *request) {
+	start := time.Now()
+	generator := generators[r.path]
+	if generator == nil {
+		r.JSON(404, gin.H{"error": "no generator for " + r.path})
+		return
+	}
+	fields, _ := r.values()
+	if err := fillBrandKit(r.PostForm("brand_kit"), fields); err != nil {
+		r.JSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkPolicyFields(r) {
+		return
+	}
+	generator(r)
+	if r.job == nil {
+		return
+	}
+	var resp map[string]any
+	data, _ := json.Marshal(r.body)
+	json.Unmarshal(data, &resp)
+	timings := r.job.completeResponse(resp, r.code, time.Since(start))
+	r.body = resp
+	resp = maps.Clone(resp)
+	delete(resp, "debug")
+	r.job.complete(r, r.code, resp, timings)
+}
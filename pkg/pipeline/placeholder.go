@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// --- PLACEHOLDER CARDS ---
+// Rendered locally when a scene has no media: a solid or gradient background
+// from the brand colors, the scene title and, for list items, its number.
+var cardStyles = map[string]bool{"solid": true, "gradient": true, "diagonal": true}
+
+type CardStyle struct {
+	Style     string // see cardStyles
+	From, To  color.RGBA
+	TextColor string // ffmpeg color, e.g. white or 0xRRGGBB
+}
+
+var defaultCard = CardStyle{
+	Style:     "gradient",
+	From:      color.RGBA{0x1f, 0x1c, 0x2c, 0xff},
+	To:        color.RGBA{0x4a, 0x47, 0x6b, 0xff},
+	TextColor: "white",
+}
+
+// cardStyleFromForm reads placeholder_style, brand_color, brand_color_2 and
+// placeholder_text_color (hex colors).
+func cardStyleFromForm(f Form) (CardStyle, error) {
+	card := defaultCard
+	if s := strings.ToLower(strings.TrimSpace(f.PostForm("placeholder_style"))); s != "" {
+		if !cardStyles[s] {
+			return card, fmt.Errorf("unknown placeholder_style %q", s)
+		}
+		card.Style = s
+	}
+	if v := f.PostForm("brand_color"); v != "" {
+		col, err := parseHexColor(v)
+		if err != nil {
+			return card, fmt.Errorf("brand_color: %v", err)
+		}
+		card.From, card.To = col, darken(col, 0.45)
+	}
+	if v := f.PostForm("brand_color_2"); v != "" {
+		col, err := parseHexColor(v)
+		if err != nil {
+			return card, fmt.Errorf("brand_color_2: %v", err)
+		}
+		card.To = col
+	}
+	if v := f.PostForm("placeholder_text_color"); v != "" {
+		col, err := parseHexColor(v)
+		if err != nil {
+			return card, fmt.Errorf("placeholder_text_color: %v", err)
+		}
+		card.TextColor = fmt.Sprintf("0x%02x%02x%02x", col.R, col.G, col.B)
+	}
+	return card, nil
+}
+
+func parseHexColor(v string) (color.RGBA, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "#")
+	if len(v) == 3 {
+		v = string([]byte{v[0], v[0], v[1], v[1], v[2], v[2]})
+	}
+	n, err := strconv.ParseUint(v, 16, 32)
+	if len(v) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q", v)
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}, nil
+}
+
+func darken(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{uint8(float64(c.R) * f), uint8(float64(c.G) * f), uint8(float64(c.B) * f), 0xff}
+}
+
+// renderPlaceholder draws the card background in Go and lays the text on top
+// with ffmpeg drawtext. index > 0 adds a large item number above the title.
+// If drawtext is unavailable the plain background is still written.
+func renderPlaceholder(text string, index int, dest, videoType string, card CardStyle) error {
+	w, h := frameSize(videoType)
+	bg := cardBackground(w, h, card)
+
+	bgPath := dest + ".bg.png"
+	if err := writePNG(bgPath, bg); err != nil {
+		return err
+	}
+	defer os.Remove(bgPath)
+
+	lineLen, size := 16, w/13
+	if videoType == "long" {
+		lineLen, size = 28, h/11
+	}
+	textPath := dest + ".txt"
+	if err := os.WriteFile(textPath, []byte(wrapWords(text, lineLen)), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(textPath)
+
+	font := escapeFilterArg(fontForText(text))
+	filter := fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s:line_spacing=%d:x=(w-text_w)/2:y=(h-text_h)/2:shadowcolor=black@0.4:shadowx=3:shadowy=3",
+		font, escapeFilterArg(textPath), size, card.TextColor, size/4)
+	if index > 0 {
+		filter = fmt.Sprintf("drawtext=font='%s':text='%d':fontsize=%d:fontcolor=%s@0.35:x=(w-text_w)/2:y=h*0.12,",
+			escapeFilterArg(captionFont), index, size*3, card.TextColor) + filter
+	}
+	if output, err := ffmpegCommand("-y", "-i", bgPath, "-vf", filter, "-frames:v", "1", "-q:v", "2", dest).CombinedOutput(); err != nil {
+		fmt.Printf("⚠️ Placeholder text failed, using plain card: %v | Log: %s\n", err, string(output))
+		out, err := os.Create(dest)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		return jpeg.Encode(out, bg, &jpeg.Options{Quality: 90})
+	}
+	return nil
+}
+
+// placeholderCard renders (or reuses) the job's card for text at index.
+func placeholderCard(job *Job, text string, index int, videoType string, card CardStyle) string {
+	path, _ := job.Fetch(fmt.Sprintf("card:%d:%s", index, text), ".jpg", func(dest string) error {
+		return renderPlaceholder(text, index, dest, videoType, card)
+	})
+	return path
+}
+
+func cardBackground(w, h int, card CardStyle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := 0.0
+			switch card.Style {
+			case "gradient":
+				t = float64(y) / float64(h-1)
+			case "diagonal":
+				t = (float64(x)/float64(w-1) + float64(y)/float64(h-1)) / 2
+			}
+			img.SetRGBA(x, y, lerpColor(card.From, card.To, t))
+		}
+	}
+	return img
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
+
+// wrapWords breaks text into lines of at most limit characters.
+func wrapWords(text string, limit int) string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range strings.Fields(text) {
+		if line.Len() > 0 && len([]rune(line.String()))+1+len([]rune(word)) > limit {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteString(" ")
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,299 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- PODCAST MODE ---
+// The uploaded audio is the narration: it is transcribed, split into
+// chapters, and each chapter is rendered over its own image with captions.
+type TranscriptSegment struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+type PodcastChapter struct {
+	Title      string  `json:"title"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	ImageQuery string  `json:"image_query"`
+}
+
+func handlePodcastVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Podcast Received")
+
+	topic := r.PostForm("topic")
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "long"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sources, err := mediaSourcesFromForm(r, queryMediaSources)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := r.FormFile("audio")
+	if err != nil {
+		r.JSON(400, gin.H{"error": "Missing audio file"})
+		return
+	}
+	sourcePath := job.MediaPath(safeExt(file.Filename, ".mp3"))
+	if err := saveUpload(file, sourcePath); err != nil {
+		r.JSON(uploadErrorStatus(err), gin.H{"error": "Could not save audio: " + err.Error()})
+		return
+	}
+	defer os.Remove(sourcePath)
+
+	fmt.Println("🔹 STEP 2: Transcribing (Groq Whisper)...")
+	job.Stage("transcribe")
+	total, err := probeDuration(sourcePath)
+	if err != nil {
+		r.JSON(422, gin.H{"error": "Unreadable audio file"})
+		return
+	}
+	transcript, err := transcribeAudio(sourcePath, total)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Whisper): %v\n", err)
+		r.JSON(500, gin.H{"error": "Transcription failed: " + err.Error()})
+		return
+	}
+	if len(transcript) == 0 {
+		r.JSON(422, gin.H{"error": "No speech detected in audio"})
+		return
+	}
+
+	fmt.Println("🔹 STEP 3: Detecting Chapters...")
+	job.Stage("chapters")
+	chapters, err := generatePodcastChapters(job, topic, transcript, total)
+	if err != nil {
+		fmt.Printf("⚠️ Chapter detection failed, using fixed chapters: %v\n", err)
+		chapters = fixedChapters(total, 60)
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Chapters: %d\n", topic, videoType, len(chapters))
+	job.Emit("job.script_ready", gin.H{"chapters": chapters})
+
+	fmt.Println("🔹 STEP 4: Rendering Chapters...")
+	job.Stage("render")
+	var segments []Segment
+	for i, ch := range chapters {
+		audioPath := job.Path(fmt.Sprintf("seg_%d.mp3", i))
+		assPath := job.Path(fmt.Sprintf("seg_%d.ass", i))
+		segPath := job.Path(fmt.Sprintf("seg_%d.mp4", i))
+
+		mediaPath, err := resolveMedia(r, job, sources, MediaRequest{Query: ch.ImageQuery, Text: ch.Title, Index: i + 1}, videoType, base.Card)
+		if err != nil {
+			r.JSON(422, gin.H{"error": err.Error()})
+			return
+		}
+		if err := cutAudio(sourcePath, audioPath, ch.Start, ch.End); err != nil {
+			fmt.Printf("⚠️ Skipping chapter %d: %v\n", i, err)
+			continue
+		}
+		if err := writeASS(chapterCues(transcript, ch), assPath, base); err != nil {
+			assPath = ""
+		}
+
+		opts := base
+		opts.SubtitlePath = assPath
+		err = renderSegmentWithAudio(audioPath, mediaPath, segPath, opts)
+		os.Remove(audioPath)
+		os.Remove(assPath)
+		if err == nil {
+			d, _ := probeDuration(segPath)
+			segments = append(segments, Segment{Path: segPath, Title: ch.Title, Duration: d})
+			job.segmentRendered(len(segments)-1, segments[len(segments)-1])
+		}
+	}
+
+	segments = withSting(segments, base)
+
+	fmt.Println("🔹 STEP 5: Stitching Video...")
+	job.Stage("stitch")
+	finalVideo := job.Path("final_podcast.mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	offerStream(r, job, finalVideo)
+	finish := finishOptionsFromForm(r, job, videoType)
+	finish.Title, finish.Chapters = topic, segmentChapters(segments)
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Podcast Video Ready.")
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": job.URL(r.base, finalVideo), "stitch": stitch,
+		"narration_url": narrationURL(r.base, job, finish), "thumbnails": thumbnailURLs(r.base, job, finish),
+		"teaser_url": teaserURL(r.base, job, finish), "size_budget": finish.SizeBudget, "output": output, "segments": segmentInfo, "chapters": chapters,
+		"chapters_text": youtubeChapters(finish.Chapters)})
+}
+
+// Whisper APIs take about 25 MB per request, under an hour of typical
+// podcast MP3. The audio is sent as 10-minute chunks of 16 kHz mono at
+// 48 kbps (about 3.6 MB each), which is all speech recognition needs.
+const transcribeChunkSecs = 600
+
+// transcribeAudio transcribes the total seconds of audio at path chunk by
+// chunk, shifting each chunk's segments onto the file's timeline.
+func transcribeAudio(path string, total float64) ([]TranscriptSegment, error) {
+	client, model, err := transcriptionClient()
+	if err != nil {
+		return nil, err
+	}
+	var segs []TranscriptSegment
+	for offset := 0.0; offset < total; offset += transcribeChunkSecs {
+		chunk := strings.TrimSuffix(path, filepath.Ext(path)) + fmt.Sprintf("_chunk%d.mp3", int(offset))
+		output, err := ffmpegCommand("-y", "-ss", fmt.Sprintf("%.3f", offset), "-t", strconv.Itoa(transcribeChunkSecs), "-i", path,
+			"-vn", "-ac", "1", "-ar", "16000", "-c:a", "libmp3lame", "-b:a", "48k", chunk).CombinedOutput()
+		if err != nil {
+			os.Remove(chunk)
+			return nil, fmt.Errorf("audio downmix failed: %v | Log: %s", err, string(output))
+		}
+		resp, err := client.CreateTranscription(context.Background(), openai.AudioRequest{
+			Model:    model,
+			FilePath: chunk,
+			Format:   openai.AudioResponseFormatVerboseJSON,
+		})
+		os.Remove(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("chunk at %.0fs: %v", offset, err)
+		}
+		for _, s := range resp.Segments {
+			if strings.TrimSpace(s.Text) == "" {
+				continue
+			}
+			segs = append(segs, TranscriptSegment{Start: s.Start + offset, End: s.End + offset, Text: strings.TrimSpace(s.Text)})
+		}
+	}
+	return segs, nil
+}
+
+// generatePodcastChapters splits the total seconds of audio into chapters.
+func generatePodcastChapters(job *Job, topic string, transcript []TranscriptSegment, total float64) ([]PodcastChapter, error) {
+	var lines strings.Builder
+	for _, s := range transcript {
+		fmt.Fprintf(&lines, "[%.1f] %s\n", s.Start, s.Text)
+	}
+
+	prompt := fmt.Sprintf(`
+    Podcast topic: "%s"
+    Total length: %.1f seconds.
+    Split this timestamped transcript into 3 to 12 chapters at natural topic changes.
+    Each chapter needs a short title and a concrete stock-photo search query describing a fitting image.
+    TRANSCRIPT:
+    %s
+    RETURN JSON ONLY:
+    {
+        "chapters": [
+            { "title": "Title", "start": 0.0, "end": 42.5, "image_query": "microphone in studio" }
+        ]
+    }
+    `, topic, total, lines.String())
+
+	var result struct {
+		Chapters []PodcastChapter `json:"chapters"`
+	}
+	if err := completeJSON(job, "podcast_chapters", prompt, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Chapters) == 0 {
+		return nil, fmt.Errorf("no chapters returned")
+	}
+	return normalizeChapters(result.Chapters, total), nil
+}
+
+// normalizeChapters makes chapters contiguous so no audio is dropped or repeated.
+func normalizeChapters(chapters []PodcastChapter, total float64) []PodcastChapter {
+	sort.Slice(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	chapters[0].Start = 0
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = total
+		}
+	}
+	var out []PodcastChapter
+	for _, ch := range chapters {
+		if ch.End-ch.Start < 1 {
+			if len(out) > 0 {
+				out[len(out)-1].End = ch.End
+			}
+			continue
+		}
+		if len(out) == 0 {
+			ch.Start = 0 // takes over a short opening chapter
+		}
+		out = append(out, ch)
+	}
+	if len(out) == 0 {
+		out = []PodcastChapter{{Title: chapters[0].Title, End: total, ImageQuery: chapters[0].ImageQuery}}
+	}
+	return out
+}
+
+func fixedChapters(total, length float64) []PodcastChapter {
+	var chapters []PodcastChapter
+	for start := 0.0; start < total; start += length {
+		end := start + length
+		if end > total {
+			end = total
+		}
+		chapters = append(chapters, PodcastChapter{Title: fmt.Sprintf("Part %d", len(chapters)+1), Start: start, End: end})
+	}
+	return chapters
+}
+
+// chapterCues returns the transcript lines inside a chapter, relative to its start.
+func chapterCues(transcript []TranscriptSegment, ch PodcastChapter) []Cue {
+	var cues []Cue
+	for _, s := range transcript {
+		if s.End <= ch.Start || s.Start >= ch.End {
+			continue
+		}
+		start := s.Start - ch.Start
+		end := s.End - ch.Start
+		if end > ch.End-ch.Start {
+			end = ch.End - ch.Start
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: s.Text})
+	}
+	return cues
+}
+
+func cutAudio(src, dest string, start, end float64) error {
+	cmd := ffmpegCommand("-y", "-i", src,
+		"-ss", fmt.Sprintf("%.3f", start), "-to", fmt.Sprintf("%.3f", end),
+		"-vn", "-c:a", "libmp3lame", "-b:a", "128k", dest)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("audio cut failed: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- CONTENT POLICY ---
+// Operators can refuse whole topics (politics, adult content, trademarks)
+// before any generation work starts. BLOCKLIST holds comma separated terms,
+// BLOCKLIST_FILE one term per line (# starts a comment). Terms match case
+// insensitively as whole words or phrases in the request's text fields;
+// fetched article text and slide text are checked by their handlers once
+// extracted. A match answers 403 with code "policy_blocked".
+var policyFields = []string{"topic", "category", "scenes", "markdown", "hook", "brand_name", "quotes", "theme"}
+
+const blocklistKey = "blocklist"
+
+type blockedTerm struct {
+	term string
+	re   *regexp.Regexp
+}
+
+type Blocklist []blockedTerm
+
+func blocklistFromEnv() Blocklist {
+	terms := strings.Split(os.Getenv("BLOCKLIST"), ",")
+	if path := os.Getenv("BLOCKLIST_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("⚠️ BLOCKLIST_FILE not loaded: %v\n", err)
+		} else {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				line, _, _ := strings.Cut(scanner.Text(), "#")
+				terms = append(terms, line)
+			}
+			f.Close()
+		}
+	}
+
+	var bl Blocklist
+	for _, term := range terms {
+		term = strings.Join(strings.Fields(term), " ")
+		if term == "" {
+			continue
+		}
+		// Word boundaries only where the term itself starts or ends with a
+		// word character, so "c++" or "#tag" still match.
+		pattern := regexp.QuoteMeta(term)
+		pattern = strings.ReplaceAll(pattern, " ", `\s+`)
+		if isWordByte(term[0]) {
+			pattern = `\b` + pattern
+		}
+		if isWordByte(term[len(term)-1]) {
+			pattern += `\b`
+		}
+		bl = append(bl, blockedTerm{term: term, re: regexp.MustCompile("(?i)" + pattern)})
+	}
+	if len(bl) > 0 {
+		fmt.Printf("🛡️ Blocklist: %d terms\n", len(bl))
+	}
+	return bl
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// match returns the first blocked term found in text.
+func (bl Blocklist) match(text string) (string, bool) {
+	for _, t := range bl {
+		if t.re.MatchString(text) {
+			return t.term, true
+		}
+	}
+	return "", false
+}
+
+// enforceBlocklist checks the request's text fields before the handler runs
+// and leaves the list on the context for checkPolicy.
+func enforceBlocklist(bl Blocklist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(bl) == 0 {
+			c.Next()
+			return
+		}
+		c.Set(blocklistKey, bl)
+		if !checkPolicyFields(httpRequest(c)) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkPolicyFields checks the request's text fields, see checkPolicy.
+func checkPolicyFields(r *request) bool {
+	for _, field := range policyFields {
+		if !checkPolicy(r, r.PostForm(field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPolicy answers 403 and returns false when text contains a blocked
+// term. Handlers use it for content they fetch or extract themselves.
+func checkPolicy(r *request, text string) bool {
+	term, blocked := r.blocklist.match(text)
+	if !blocked {
+		return true
+	}
+	fmt.Printf("🛡️ Request blocked by policy term %q\n", term)
+	r.JSON(403, gin.H{"error": fmt.Sprintf("This server does not produce videos about %q", term), "code": "policy_blocked"})
+	return false
+}
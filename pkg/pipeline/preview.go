@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PREVIEW ---
+// preview=true renders the script once at 360p/15fps first (no title
+// cards, avatar, sting or music) and answers 202 with its URL as soon as it
+// is stitched. The full-quality render then continues on the same job,
+// reusing the preview's narration, and its result arrives through the
+// webhook (job.completed) or GET /v1/jobs/:id.
+const (
+	previewHeight = 360
+	previewFPS    = 15
+)
+
+// previewFrame scales a frame size so its short side is previewHeight,
+// keeping both sides even for yuv420p.
+func previewFrame(w, h int) (int, int) {
+	short := min(w, h)
+	return w * previewHeight / short / 2 * 2, h * previewHeight / short / 2 * 2
+}
+
+// previewPath names the preview twin of a segment file.
+func previewPath(path string) string {
+	dir, name := filepath.Split(path)
+	return filepath.Join(dir, "preview_"+name)
+}
+
+// narrationPath is where a segment's narration is written. A preview
+// segment shares it with its full-quality twin, which then skips TTS.
+func narrationPath(segPath string) string {
+	dir, name := filepath.Split(segPath)
+	return filepath.Join(dir, strings.TrimSuffix(strings.TrimPrefix(name, "preview_"), ".mp4")+".mp3")
+}
+
+func previewOptions(opts RenderOptions) RenderOptions {
+	opts.Preview = true
+	opts.TitleCard = 0
+	opts.Avatar = ""
+	opts.StingPath = ""
+	return opts
+}
+
+// offerPreview renders and sends the preview when the request asked for
+// one. On failure it logs and the request simply waits for the full render.
+// It is called within the render stage, which resumes after it.
+func offerPreview(r *request, job *Job, script ScriptResponse, introPath string, scenePaths []string, outroPath string, base RenderOptions, sceneOpts []RenderOptions) {
+	if r.PostForm("preview") != "true" {
+		return
+	}
+	job.Stage("preview")
+	defer job.Stage("render")
+	opts := make([]RenderOptions, len(sceneOpts))
+	for i := range sceneOpts {
+		opts[i] = previewOptions(sceneOpts[i])
+	}
+	segments, err := renderScript(job, script, introPath, scenePaths, outroPath, previewOptions(base), opts)
+	if err != nil || len(segments) == 0 {
+		fmt.Println("⚠️ Preview skipped: no segments rendered")
+		return
+	}
+	out := job.Path("preview.mp4")
+	_, err = stitchSegments(segments, out)
+	job.RemoveSegments(segments)
+	if err != nil {
+		fmt.Printf("⚠️ Preview skipped: %v\n", err)
+		return
+	}
+
+	fmt.Println("👀 Preview ready, full render continues.")
+	previewURL := job.URL(r.base, out)
+	job.Emit("job.preview_ready", gin.H{"preview_url": previewURL})
+	job.Detach(r, 202, gin.H{"status": "rendering", "job_id": job.ID, "preview_url": previewURL,
+		"status_url": r.base + "/v1/jobs/" + job.ID})
+}
+
+// Detach sends response to the client now, while the handler keeps running.
+// Its final response is still recorded as the job result by jobEvents but
+// no longer written; until then the detached response is the job's status.
+// A streamed response gets it as a status event and stays open.
+func (j *Job) Detach(r *request, code int, response gin.H) {
+	if j.stream != nil {
+		j.SaveResult(response)
+		j.stream.send("status", response)
+		return
+	}
+	if r.c == nil { // Pipeline.Generate: the status is all there is to update
+		j.SaveResult(response)
+		return
+	}
+	rec, ok := r.c.Writer.(*bodyRecorder)
+	if !ok || rec.detached {
+		return
+	}
+	j.SaveResult(response)
+	data, _ := json.Marshal(response)
+	w := rec.ResponseWriter
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(code)
+	w.Write(data)
+	w.Flush()
+	rec.detached = true
+}
@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PRONUNCIATIONS ---
+// A lexicon maps terms the voices get wrong (brand names, Indian names,
+// jargon) to a spelling they read right, e.g. "Vixio": "Vik-see-oh". It is
+// applied to the narration just before TTS, so scripts, captions and specs
+// keep the real spelling. Callers identified by an X-Api-Key header keep
+// one with PUT /pronunciations, stored under a hash of the key like
+// notification settings, and a script template may carry one in
+// "pronunciations"; every request with that key or template uses them, the
+// caller's entry winning over the template's. Terms match whole words,
+// ignoring case, longest first.
+type Lexicon map[string]string
+
+const maxLexiconTerms = 500
+
+func pronunciationDir() string {
+	if dir := os.Getenv("PRONUNCIATION_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "pronunciations")
+}
+
+func pronunciationFile(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return filepath.Join(pronunciationDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+func loadLexicon(apiKey string) Lexicon {
+	var l Lexicon
+	if apiKey == "" {
+		return nil
+	}
+	data, err := os.ReadFile(pronunciationFile(apiKey))
+	if err != nil || json.Unmarshal(data, &l) != nil {
+		return nil
+	}
+	return l
+}
+
+func (l Lexicon) validate() error {
+	if len(l) > maxLexiconTerms {
+		return fmt.Errorf("too many pronunciations (max %d)", maxLexiconTerms)
+	}
+	for term, say := range l {
+		if strings.TrimSpace(term) == "" || strings.TrimSpace(say) == "" {
+			return fmt.Errorf("pronunciation %q needs a term and a spelling", term)
+		}
+	}
+	return nil
+}
+
+// requestLexicon merges the request's template lexicon with the caller's.
+func requestLexicon(f Form) Lexicon {
+	merged := Lexicon{}
+	if id := strings.ToLower(strings.TrimSpace(f.PostForm("script_template"))); id != "" {
+		if t, err := loadScriptTemplate(id); err == nil {
+			for term, say := range t.Pronunciations {
+				merged[term] = say
+			}
+		}
+	}
+	for term, say := range loadLexicon(f.GetHeader("X-Api-Key")) {
+		merged[term] = say
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// apply respells every term in text.
+func (l Lexicon) apply(text string) string {
+	if len(l) == 0 {
+		return text
+	}
+	terms := make([]string, 0, len(l))
+	for term := range l {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	say := map[string]string{}
+	alts := make([]string, len(terms))
+	for i, term := range terms {
+		say[strings.ToLower(term)] = l[term]
+		alts[i] = wordBounded(term)
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(alts, "|"))
+	return re.ReplaceAllStringFunc(text, func(m string) string {
+		return say[strings.ToLower(m)]
+	})
+}
+
+// wordBounded matches term as a whole word; \b only knows ASCII words, so
+// it is left off ends that aren't ASCII letters or digits.
+func wordBounded(term string) string {
+	asciiWord := func(r rune) bool {
+		return r < utf8.RuneSelf && (r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+	pattern := regexp.QuoteMeta(term)
+	if asciiWord(first) {
+		pattern = `\b` + pattern
+	}
+	if asciiWord(last) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+// PUT /pronunciations (JSON: {"pronunciations": {"Vixio": "Vik-see-oh"}},
+// replacing the caller's lexicon)
+func handleSetPronunciations(c *gin.Context) {
+	key := c.GetHeader("X-Api-Key")
+	if key == "" {
+		c.JSON(401, gin.H{"error": "X-Api-Key header is required"})
+		return
+	}
+	var body struct {
+		Pronunciations Lexicon `json:"pronunciations"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	if err := body.Pronunciations.validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	os.MkdirAll(pronunciationDir(), 0700)
+	data, _ := json.MarshalIndent(body.Pronunciations, "", "  ")
+	if err := os.WriteFile(pronunciationFile(key), data, 0600); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save pronunciations"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "pronunciations": body.Pronunciations})
+}
+
+// GET /pronunciations
+func handleGetPronunciations(c *gin.Context) {
+	l := loadLexicon(c.GetHeader("X-Api-Key"))
+	if l == nil {
+		c.JSON(404, gin.H{"error": "no pronunciations for this key"})
+		return
+	}
+	c.JSON(200, gin.H{"pronunciations": l})
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// --- PUBLIC FETCHES ---
+// URLs a caller hands us (article pages, RSS feeds, the images they
+// reference, webhook targets) are only ever fetched from public addresses. The check
+// runs on the address actually dialed, after DNS and on every redirect, so
+// a name that resolves inward or a redirect to the metadata service is
+// refused too. checkPublicURL gives the same answer up front, when the URL
+// is accepted.
+var errNotPublic = errors.New("not a public address")
+
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || cgnat.Contains(ip))
+}
+
+// publicClient fetches caller-supplied URLs. It never uses a proxy: the
+// proxy's address would be the one checked.
+var publicClient = &http.Client{
+	Timeout: time.Minute,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return fmt.Errorf("%w: %s", errNotPublic, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return checkPublicURL(req.URL.String())
+	},
+}
+
+// checkPublicURL accepts an http(s) URL whose host resolves only to public
+// addresses.
+func checkPublicURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("invalid url")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !publicIP(ip) {
+			return fmt.Errorf("%w: %s", errNotPublic, u.Hostname())
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("could not resolve %s", u.Hostname())
+	}
+	for _, a := range addrs {
+		if !publicIP(a.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", errNotPublic, u.Hostname(), a.IP)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", false},       // loopback
+		{"10.1.2.3", false},        // RFC 1918
+		{"172.16.0.1", false},      // RFC 1918
+		{"192.168.1.1", false},     // RFC 1918
+		{"169.254.169.254", false}, // link-local, cloud metadata
+		{"100.64.0.1", false},      // CGNAT
+		{"100.127.255.254", false}, // CGNAT
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"8.8.8.8", true},
+		{"100.128.0.1", true}, // just past CGNAT
+		{"2001:4860:4860::8888", true},
+	}
+	for _, tt := range tests {
+		if got := publicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("publicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPublicURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string // ok, private or invalid
+	}{
+		{"http://127.0.0.1/feed", "private"},
+		{"http://10.0.0.5:8080/", "private"},
+		{"http://169.254.169.254/latest/meta-data/", "private"},
+		{"http://100.64.1.1/", "private"},
+		{"http://[::1]/", "private"},
+		{"http://[fe80::1]/", "private"},
+		{"https://8.8.8.8/", "ok"},
+		{"ftp://8.8.8.8/", "invalid"},
+		{"file:///etc/passwd", "invalid"},
+		{"http:///nohost", "invalid"},
+		{"", "invalid"},
+	}
+	for _, tt := range tests {
+		err := checkPublicURL(tt.url)
+		got := "invalid"
+		switch {
+		case err == nil:
+			got = "ok"
+		case errors.Is(err, errNotPublic):
+			got = "private"
+		}
+		if got != tt.want {
+			t.Errorf("checkPublicURL(%q) = %v, want %s", tt.url, err, tt.want)
+		}
+	}
+}
+
+func TestPublicClientRefusesPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	if _, err := publicClient.Get(srv.URL); !errors.Is(err, errNotPublic) {
+		t.Errorf("GET %s = %v, want not public", srv.URL, err)
+	}
+}
+
+func TestPublicClientRedirectToPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer srv.Close()
+	// The test server is on loopback, which publicClient won't dial, so
+	// follow the redirect with its redirect policy over a plain transport.
+	client := &http.Client{CheckRedirect: publicClient.CheckRedirect}
+	if _, err := client.Get(srv.URL); !errors.Is(err, errNotPublic) {
+		t.Errorf("redirect to metadata service = %v, want not public", err)
+	}
+}
@@ -0,0 +1,331 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PUBLISHING ---
+// A connection stores one account's platform credentials under an ID, like a
+// brand kit: POST /connections with
+//
+//	{"platform": "instagram", "access_token": "...", "account_id": "<ig user id>"}
+//	{"platform": "tiktok", "access_token": "..."}
+//
+// Tokens are never returned. A connection belongs to the X-Api-Key that
+// created it: only requests with that key can read it or publish with it.
+// POST /videos/:id/publish then posts a finished job's video to the
+// connection's account:
+//
+//	{"connection_id": "...", "caption": "...", "privacy_level": "SELF_ONLY"}
+//
+// Instagram pulls the video from its public URL (the server must be
+// reachable); TikTok gets the file uploaded. privacy_level is TikTok's and
+// defaults to SELF_ONLY, the only level unaudited apps may use.
+const (
+	graphAPI        = "https://graph.facebook.com/v19.0"
+	tiktokAPI       = "https://open.tiktokapis.com/v2"
+	publishTimeout  = 5 * time.Minute
+	tiktokChunkSize = 10 << 20
+)
+
+type Connection struct {
+	ID          string `json:"id"`
+	Platform    string `json:"platform"`
+	Name        string `json:"name,omitempty"`
+	AccountID   string `json:"account_id,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
+	Owner       string `json:"owner,omitempty"` // see keyOwner
+}
+
+func connectionDir() string {
+	if dir := os.Getenv("CONNECTION_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "connections")
+}
+
+// loadConnection returns the connection if apiKey owns it. Another
+// caller's connection is reported as not found.
+func loadConnection(id, apiKey string) (Connection, error) {
+	var conn Connection
+	if !uuidPattern.MatchString(id) {
+		return conn, fmt.Errorf("invalid connection_id")
+	}
+	data, err := os.ReadFile(filepath.Join(connectionDir(), id+".json"))
+	if err == nil {
+		err = json.Unmarshal(data, &conn)
+	}
+	if err != nil || conn.Owner == "" || conn.Owner != keyOwner(apiKey) {
+		return Connection{}, fmt.Errorf("connection %s not found", id)
+	}
+	return conn, nil
+}
+
+// redacted is the connection as shown to clients.
+func (conn Connection) redacted() Connection {
+	conn.AccessToken, conn.Owner = "", ""
+	return conn
+}
+
+// POST /connections
+func handleCreateConnection(c *gin.Context) {
+	owner := keyOwner(c.GetHeader("X-Api-Key"))
+	if owner == "" {
+		c.JSON(401, gin.H{"error": "X-Api-Key header is required"})
+		return
+	}
+	var conn Connection
+	if err := c.ShouldBindJSON(&conn); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	conn.Platform = strings.ToLower(strings.TrimSpace(conn.Platform))
+	switch {
+	case conn.Platform != "instagram" && conn.Platform != "tiktok":
+		c.JSON(400, gin.H{"error": "platform must be instagram or tiktok"})
+		return
+	case conn.AccessToken == "":
+		c.JSON(400, gin.H{"error": "access_token is required"})
+		return
+	case conn.Platform == "instagram" && conn.AccountID == "":
+		c.JSON(400, gin.H{"error": "account_id (Instagram user ID) is required"})
+		return
+	}
+	conn.ID, conn.Owner = newUUID(), owner
+
+	os.MkdirAll(connectionDir(), 0700)
+	data, _ := json.MarshalIndent(conn, "", "  ")
+	if err := os.WriteFile(filepath.Join(connectionDir(), conn.ID+".json"), data, 0600); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save connection"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "connection": conn.redacted()})
+}
+
+// GET /connections/:id
+func handleGetConnection(c *gin.Context) {
+	conn, err := loadConnection(c.Param("id"), c.GetHeader("X-Api-Key"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"connection": conn.redacted()})
+}
+
+type publishRequest struct {
+	ConnectionID string `json:"connection_id"`
+	Caption      string `json:"caption"`
+	PrivacyLevel string `json:"privacy_level"`
+}
+
+// POST /videos/:id/publish posts the finished video of job :id.
+func handlePublishVideo(c *gin.Context) {
+	var req publishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	conn, err := loadConnection(req.ConnectionID, c.GetHeader("X-Api-Key"))
+	if err != nil {
+		c.JSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	job, result, err := openJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	videoURL, _ := result["video_url"].(string)
+	if result["status"] != "success" || videoURL == "" {
+		c.JSON(409, gin.H{"error": "video is not finished"})
+		return
+	}
+	video := job.Path(path.Base(videoURL))
+
+	fmt.Printf("📤 Publishing job %s to %s\n", job.ID, conn.Platform)
+	client := &http.Client{Timeout: time.Minute}
+	var postID string
+	switch conn.Platform {
+	case "instagram":
+		postID, err = publishInstagram(client, conn, videoURL, req.Caption)
+	case "tiktok":
+		postID, err = publishTikTok(client, conn, video, req.Caption, req.PrivacyLevel)
+	}
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Publish): %v\n", err)
+		c.JSON(502, gin.H{"error": "Publish failed: " + err.Error()})
+		return
+	}
+	fmt.Println("✅ SUCCESS! Video Published.")
+	c.JSON(200, gin.H{"status": "published", "job_id": job.ID, "platform": conn.Platform, "post_id": postID})
+}
+
+// publishInstagram creates a Reels container from the public video URL,
+// waits for Instagram to process it and publishes it.
+func publishInstagram(client *http.Client, conn Connection, videoURL, caption string) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	form := url.Values{"media_type": {"REELS"}, "video_url": {videoURL}, "caption": {caption}, "access_token": {conn.AccessToken}}
+	if err := callJSON(client, "POST", graphAPI+"/"+conn.AccountID+"/media", "", form, &created); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(publishTimeout)
+	for {
+		var status struct {
+			StatusCode string `json:"status_code"`
+		}
+		q := url.Values{"fields": {"status_code"}, "access_token": {conn.AccessToken}}
+		if err := callJSON(client, "GET", graphAPI+"/"+created.ID+"?"+q.Encode(), "", nil, &status); err != nil {
+			return "", err
+		}
+		if status.StatusCode == "FINISHED" {
+			break
+		}
+		if status.StatusCode == "ERROR" || status.StatusCode == "EXPIRED" {
+			return "", fmt.Errorf("instagram could not process the video (%s)", status.StatusCode)
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("instagram still processing after %v", publishTimeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	var published struct {
+		ID string `json:"id"`
+	}
+	form = url.Values{"creation_id": {created.ID}, "access_token": {conn.AccessToken}}
+	if err := callJSON(client, "POST", graphAPI+"/"+conn.AccountID+"/media_publish", "", form, &published); err != nil {
+		return "", err
+	}
+	return published.ID, nil
+}
+
+// publishTikTok uploads the file through the Content Posting API's direct
+// post flow and waits until TikTok reports the post complete.
+func publishTikTok(client *http.Client, conn Connection, video, caption, privacy string) (string, error) {
+	st, err := os.Stat(video)
+	if err != nil {
+		return "", err
+	}
+	if privacy == "" {
+		privacy = "SELF_ONLY"
+	}
+	size := st.Size()
+	if size == 0 {
+		return "", fmt.Errorf("video file is empty")
+	}
+	// A small file goes up whole; otherwise the last chunk takes the remainder.
+	chunk := min(int64(tiktokChunkSize), size)
+	chunks := size / chunk
+
+	var started struct {
+		Data struct {
+			PublishID string `json:"publish_id"`
+			UploadURL string `json:"upload_url"`
+		} `json:"data"`
+	}
+	body := gin.H{
+		"post_info":   gin.H{"title": caption, "privacy_level": privacy},
+		"source_info": gin.H{"source": "FILE_UPLOAD", "video_size": size, "chunk_size": chunk, "total_chunk_count": chunks},
+	}
+	if err := callJSON(client, "POST", tiktokAPI+"/post/publish/video/init/", conn.AccessToken, body, &started); err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(video)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	for i := int64(0); i < chunks; i++ {
+		start, end := i*chunk, (i+1)*chunk
+		if i == chunks-1 {
+			end = size
+		}
+		req, _ := http.NewRequest("PUT", started.Data.UploadURL, io.NewSectionReader(f, start, end-start))
+		req.ContentLength = end - start
+		req.Header.Set("Content-Type", "video/mp4")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, size))
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return "", fmt.Errorf("tiktok upload chunk %d: status %d", i+1, resp.StatusCode)
+		}
+	}
+
+	deadline := time.Now().Add(publishTimeout)
+	for {
+		var status struct {
+			Data struct {
+				Status     string `json:"status"`
+				FailReason string `json:"fail_reason"`
+			} `json:"data"`
+		}
+		if err := callJSON(client, "POST", tiktokAPI+"/post/publish/status/fetch/", conn.AccessToken, gin.H{"publish_id": started.Data.PublishID}, &status); err != nil {
+			return "", err
+		}
+		switch status.Data.Status {
+		case "PUBLISH_COMPLETE", "SEND_TO_USER_INBOX":
+			return started.Data.PublishID, nil
+		case "FAILED":
+			return "", fmt.Errorf("tiktok publish failed: %s", status.Data.FailReason)
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("tiktok still processing after %v", publishTimeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// callJSON sends a form (url.Values) or JSON body with an optional bearer
+// token and decodes the JSON answer into out. Non-2xx answers are errors
+// carrying the platform's message.
+func callJSON(client *http.Client, method, target, token string, body any, out any) error {
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		reader, contentType = strings.NewReader(b.Encode()), "application/x-www-form-urlencoded"
+	default:
+		data, _ := json.Marshal(b)
+		reader, contentType = bytes.NewReader(data), "application/json; charset=UTF-8"
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %d: %s", method, req.URL.Host+req.URL.Path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"os"
+)
+
+// --- MEDIA QUALITY GATE ---
+// Fetched images (TMDB artwork, stock and Unsplash photos, article and
+// headline images) must be at least MEDIA_MIN_SIDE pixels (400) on their
+// shorter side and no more than MEDIA_MAX_ASPECT (3) times longer than
+// wide or the other way round, so a 154px poster or a banner strip is
+// never blown up to fill the frame. A rejected image moves on to the next
+// candidate (the next TMDB match, stock result or article image) and then
+// to the next media source. Uploads and generated images are not gated;
+// formats the server can't measure pass.
+func mediaQualityOK(w, h int) error {
+	minSide, maxAspect := int(envInt("MEDIA_MIN_SIDE", 400)), float64(envInt("MEDIA_MAX_ASPECT", 3))
+	if min(w, h) < minSide {
+		return fmt.Errorf("%dx%d is below %dpx", w, h, minSide)
+	}
+	if aspect := float64(max(w, h)) / float64(min(w, h)); aspect > maxAspect {
+		return fmt.Errorf("%dx%d is wider than %.0f:1", w, h, maxAspect)
+	}
+	return nil
+}
+
+// checkImageQuality applies the gate to a downloaded file.
+func checkImageQuality(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil
+	}
+	if err := mediaQualityOK(cfg.Width, cfg.Height); err != nil {
+		fmt.Printf("🔍 Rejected image: %v\n", err)
+		return fmt.Errorf("low quality image: %v", err)
+	}
+	return nil
+}
+
+// gated runs fetch and then the quality gate and NSFW filter, so a
+// rejected image fails the fetch and isn't cached.
+func gated(fetch func(dest string) error) func(dest string) error {
+	return screened(func(dest string) error {
+		if err := fetch(dest); err != nil {
+			return err
+		}
+		return checkImageQuality(dest)
+	})
+}
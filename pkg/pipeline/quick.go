@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- QUICK VIDEO (no-code tools) ---
+// POST /v1/quick-video takes flat JSON instead of multipart with nested
+// scenes JSON, which Zapier and n8n struggle to build:
+//
+//	{"topic": "Top 5 sci-fi films", "items": ["Dune: desert power", "Alien"],
+//	 "type": "short", "webhook_url": "https://hooks.example.com/x"}
+//
+// An item "Name: details" is split at the first colon. Everything else gets
+// defaults (captions on); "options" may set any other form field. The job
+// renders in the background: the response is the job ID, the result comes
+// through the webhook or GET /v1/jobs/:id.
+type quickVideoRequest struct {
+	Topic         string            `json:"topic"`
+	Items         []string          `json:"items"`
+	Type          string            `json:"type"`
+	WebhookURL    string            `json:"webhook_url"`
+	WebhookEvents string            `json:"webhook_events"`
+	Options       map[string]string `json:"options"`
+}
+
+func handleQuickVideo(c *gin.Context) {
+	var req quickVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Topic == "" || len(req.Items) == 0 {
+		c.JSON(400, gin.H{"error": "topic and items are required"})
+		return
+	}
+	if limit := limitsFromEnv().MaxScenes; len(req.Items) > limit {
+		c.JSON(422, gin.H{"error": fmt.Sprintf("Too many items (max %d)", limit)})
+		return
+	}
+
+	spec := Spec{Topic: req.Topic, Type: req.Type, Options: map[string]string{"captions": "true"}}
+	for _, item := range req.Items {
+		name, details, ok := strings.Cut(item, ":")
+		if !ok {
+			details = item
+		}
+		spec.Scenes = append(spec.Scenes, SceneSpec{SceneData: SceneData{Name: strings.TrimSpace(name), Details: strings.TrimSpace(details)}})
+	}
+	for k, v := range req.Options {
+		spec.Options[k] = v
+	}
+	if req.WebhookURL != "" {
+		spec.Options["webhook_url"] = req.WebhookURL
+		spec.Options["webhook_events"] = req.WebhookEvents
+	}
+
+	job, err := newJob()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	p := &Pipeline{BaseURL: baseURL(c), APIKey: c.GetHeader("X-Api-Key")}
+	go func() {
+		r, err := p.request(spec)
+		if err == nil {
+			r.preset = job // newRequestJob adopts it
+			_, err = generate(r)
+		}
+		if err != nil {
+			fmt.Printf("❌ Quick video %s failed: %v\n", job.ID, err)
+			// Rejected before the render adopted the job: record it here.
+			if _, statErr := os.Stat(job.Path("result.json")); statErr != nil {
+				job.SaveResult(gin.H{"status": "failed", "job_id": job.ID, "error": err.Error()})
+			}
+		}
+	}()
+	c.JSON(202, gin.H{"status": "accepted", "job_id": job.ID, "status_url": baseURL(c) + "/v1/jobs/" + job.ID})
+}
+
+// GET /v1/jobs/:id returns the saved result, the ready_for_streaming state
+// (see offerStream) or status "running". Admins (X-Admin-Token) also get
+// the job's debug record, if it has one.
+func handleGetJob(c *gin.Context) {
+	id := c.Param("id")
+	job, result, err := openJob(id)
+	if err == errJobNotFound {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		if state := streamState(job); state != nil {
+			c.JSON(200, state)
+			return
+		}
+		c.JSON(200, gin.H{"status": "running", "job_id": id})
+		return
+	}
+	if report, owner, ok := loadDebug(id); ok {
+		token := os.Getenv("ADMIN_TOKEN")
+		admin := token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(token)) == 1
+		if admin || (owner != "" && owner == keyOwner(c.GetHeader("X-Api-Key"))) {
+			result["debug"] = report
+		}
+	}
+	c.JSON(200, result)
+}
@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- QUOTE MODE ---
+// POST /generate-quote-video renders one typographic card per quote, read
+// out with its author. quotes takes one quote per line as "text — author"
+// (an ASCII " - " works too); without it, theme=<theme> has the LLM pick
+// count (default 5, at most 10) well-attributed quotes on the theme. No
+// external media is needed: every scene is a text card.
+const maxQuotes = 10
+
+// Saying is one quote and who said it.
+type Saying struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+}
+
+func (s Saying) byline() string {
+	if s.Author == "" {
+		return ""
+	}
+	return "— " + s.Author
+}
+
+func handleQuoteVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Quote Request Received")
+
+	sayings := parseSayings(r.PostForm("quotes"))
+	theme := strings.TrimSpace(r.PostForm("theme"))
+	if len(sayings) == 0 && theme == "" {
+		r.JSON(400, gin.H{"error": "quotes or theme is required"})
+		return
+	}
+	if len(sayings) > maxQuotes {
+		r.JSON(400, gin.H{"error": fmt.Sprintf("at most %d quotes", maxQuotes)})
+		return
+	}
+	count := 5
+	if v := r.PostForm("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxQuotes {
+			r.JSON(400, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxQuotes)})
+			return
+		}
+		count = n
+	}
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	if len(sayings) == 0 {
+		fmt.Println("🔹 STEP 2: Finding Quotes (Groq)...")
+		job.Stage("script")
+		sayings, err = findSayings(job, theme, count)
+		if err != nil {
+			fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+			r.JSON(500, gin.H{"error": "AI quote search failed: " + err.Error()})
+			return
+		}
+		var text strings.Builder
+		for _, s := range sayings {
+			text.WriteString(s.Text + "\n")
+		}
+		if !checkPolicy(r, text.String()) {
+			return
+		}
+		if topic == "" {
+			topic = "Quotes on " + theme
+		}
+	}
+	if topic == "" {
+		topic = "Quotes to live by"
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Quotes: %d\n", topic, videoType, len(sayings))
+
+	scriptData := ScriptResponse{Intro: topic + ".", Outro: "Which one stayed with you? Save this for later.", Mood: "calm"}
+	for _, s := range sayings {
+		details := s.Text
+		if s.Author != "" {
+			details += " — " + s.Author + "."
+		}
+		scriptData.Items = append(scriptData.Items, ScriptItem{Title: s.Author, Details: details})
+	}
+	job.scriptReady(scriptData)
+
+	job.Stage("media")
+	w, h := frameSize(videoType)
+	introPath := placeholderCard(job, topic, 0, videoType, base.Card)
+	scenePaths := make([]string, len(sayings))
+	for i, s := range sayings {
+		tc := TextCard{Mark: "“", Body: s.Text, Footer: s.byline(), Size: min(w, h) / 12, Center: true}
+		path, err := job.Fetch("quote:"+s.Text+"|"+s.Author, ".jpg", func(dest string) error {
+			return renderTextCard(tc, dest, videoType, base.Card)
+		})
+		if err != nil {
+			fmt.Printf("⚠️ Quote card failed: %v\n", err)
+			path = placeholderCard(job, s.Text, i+1, videoType, base.Card)
+		}
+		scenePaths[i] = path
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 3, roundup{Name: "quotes", Topic: topic, VideoType: videoType, Script: scriptData,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+// parseSayings reads one quote per line, splitting the author off at the
+// last " — " or " - ". Surrounding quote marks are dropped.
+func parseSayings(v string) []Saying {
+	var sayings []Saying
+	for _, line := range strings.Split(v, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var s Saying
+		cut := max(strings.LastIndex(line, " — "), strings.LastIndex(line, " - "))
+		if cut > 0 {
+			s.Text, s.Author = line[:cut], strings.TrimLeft(line[cut+1:], "—- ")
+		} else {
+			s.Text = line
+		}
+		s.Text = strings.Trim(strings.TrimSpace(s.Text), `"“”`)
+		sayings = append(sayings, s)
+	}
+	return sayings
+}
+
+// findSayings asks the LLM for count real quotes on theme.
+func findSayings(job *Job, theme string, count int) ([]Saying, error) {
+	prompt := fmt.Sprintf(`
+    Theme: "%s"
+    List exactly %d short, memorable quotes on this theme, each under 40 words.
+    Only use real quotes whose attribution is well documented; never invent or paraphrase a quote.
+    Prefer variety: different authors, eras and angles on the theme.
+    RETURN JSON ONLY:
+    {
+        "quotes": [
+            { "text": "The quote, without quote marks", "author": "Full name" }
+        ]
+    }
+    `, theme, count)
+
+	var result struct {
+		Quotes []Saying `json:"quotes"`
+	}
+	if err := completeJSON(job, "quote_search", prompt, &result); err != nil {
+		return nil, err
+	}
+	var sayings []Saying
+	for _, s := range result.Quotes {
+		if s.Text = strings.Trim(strings.TrimSpace(s.Text), `"“”`); s.Text != "" {
+			sayings = append(sayings, s)
+		}
+	}
+	if len(sayings) == 0 {
+		return nil, fmt.Errorf("no quotes returned")
+	}
+	if len(sayings) > count {
+		sayings = sayings[:count]
+	}
+	return sayings, nil
+}
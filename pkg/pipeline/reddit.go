@@ -0,0 +1,246 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- REDDIT MODE ---
+// POST /generate-reddit-video turns a thread (url=https://www.reddit.com/r/
+// .../comments/<id>/... or https://redd.it/<id>) into the "AskReddit"
+// format: the post as the intro card, then one comment card per top-level
+// comment (comments, default 5, long 8, at most 10), read out verbatim.
+// Comments longer than twice the mode's scene length are skipped so each
+// card stays readable, as are stickied, deleted and bot comments.
+const maxComments = 10
+
+var (
+	redditLink  = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	redditMarks = strings.NewReplacer("**", "", "__", "", "~~", "", "\\", "")
+)
+
+// RedditPost is a thread or one of its comments.
+type RedditPost struct {
+	Subreddit string
+	Title     string // empty for comments
+	Author    string
+	Body      string // self text or comment, as plain text
+	Score     int
+	Permalink string
+}
+
+func (p RedditPost) header() string {
+	if p.Title != "" {
+		return fmt.Sprintf("r/%s · u/%s", p.Subreddit, p.Author)
+	}
+	return fmt.Sprintf("u/%s · %s points", p.Author, shortCount(p.Score))
+}
+
+func handleRedditVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Reddit Request Received")
+
+	id, err := redditThreadID(r.PostForm("url"))
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+	count := 5
+	if videoType == "long" {
+		count = 8
+	}
+	if v := r.PostForm("comments"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxComments {
+			r.JSON(400, gin.H{"error": fmt.Sprintf("comments must be between 1 and %d", maxComments)})
+			return
+		}
+		count = n
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Fetching Thread...")
+	job.Stage("thread")
+	_, maxWords := wordRange(videoType)
+	post, comments, err := fetchRedditThread(&http.Client{Timeout: newsTimeout}, id, maxWords*2)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Reddit): %v\n", err)
+		r.JSON(502, gin.H{"error": "Could not fetch thread: " + err.Error()})
+		return
+	}
+	if len(comments) == 0 {
+		r.JSON(422, gin.H{"error": "Thread has no short enough comments to read"})
+		return
+	}
+	if len(comments) > count {
+		comments = comments[:count]
+	}
+	text := post.Title
+	for _, cm := range comments {
+		text += "\n" + cm.Body
+	}
+	if !checkPolicy(r, text) {
+		return
+	}
+	job.AddSource("", Source{Provider: "Reddit", Title: post.Title, Author: "u/" + post.Author, URL: post.Permalink})
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	if topic == "" {
+		topic = post.Title
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Comments: %d\n", topic, videoType, len(comments))
+	job.Stage("script")
+	scriptData := ScriptResponse{
+		Intro: post.Title,
+		Outro: "Which answer was your favourite? Tell us in the comments!",
+		Mood:  "playful",
+	}
+	for _, cm := range comments {
+		scriptData.Items = append(scriptData.Items, ScriptItem{Title: "u/" + cm.Author, Details: cm.Body})
+	}
+	job.scriptReady(scriptData)
+
+	job.Stage("media")
+	introPath := redditCard(job, post, 0, videoType, base.Card)
+	scenePaths := make([]string, len(comments))
+	for i, cm := range comments {
+		scenePaths[i] = redditCard(job, cm, i+1, videoType, base.Card)
+		job.AddSource("", Source{Provider: "Reddit", Author: "u/" + cm.Author, URL: cm.Permalink})
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 3, roundup{Name: "reddit", Topic: topic, VideoType: videoType, Script: scriptData,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+// redditThreadID extracts the base36 thread id from a thread link.
+func redditThreadID(link string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("url must be a Reddit thread link")
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case host == "redd.it" && parts[0] != "":
+		return parts[0], nil
+	case host == "reddit.com" || strings.HasSuffix(host, ".reddit.com"):
+		for i, p := range parts {
+			if p == "comments" && i+1 < len(parts) {
+				return parts[i+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("url must be a Reddit thread link")
+}
+
+// fetchRedditThread loads the post and its top-level comments by score,
+// keeping those of at most maxWords words.
+func fetchRedditThread(client *http.Client, id string, maxWords int) (RedditPost, []RedditPost, error) {
+	var listings []struct {
+		Data struct {
+			Children []struct {
+				Kind string `json:"kind"`
+				Data struct {
+					Subreddit string `json:"subreddit"`
+					Title     string `json:"title"`
+					Selftext  string `json:"selftext"`
+					Body      string `json:"body"`
+					Author    string `json:"author"`
+					Score     int    `json:"score"`
+					Permalink string `json:"permalink"`
+					Stickied  bool   `json:"stickied"`
+					Over18    bool   `json:"over_18"`
+				} `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	q := url.Values{"sort": {"top"}, "depth": {"1"}, "limit": {"50"}, "raw_json": {"1"}}
+	req, _ := http.NewRequest("GET", "https://www.reddit.com/comments/"+url.PathEscape(id)+".json?"+q.Encode(), nil)
+	if err := getNewsJSON(client, req, &listings); err != nil {
+		return RedditPost{}, nil, err
+	}
+	if len(listings) < 2 || len(listings[0].Data.Children) == 0 {
+		return RedditPost{}, nil, fmt.Errorf("thread not found")
+	}
+	p := listings[0].Data.Children[0].Data
+	if p.Over18 {
+		return RedditPost{}, nil, fmt.Errorf("NSFW threads are not supported")
+	}
+	post := RedditPost{Subreddit: p.Subreddit, Title: p.Title, Author: p.Author, Body: redditText(p.Selftext),
+		Score: p.Score, Permalink: "https://www.reddit.com" + p.Permalink}
+
+	var comments []RedditPost
+	for _, child := range listings[1].Data.Children {
+		d := child.Data
+		body := redditText(d.Body)
+		if child.Kind != "t1" || d.Stickied || d.Author == "[deleted]" || d.Author == "AutoModerator" ||
+			body == "" || body == "[deleted]" || body == "[removed]" || len(strings.Fields(body)) > maxWords {
+			continue
+		}
+		comments = append(comments, RedditPost{Subreddit: d.Subreddit, Author: d.Author, Body: body,
+			Score: d.Score, Permalink: "https://www.reddit.com" + d.Permalink})
+	}
+	return post, comments, nil
+}
+
+// redditText flattens Reddit markdown to what should be read out: link
+// text without the URL, no emphasis or quote markers, one paragraph.
+func redditText(md string) string {
+	md = redditLink.ReplaceAllString(md, "$1")
+	var lines []string
+	for _, line := range strings.Split(redditMarks.Replace(md), "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), ">*-# ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// shortCount formats a score as 950, 1.2k or 15k.
+func shortCount(n int) string {
+	switch {
+	case n >= 10000:
+		return fmt.Sprintf("%dk", n/1000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return strconv.Itoa(n)
+}
+
+// redditCard renders (or reuses) p as a text card on a panel, falling back
+// to a placeholder card with the text.
+func redditCard(job *Job, p RedditPost, index int, videoType string, card CardStyle) string {
+	text := p.Body
+	if p.Title != "" {
+		text = p.Title
+	}
+	path, err := job.Fetch(fmt.Sprintf("reddit:%s", p.Permalink), ".jpg", func(dest string) error {
+		return renderTextCard(TextCard{Header: p.header(), Body: text, Panel: true}, dest, videoType, card)
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Reddit card failed: %v\n", err)
+		return placeholderCard(job, text, index, videoType, card)
+	}
+	return path
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import "testing"
+
+func TestRedditThreadID(t *testing.T) {
+	tests := []struct {
+		link string
+		want string // "" for an error
+	}{
+		{"https://www.reddit.com/r/golang/comments/1abc23/some_title/", "1abc23"},
+		{"https://reddit.com/r/golang/comments/1abc23", "1abc23"},
+		{"https://old.reddit.com/r/golang/comments/1abc23/some_title/", "1abc23"},
+		{"https://np.reddit.com/r/golang/comments/1abc23/t/kxyz9/", "1abc23"},
+		{"https://WWW.Reddit.com/comments/1abc23", "1abc23"},
+		{"https://redd.it/1abc23", "1abc23"},
+		{"  https://redd.it/1abc23?utm_source=share  ", "1abc23"},
+		{"https://www.reddit.com/r/golang/comments/1abc23/?sort=top#c", "1abc23"},
+		{"", ""},
+		{"not a url", ""},
+		{"reddit.com/r/golang/comments/1abc23", ""}, // no scheme, so no host
+		{"https://redd.it/", ""},
+		{"https://www.reddit.com/r/golang/", ""},
+		{"https://www.reddit.com/r/golang/comments/", ""},
+		{"https://notreddit.com/r/golang/comments/1abc23", ""},
+		{"https://reddit.com.evil.example/r/x/comments/1abc23", ""},
+		{"https://example.com/comments/1abc23", ""},
+	}
+	for _, tt := range tests {
+		got, err := redditThreadID(tt.link)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("redditThreadID(%q) = %q, want error", tt.link, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("redditThreadID(%q) = %q, %v, want %q", tt.link, got, err, tt.want)
+		}
+	}
+}
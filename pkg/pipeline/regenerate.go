@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PINNED REGENERATION ---
+// POST /v1/jobs/:id/script/regenerate rewrites a job's script except the
+// items listed in "keep", which stay verbatim (text, media and render).
+// The LLM sees the whole script, so the rewritten items still fit between
+// the pinned ones, plus optional "instructions" such as "make item 3
+// funnier". The result replaces the job's draft like any other edit (see
+// scriptedit.go), and a rerender only narrates and encodes the rewritten
+// items. "seed" fixes the rewrite as it does for generation.
+type itemRewrite struct {
+	Index int    `json:"index"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+func handleRegenerateScript(c *gin.Context) {
+	var body struct {
+		Keep         []int  `json:"keep"`
+		Instructions string `json:"instructions"`
+		Seed         *int   `json:"seed"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	req := httpRequest(c)
+	if !checkPolicy(req, body.Instructions) {
+		return
+	}
+	draftMu.Lock()
+	defer draftMu.Unlock()
+	job, spec, ok := openScript(c)
+	if !ok {
+		return
+	}
+	items := scriptItems(spec.Segments)
+	kept := map[int]bool{}
+	for _, i := range body.Keep {
+		if i < 0 || i >= len(items) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("no item %d (the script has %d)", i, len(items))})
+			return
+		}
+		kept[i] = true
+	}
+	if len(kept) == len(items) {
+		c.JSON(400, gin.H{"error": "every item is kept, nothing to regenerate"})
+		return
+	}
+
+	var script strings.Builder
+	for i, r := range items {
+		var text []string
+		for _, s := range spec.Segments[r[0]:r[1]] {
+			text = append(text, s.Text)
+		}
+		label := "REWRITE"
+		if kept[i] {
+			label = "LOCKED"
+		}
+		fmt.Fprintf(&script, "\n    Item %d [%s]: %s\n    %s\n", i, label, spec.Segments[r[0]].Title, strings.Join(text, " "))
+	}
+	instructions := ""
+	if s := strings.TrimSpace(body.Instructions); s != "" {
+		instructions = "\n    Instructions: " + s
+	}
+	prompt := fmt.Sprintf(`
+    This is the narration of a %s video, item by item:
+    %s
+    Items marked LOCKED are final. Rewrite every item marked REWRITE with a fresh take that flows
+    from the item before it into the item after it, keeps about the same length and does not repeat
+    what the LOCKED items already say. Keep an item's title unless it no longer fits.%s
+    RETURN JSON ONLY:
+    { "items": [ { "index": 2, "title": "Title", "text": "New narration" } ] }
+    `, spec.Type, script.String(), instructions)
+
+	seed := ""
+	if body.Seed != nil {
+		seed = strconv.Itoa(*body.Seed)
+	}
+	job.seed = seedFromForm(seed)
+	var result struct {
+		Items []itemRewrite `json:"items"`
+	}
+	fmt.Printf("🔹 Regenerating %d of %d items of job %s\n", len(items)-len(kept), len(items), job.ID)
+	if err := completeJSON(job, "regenerate_items", prompt, &result); err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		c.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	rewrites := map[int]itemRewrite{}
+	for _, r := range result.Items {
+		if r.Index >= 0 && r.Index < len(items) && !kept[r.Index] && strings.TrimSpace(r.Text) != "" {
+			if !checkPolicy(req, r.Title) || !checkPolicy(req, r.Text) {
+				return
+			}
+			rewrites[r.Index] = r
+		}
+	}
+	if len(rewrites) == 0 {
+		c.JSON(502, gin.H{"error": "The model did not rewrite any item"})
+		return
+	}
+
+	var segments []SegmentSpec
+	for i, r := range items {
+		rewrite, ok := rewrites[i]
+		if !ok {
+			segments = append(segments, spec.Segments[r[0]:r[1]]...)
+			continue
+		}
+		title := strings.TrimSpace(rewrite.Title)
+		if title == "" {
+			title = spec.Segments[r[0]].Title
+		}
+		segments = append(segments, itemSegments(spec.Segments[r[0]], title, strings.TrimSpace(rewrite.Text), spec.Type)...)
+	}
+	spec.Segments = segments
+	saveDraft(job, spec)
+	respondScript(c, job, spec, true)
+}
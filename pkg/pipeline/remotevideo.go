@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- REMOTE VIDEO ---
+// A scene can take its clip from a YouTube or Vimeo link instead of an
+// upload: scenes[i].video_url, optionally with video_start (seconds), for
+// reaction and commentary formats. The clip is downloaded with yt-dlp and
+// is used when the scene has no media_<i> upload. Remote video is off unless
+// the operator sets REMOTE_VIDEO=true; REMOTE_VIDEO_HOSTS (youtube.com,
+// youtu.be, vimeo.com) lists the sites links may point at. Only
+// REMOTE_VIDEO_MAX_SECONDS (60) from the start are downloaded, capped at the
+// upload size limit, and live streams are refused. YTDLP_PATH sets the
+// binary and REMOTE_VIDEO_TIMEOUT (300 seconds) bounds each download. The
+// video is credited as a source like stock photos.
+var defaultVideoHosts = []string{"youtube.com", "youtu.be", "vimeo.com"}
+
+func remoteVideoEnabled() bool {
+	return os.Getenv("REMOTE_VIDEO") == "true"
+}
+
+func videoHosts() []string {
+	var hosts []string
+	for _, h := range strings.Split(os.Getenv("REMOTE_VIDEO_HOSTS"), ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	if len(hosts) == 0 {
+		return defaultVideoHosts
+	}
+	return hosts
+}
+
+// checkVideoURL validates a scene's video_url; "" is allowed.
+func checkVideoURL(where, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if !remoteVideoEnabled() {
+		return fmt.Errorf("%s: video_url is disabled on this server", where)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("%s: invalid video_url", where)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, h := range videoHosts() {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: video_url host %s is not allowed (use %s)", where, host, strings.Join(videoHosts(), ", "))
+}
+
+// fetchRemoteVideo downloads the clip of a scene's video_url into the job.
+func fetchRemoteVideo(job *Job, link string, start float64) (string, error) {
+	if err := checkVideoURL("scene", link); err != nil {
+		return "", err
+	}
+	var source Source
+	key := fmt.Sprintf("video:%s@%g", link, start)
+	path, err := job.Fetch(key, ".mp4", func(dest string) (err error) {
+		source, err = downloadRemoteVideo(link, start, dest)
+		return err
+	})
+	if err == nil {
+		job.AddSource(path, source)
+	}
+	return path, err
+}
+
+// downloadRemoteVideo runs yt-dlp for the capped section from start.
+func downloadRemoteVideo(link string, start float64, dest string) (Source, error) {
+	maxSeconds := envInt("REMOTE_VIDEO_MAX_SECONDS", 60)
+	ytdlp := os.Getenv("YTDLP_PATH")
+	if ytdlp == "" {
+		ytdlp = "yt-dlp"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(envInt("REMOTE_VIDEO_TIMEOUT", 300))*time.Second)
+	defer cancel()
+
+	fmt.Printf("📥 Downloading clip %s (%gs, %ds max)\n", link, start, maxSeconds)
+	section := fmt.Sprintf("*%g-%g", start, start+float64(maxSeconds))
+	cmd := exec.CommandContext(ctx, ytdlp,
+		"--no-playlist", "--no-progress", "--no-warnings",
+		"--match-filter", "!is_live",
+		"--max-filesize", strconv.FormatInt(limitsFromEnv().MaxFile, 10),
+		"-f", "bv*[height<=1920]+ba/b",
+		"--merge-output-format", "mp4",
+		"--download-sections", section, "--force-keyframes-at-cuts",
+		"--print", "after_move:%(extractor_key)s\t%(uploader)s\t%(title)s\t%(webpage_url)s",
+		"-o", dest, link)
+	out, err := cmd.Output()
+	if err != nil {
+		log := ""
+		if exit, ok := err.(*exec.ExitError); ok {
+			log = string(exit.Stderr)
+		}
+		return Source{}, fmt.Errorf("yt-dlp: %v | Log: %s", err, log)
+	}
+	if info, err := os.Stat(dest); err != nil || info.Size() == 0 {
+		return Source{}, fmt.Errorf("yt-dlp: no video downloaded (live stream or over the size limit)")
+	}
+	if err := scanFile(dest); err != nil {
+		quarantine(dest)
+		return Source{}, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Split(lines[len(lines)-1], "\t")
+	for len(fields) < 4 {
+		fields = append(fields, "")
+	}
+	source := Source{Provider: fields[0], Author: fields[1], Title: fields[2], URL: fields[3], clip: true}
+	if source.Provider == "" {
+		u, _ := url.Parse(link)
+		source.Provider = strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	if source.URL == "" {
+		source.URL = link
+	}
+	return source, nil
+}
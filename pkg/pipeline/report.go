@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- MEDIA REPORT ---
+// Probed facts about the final video and each stitched segment, returned
+// with the job so clients don't have to download and probe the file.
+type MediaInfo struct {
+	Title      string  `json:"title,omitempty"`
+	Duration   float64 `json:"duration"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	FPS        float64 `json:"fps"`
+	Size       int64   `json:"size"`
+	VideoCodec string  `json:"video_codec"`
+	PixFmt     string  `json:"pix_fmt"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	SampleRate int     `json:"sample_rate,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	URL        string  `json:"url,omitempty"`      // kept segments only
+	Fallback   string  `json:"fallback,omitempty"` // see renderWithFallbacks
+}
+
+func probeMediaInfo(path string) (MediaInfo, error) {
+	f, err := probeSegmentFormat(path)
+	if err != nil {
+		return MediaInfo{}, err
+	}
+	info := MediaInfo{Width: f.Width, Height: f.Height, FPS: parseRate(f.AvgRate), VideoCodec: f.VCodec,
+		PixFmt: f.PixFmt, AudioCodec: f.ACodec, Channels: f.Channels}
+	info.SampleRate, _ = strconv.Atoi(f.SampleRate)
+	info.Duration, _ = probeDuration(path)
+	if st, err := os.Stat(path); err == nil {
+		info.Size = st.Size()
+	}
+	return info, nil
+}
+
+// parseRate turns an ffprobe rate such as "30000/1001" into frames per second.
+func parseRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	n, _ := strconv.ParseFloat(num, 64)
+	if !ok {
+		return n
+	}
+	d, _ := strconv.ParseFloat(den, 64)
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// segmentResults reports on the final video and its segments, then either
+// exposes the segment files (keep_segments=true) or deletes them.
+func segmentResults(r *request, job *Job, finalVideo string, segments []Segment) (MediaInfo, []MediaInfo) {
+	output, infos := mediaReport(finalVideo, segments)
+	if r.PostForm("keep_segments") != "true" {
+		job.RemoveSegments(segments)
+		return output, infos
+	}
+	for i, s := range segments {
+		if rel, err := filepath.Rel("output", s.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			infos[i].URL = publicURL(r.base, filepath.ToSlash(rel))
+		}
+	}
+	return output, infos
+}
+
+// mediaReport probes the final video and its segments for the job result.
+// Segments that can't be probed are reported with their title only.
+func mediaReport(finalVideo string, segments []Segment) (MediaInfo, []MediaInfo) {
+	output, _ := probeMediaInfo(finalVideo)
+	infos := make([]MediaInfo, len(segments))
+	for i, s := range segments {
+		infos[i], _ = probeMediaInfo(s.Path)
+		infos[i].Title, infos[i].Fallback = s.Title, s.Fallback
+	}
+	return output, infos
+}
@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- GENERATION REQUESTS ---
+// The generation modes read their request through a Form: the HTTP
+// request's fields, uploads and headers (httpForm), or a Spec's for
+// Pipeline.Generate (specForm). A generator answers with request.JSON,
+// which writes to the HTTP client if there is one and records the answer
+// either way, so the same code serves both.
+type Form interface {
+	PostForm(key string) string
+	DefaultPostForm(key, defaultValue string) string
+	GetHeader(key string) string
+	FormFile(name string) (*Upload, error)
+	values() (url.Values, map[string][]*Upload) // every field and upload, see saveRequest
+}
+
+// Upload is a file sent with the request: a multipart upload or a local file.
+type Upload struct {
+	Filename string
+	Size     int64
+	open     func() (io.ReadCloser, error)
+}
+
+func (u *Upload) Open() (io.ReadCloser, error) {
+	return u.open()
+}
+
+// save copies the upload to dest.
+func (u *Upload) save(dest string) error {
+	src, err := u.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+type httpForm struct {
+	*gin.Context
+}
+
+func (f httpForm) FormFile(name string) (*Upload, error) {
+	fh, err := f.Context.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return multipartUpload(fh), nil
+}
+
+func (f httpForm) values() (url.Values, map[string][]*Upload) {
+	f.Context.PostForm("") // parses the form
+	uploads := map[string][]*Upload{}
+	if form := f.Request.MultipartForm; form != nil {
+		for field, files := range form.File {
+			for _, fh := range files {
+				uploads[field] = append(uploads[field], multipartUpload(fh))
+			}
+		}
+	}
+	return f.Request.PostForm, uploads
+}
+
+func multipartUpload(fh *multipart.FileHeader) *Upload {
+	return &Upload{Filename: fh.Filename, Size: fh.Size, open: func() (io.ReadCloser, error) { return fh.Open() }}
+}
+
+// specForm is a Spec (or a saved request, see handleRetryJob) as form
+// fields and uploads by field.
+type specForm struct {
+	fields url.Values
+	files  map[string]*Upload
+	header http.Header
+}
+
+func (f specForm) PostForm(key string) string {
+	return f.fields.Get(key)
+}
+
+func (f specForm) DefaultPostForm(key, defaultValue string) string {
+	if v, ok := f.fields[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+	return defaultValue
+}
+
+func (f specForm) GetHeader(key string) string {
+	return f.header.Get(key)
+}
+
+func (f specForm) FormFile(name string) (*Upload, error) {
+	if u := f.files[name]; u != nil {
+		return u, nil
+	}
+	return nil, http.ErrMissingFile
+}
+
+func (f specForm) values() (url.Values, map[string][]*Upload) {
+	uploads := map[string][]*Upload{}
+	for field, u := range f.files {
+		uploads[field] = []*Upload{u}
+	}
+	return f.fields, uploads
+}
+
+func fileUpload(path string) (*Upload, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{Filename: filepath.Base(path), Size: info.Size(), open: func() (io.ReadCloser, error) { return os.Open(path) }}, nil
+}
+
+// request is one generation request on its way through a generator.
+type request struct {
+	Form
+	path      string // route it arrived on (or stands for), e.g. /generate-podcast-video
+	base      string // scheme://host of the URLs in the answer
+	blocklist Blocklist
+
+	c *gin.Context // the HTTP request; nil under Pipeline.Generate
+
+	preset   *Job           // job created before the request ran, see newRequestJob
+	progress func(Progress) // see Pipeline.Progress
+	retry    *retrySource   // a failed job's work to reuse, see handleRetryJob
+
+	job  *Job // set by newRequestJob
+	code int  // the answer, see JSON
+	body gin.H
+}
+
+// httpRequest wraps an HTTP request for a generator.
+func httpRequest(c *gin.Context) *request {
+	bl, _ := c.Get(blocklistKey)
+	r := &request{Form: httpForm{c}, path: c.FullPath(), base: baseURL(c), c: c}
+	r.blocklist, _ = bl.(Blocklist)
+	return r
+}
+
+// serve runs a generator as a gin handler.
+func serve(generate func(r *request)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		generate(httpRequest(c))
+	}
+}
+
+// JSON answers the request. An HTTP request is answered right away, as
+// c.JSON would; the last answer is kept for Pipeline.Generate.
+func (r *request) JSON(code int, body gin.H) {
+	r.code, r.body = code, body
+	if r.c != nil {
+		r.c.JSON(code, body)
+	}
+}
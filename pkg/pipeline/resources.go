@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- FFMPEG RESOURCES ---
+// Every ffmpeg run goes through ffmpegCommand, which sizes its threads to
+// the idle part of the machine when it starts: the CPUs it may use, less the
+// 1-minute load average and FFMPEG_RESERVED_CPUS (1) kept for the API,
+// between 1 and FFMPEG_MAX_THREADS (all of them). A long-form job on an idle
+// box uses every core; on a busy one each encode drops towards one thread.
+// FFMPEG_NICE (10, 0 disables) lowers its priority, FFMPEG_CPUS ("2-7")
+// pins it to a CPU list and FFMPEG_MEMORY_MB caps its address space; each
+// applies only when nice, taskset or prlimit is installed.
+type FFmpegLimits struct {
+	CPUs       int    // CPUs ffmpeg may run on
+	CPUList    string // taskset list, "" for all
+	Reserved   int
+	MaxThreads int
+	Nice       int
+	MemoryMB   int64
+	wrappers   []string // command prefix built from the above
+}
+
+var ffmpegLimits = sync.OnceValue(ffmpegLimitsFromEnv)
+
+func ffmpegLimitsFromEnv() FFmpegLimits {
+	l := FFmpegLimits{
+		CPUs:     runtime.NumCPU(),
+		CPUList:  strings.TrimSpace(os.Getenv("FFMPEG_CPUS")),
+		Reserved: 1,
+		Nice:     10,
+		MemoryMB: envInt("FFMPEG_MEMORY_MB", 0),
+	}
+	if v, err := strconv.Atoi(os.Getenv("FFMPEG_RESERVED_CPUS")); err == nil && v >= 0 {
+		l.Reserved = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("FFMPEG_NICE")); err == nil && v >= 0 && v <= 19 {
+		l.Nice = v
+	}
+	if l.CPUList != "" {
+		if n := cpuListSize(l.CPUList); n > 0 {
+			l.CPUs = n
+		} else {
+			fmt.Printf("⚠️ FFMPEG_CPUS %q ignored: not a CPU list\n", l.CPUList)
+			l.CPUList = ""
+		}
+	}
+	l.MaxThreads = int(envInt("FFMPEG_MAX_THREADS", int64(l.CPUs)))
+
+	if l.MemoryMB > 0 && hasTool("prlimit") {
+		l.wrappers = append(l.wrappers, "prlimit", fmt.Sprintf("--as=%d", l.MemoryMB<<20), "--")
+	}
+	if l.CPUList != "" && hasTool("taskset") {
+		l.wrappers = append(l.wrappers, "taskset", "-c", l.CPUList)
+	}
+	if l.Nice > 0 && hasTool("nice") {
+		l.wrappers = append(l.wrappers, "nice", "-n", strconv.Itoa(l.Nice))
+	}
+	return l
+}
+
+// threads is the thread count for an ffmpeg run starting now.
+func (l FFmpegLimits) threads() int {
+	free := float64(l.CPUs-l.Reserved) - loadAverage()
+	return max(1, min(int(free), l.MaxThreads))
+}
+
+// ffmpegCommand builds an ffmpeg run with the configured priority, affinity
+// and memory cap. The thread count goes just before the last argument, the
+// output, so it applies to the encoder.
+func ffmpegCommand(args ...string) *exec.Cmd {
+	l := ffmpegLimits()
+	n := strconv.Itoa(l.threads())
+	full := append([]string{"-filter_threads", n}, args[:len(args)-1]...)
+	full = append(full, "-threads", n, args[len(args)-1])
+	if len(l.wrappers) == 0 {
+		return exec.Command("ffmpeg", full...)
+	}
+	return exec.Command(l.wrappers[0], append(append(l.wrappers[1:], "ffmpeg"), full...)...)
+}
+
+// loadAverage is the 1-minute load average, 0 where /proc is unavailable.
+func loadAverage() float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(fields[0], 64)
+	return v
+}
+
+// cpuListSize counts the CPUs in a taskset list such as "0,2-5", 0 if it
+// doesn't parse.
+func cpuListSize(list string) int {
+	n := 0
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		a, err := strconv.Atoi(lo)
+		if err != nil {
+			return 0
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(hi); err != nil || b < a {
+				return 0
+			}
+		}
+		n += b - a + 1
+	}
+	return n
+}
+
+func hasTool(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
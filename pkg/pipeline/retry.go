@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// --- SEGMENT FALLBACKS ---
+// A segment whose encode fails is retried before it is dropped, reusing its
+// narration, with progressively safer settings: first with the media
+// re-encoded to a plain H.264 clip or JPEG scaled to the frame (which also
+// brings oversized inputs down to frame resolution), then with a still
+// image in place of a video (its first frame, else the plain card). Both
+// retries drop the effect and the chroma-key, PiP and avatar layers. Media
+// that can't be read at all goes straight to the card, see verifyAsset. The
+// fallback used is reported with the segment so quality loss isn't silent.
+const (
+	fallbackReencoded = "reencoded_media"
+	fallbackStill     = "still_image"
+)
+
+// renderWithFallbacks renders the segment and returns which fallback, if
+// any, produced it.
+func renderWithFallbacks(job *Job, title, text, mediaPath, outPath string, opts RenderOptions) (string, error) {
+	audioPath := narrationPath(outPath)
+	if !opts.Preview {
+		defer os.Remove(audioPath)
+	}
+	opts.KeepNarration = true
+	used := ""
+	if err := verifyAsset(mediaPath); err != nil {
+		fmt.Printf("⚠️ Unusable media for %s (%v), using a placeholder card\n", filepath.Base(outPath), err)
+		mediaPath, used = placeholderCard(job, title, 0, opts.VideoType, opts.Card), fallbackStill
+	}
+	if opts.BlurFaces {
+		mediaPath = blurFaces(job, mediaPath)
+	}
+	err := renderSegment(text, mediaPath, outPath, opts)
+	if err == nil {
+		return used, nil
+	}
+	if info, statErr := os.Stat(audioPath); statErr != nil || info.Size() == 0 {
+		return "", err // no narration: other media won't help
+	}
+
+	safe := opts
+	safe.Effect, safe.ChromaKeyPath, safe.PiPPath, safe.Avatar = "", "", "", ""
+	safe.BRollClips = nil
+	for _, fallback := range []string{fallbackReencoded, fallbackStill} {
+		media, prepErr := fallbackMedia(job, fallback, title, mediaPath, opts)
+		if prepErr != nil {
+			fmt.Printf("⚠️ %s fallback for %s unavailable: %v\n", fallback, filepath.Base(outPath), prepErr)
+			continue
+		}
+		fmt.Printf("🔁 Segment %s failed (%v), retrying with %s\n", filepath.Base(outPath), err, fallback)
+		if err = renderSegment(text, media, outPath, safe); err == nil {
+			return fallback, nil
+		}
+	}
+	return "", err
+}
+
+// fallbackMedia prepares the stand-in media for a fallback.
+func fallbackMedia(job *Job, fallback, title, mediaPath string, opts RenderOptions) (string, error) {
+	w, h := frameSize(opts.VideoType)
+	scale := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,setsar=1", w, h)
+	video := isVideoFile(mediaPath)
+	switch {
+	case fallback == fallbackReencoded && video:
+		dest := job.MediaPath(".mp4")
+		output, err := ffmpegCommand("-y", "-i", mediaPath, "-an", "-vf", scale+",format=yuv420p",
+			"-r", fmt.Sprint(opts.frameRate()), "-c:v", "libx264", "-preset", "ultrafast", dest).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("%v | Log: %s", err, string(output))
+		}
+		return dest, nil
+	case fallback == fallbackReencoded:
+		dest := job.MediaPath(".jpg")
+		output, err := ffmpegCommand("-y", "-i", mediaPath, "-vf", scale, "-frames:v", "1", "-q:v", "2", dest).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("%v | Log: %s", err, string(output))
+		}
+		return dest, nil
+	case video:
+		dest := job.MediaPath(".jpg")
+		if _, err := ffmpegCommand("-y", "-i", mediaPath, "-vf", scale, "-frames:v", "1", "-q:v", "2", dest).CombinedOutput(); err == nil {
+			return dest, nil
+		}
+	}
+	return placeholderCard(job, title, 0, opts.VideoType, opts.Card), nil
+}
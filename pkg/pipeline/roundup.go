@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- ROUNDUP RENDERING ---
+// Modes that build their own scenes rather than searching for media (news,
+// sports, markets, weather, reddit, quotes) share the tail of the pipeline
+// once script and scene media exist: preview, render, stitch, finish and the
+// success response.
+type roundup struct {
+	Name      string // final_<name>.mp4
+	Topic     string
+	VideoType string
+	Script    ScriptResponse
+	FactCheck []FactWarning
+	Intro     string
+	Scenes    []string
+	Outro     string
+	Base      RenderOptions
+}
+
+// renderRoundup finishes the request; step numbers the first log step.
+func renderRoundup(r *request, job *Job, step int, ru roundup) {
+	fmt.Printf("🔹 STEP %d: Rendering Segments...\n", step)
+	job.Stage("render")
+	offerPreview(r, job, ru.Script, ru.Intro, ru.Scenes, ru.Outro, ru.Base, nil)
+	segments, err := renderScript(job, ru.Script, ru.Intro, ru.Scenes, ru.Outro, ru.Base, nil)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (TTS): %v\n", err)
+		r.JSON(502, gin.H{"error": "Narration incomplete: " + err.Error(), "narration_lost": job.LostNarration()})
+		return
+	}
+	segments = withSting(segments, ru.Base)
+
+	fmt.Printf("🔹 STEP %d: Stitching Video...\n", step+1)
+	job.Stage("stitch")
+	finalVideo := job.Path("final_" + ru.Name + ".mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	offerStream(r, job, finalVideo)
+	finish := finishOptionsFromForm(r, job, ru.VideoType)
+	applyMood(job, &finish, ru.Script.Mood)
+	finish.Title, finish.Chapters = ru.Topic, segmentChapters(segments)
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Video Ready.")
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": job.URL(r.base, finalVideo), "stitch": stitch,
+		"narration_url": narrationURL(r.base, job, finish), "thumbnails": thumbnailURLs(r.base, job, finish),
+		"teaser_url": teaserURL(r.base, job, finish), "size_budget": finish.SizeBudget, "fact_check": ru.FactCheck,
+		"output": output, "segments": segmentInfo, "script": ru.Script,
+		"chapters": finish.Chapters, "chapters_text": youtubeChapters(finish.Chapters)})
+}
@@ -0,0 +1,193 @@
+package pipeline
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// --- S3 INPUT ---
+// An upload field's media can be read straight from object storage instead
+// of being sent in the request: media_2_url=s3://bucket/path/photo.jpg (the
+// same for media_intro, media_outro and every other upload field). Only the
+// buckets listed in S3_BUCKETS can be read, with S3_ACCESS_KEY_ID and
+// S3_SECRET_ACCESS_KEY (or the AWS_ variables) and an optional session
+// token. S3_ENDPOINT points at any S3-compatible service (MinIO, R2, ...),
+// which is addressed path-style; the default is AWS in S3_REGION. Objects
+// over S3_MAX_MB (1024) are refused. Downloads go into the asset library,
+// so an object is fetched once per job.
+type s3Config struct {
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Buckets      map[string]bool
+	MaxSize      int64
+}
+
+func s3ConfigFromEnv() (s3Config, error) {
+	env := func(keys ...string) string {
+		for _, k := range keys {
+			if v := os.Getenv(k); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	cfg := s3Config{
+		Region:       env("S3_REGION", "AWS_REGION"),
+		AccessKey:    env("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+		SecretKey:    env("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+		SessionToken: env("S3_SESSION_TOKEN", "AWS_SESSION_TOKEN"),
+		Buckets:      map[string]bool{},
+		MaxSize:      int64(envInt("S3_MAX_MB", 1024)) << 20,
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Endpoint = strings.TrimSuffix(env("S3_ENDPOINT"), "/")
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	for _, b := range strings.Split(os.Getenv("S3_BUCKETS"), ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			cfg.Buckets[b] = true
+		}
+	}
+	if len(cfg.Buckets) == 0 || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return cfg, fmt.Errorf("S3 input is not configured")
+	}
+	return cfg, nil
+}
+
+// parseS3URL splits s3://bucket/key.
+func parseS3URL(raw string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(raw), "s3://")
+	bucket, key, _ = strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("invalid S3 URL %q (use s3://bucket/key)", raw)
+	}
+	return bucket, key, nil
+}
+
+// fetchS3Media downloads an s3:// scene reference like an upload.
+func fetchS3Media(job *Job, ref string) (string, error) {
+	bucket, key, err := parseS3URL(ref)
+	if err != nil {
+		return "", err
+	}
+	return job.Fetch("s3:"+bucket+"/"+key, safeExt(key, ".jpg"), func(dest string) error {
+		if err := downloadS3Object(bucket, key, dest); err != nil {
+			return err
+		}
+		if err := normalizeMedia(dest); err != nil {
+			fmt.Printf("⚠️ %s: %v\n", ref, err)
+		}
+		return nil
+	})
+}
+
+// downloadS3Object fetches the object at s3://bucket/key to dest.
+func downloadS3Object(bucket, key, dest string) error {
+	cfg, err := s3ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if !cfg.Buckets[bucket] {
+		return fmt.Errorf("bucket %q is not allowed", bucket)
+	}
+	u, err := url.Parse(cfg.Endpoint + "/" + bucket + "/" + s3Escape(key))
+	if err != nil {
+		return err
+	}
+	req, _ := http.NewRequest("GET", u.String(), nil)
+	signS3(req, u, cfg, time.Now().UTC())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("s3 status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if resp.ContentLength > cfg.MaxSize {
+		return fmt.Errorf("object exceeds %d MB", cfg.MaxSize>>20)
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(resp.Body, cfg.MaxSize+1))
+	out.Close()
+	if err == nil && n > cfg.MaxSize {
+		err = fmt.Errorf("object exceeds %d MB", cfg.MaxSize>>20)
+	}
+	if err == nil {
+		if err = scanFile(dest); err != nil {
+			quarantine(dest)
+		}
+	}
+	return err
+}
+
+// signS3 adds AWS Signature Version 4 headers to an unsigned-body GET.
+func signS3(req *http.Request, u *url.URL, cfg s3Config, now time.Time) {
+	amzDate, day := now.Format("20060102T150405Z"), now.Format("20060102")
+	payload := hex.EncodeToString(sha256Sum(nil))
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payload)
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	headers := "host:" + u.Host + "\nx-amz-content-sha256:" + payload + "\nx-amz-date:" + amzDate + "\n"
+	if cfg.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", cfg.SessionToken)
+		signed += ";x-amz-security-token"
+		headers += "x-amz-security-token:" + cfg.SessionToken + "\n"
+	}
+	canonical := strings.Join([]string{"GET", u.EscapedPath(), "", headers, signed, payload}, "\n")
+	scope := day + "/" + cfg.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sha256Sum([]byte(canonical)))
+
+	k := hmacSHA256([]byte("AWS4"+cfg.SecretKey), day)
+	for _, part := range []string{cfg.Region, "s3", "aws4_request"} {
+		k = hmacSHA256(k, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(k, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKey, scope, signed, signature))
+}
+
+// s3Escape percent-encodes an object key the way SigV4 expects, keeping
+// the slashes.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', strings.IndexByte("-_.~/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
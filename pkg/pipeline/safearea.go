@@ -0,0 +1,33 @@
+package pipeline
+
+// --- PLATFORM SAFE AREAS ---
+// TikTok, Reels and Shorts draw their own UI over the video: the caption and
+// account line along the bottom, the like/comment/share column down the
+// right edge. With platform set, captions, title text, corner overlays and
+// the progress bar are kept inside the area that UI leaves clear.
+type SafeArea struct {
+	Top, Bottom, Left, Right float64 // fractions of the frame to keep clear
+}
+
+var platformSafeAreas = map[string]SafeArea{
+	"tiktok": {Top: 0.08, Bottom: 0.15, Left: 0.04, Right: 0.15},
+	"reels":  {Top: 0.10, Bottom: 0.15, Left: 0.04, Right: 0.12},
+	"shorts": {Top: 0.08, Bottom: 0.15, Left: 0.04, Right: 0.12},
+}
+
+// Margins are a safe area in pixels.
+type Margins struct {
+	Top, Bottom, Left, Right int
+}
+
+// safeMargins returns the platform's clear margins for a w×h frame; zero
+// when no platform is set.
+func safeMargins(platform string, w, h int) Margins {
+	a := platformSafeAreas[platform]
+	return Margins{
+		Top:    int(a.Top * float64(h)),
+		Bottom: int(a.Bottom * float64(h)),
+		Left:   int(a.Left * float64(w)),
+		Right:  int(a.Right * float64(w)),
+	}
+}
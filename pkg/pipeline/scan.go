@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// --- UPLOAD SCANNING ---
+// Uploads are staged outside the served output tree and scanned before they
+// are moved in. CLAMAV_ADDR (host:port or a unix socket path) uses clamd's
+// INSTREAM command; SCAN_API_URL posts the file as multipart "file" and
+// expects {"clean": bool, "threat": "..."}. With neither set uploads pass
+// straight through. Rejected or unscannable files go to QUARANTINE_DIR.
+const stagingDir = "uploads"
+
+var errUploadRejected = errors.New("upload rejected by content scan")
+
+// saveUpload stores an uploaded file at dest once it has passed the scan.
+func saveUpload(file *Upload, dest string) error {
+	os.MkdirAll(stagingDir, 0755)
+	tmp, err := os.CreateTemp(stagingDir, "upload-*"+filepath.Ext(dest))
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	staged := tmp.Name()
+	if err := file.save(staged); err != nil {
+		os.Remove(staged)
+		return err
+	}
+
+	if err := scanFile(staged); err != nil {
+		fmt.Printf("☣️ Upload %q quarantined: %v\n", file.Filename, err)
+		quarantine(staged)
+		return fmt.Errorf("%w: %v", errUploadRejected, err)
+	}
+	if err := os.Rename(staged, dest); err != nil {
+		os.Remove(staged)
+		return err
+	}
+	return nil
+}
+
+// uploadErrorStatus maps a saveUpload error to an HTTP status.
+func uploadErrorStatus(err error) int {
+	if errors.Is(err, errUploadRejected) {
+		return 422
+	}
+	return 500
+}
+
+func scanFile(path string) error {
+	if addr := os.Getenv("CLAMAV_ADDR"); addr != "" {
+		return clamdScan(addr, path)
+	}
+	if apiURL := os.Getenv("SCAN_API_URL"); apiURL != "" {
+		return apiScan(apiURL, path)
+	}
+	return nil
+}
+
+func clamdScan(addr, path string) error {
+	network := "tcp"
+	if strings.HasPrefix(addr, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("clamd unreachable: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+	buf := make([]byte, 64<<10)
+	size := make([]byte, 4)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := conn.Write(append(size, buf[:n]...)); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return err
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	result := strings.TrimRight(string(reply), "\x00\n")
+	if strings.HasSuffix(result, "OK") {
+		return nil
+	}
+	return fmt.Errorf("clamd: %s", strings.TrimPrefix(result, "stream: "))
+}
+
+func apiScan(apiURL, path string) error {
+	var res struct {
+		Clean  bool   `json:"clean"`
+		Threat string `json:"threat"`
+	}
+	auth := func(req *http.Request) {
+		if key := os.Getenv("SCAN_API_KEY"); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+	if err := postMultipartFile(apiURL, "file", path, auth, &res); err != nil {
+		return fmt.Errorf("scan API: %v", err)
+	}
+	if !res.Clean {
+		return fmt.Errorf("scan API flagged file: %s", res.Threat)
+	}
+	return nil
+}
+
+func quarantine(path string) {
+	dir := os.Getenv("QUARANTINE_DIR")
+	if dir == "" {
+		dir = "quarantine"
+	}
+	os.MkdirAll(dir, 0700)
+	dest := filepath.Join(dir, time.Now().Format("20060102-150405-")+filepath.Base(path))
+	if err := os.Rename(path, dest); err != nil {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,308 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- SCRIPT EDITING ---
+// A rendered job's script can be edited item by item instead of resending
+// the whole request. The script is the job's render spec grouped into
+// items: a titled segment (the intro, each scene, the outro) and the beats
+// that follow it. GET /v1/jobs/:id/script lists them; reordering,
+// inserting and deleting items edits a draft kept next to spec.json, and
+// POST /v1/jobs/:id/rerender renders the draft in a new job, as
+// /v1/render-spec would. Segments the edit left alone are reused from the
+// job as long as it kept them (keep_segments=true), so only inserted items
+// are narrated and encoded again. Item 0 is normally the intro.
+type EditableItem struct {
+	Index    int    `json:"index"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	Media    string `json:"media"`
+	Segments int    `json:"segments"`
+}
+
+// draftMu serializes draft edits.
+var draftMu sync.Mutex
+
+// scriptItems returns the [start, end) segment range of each item.
+func scriptItems(segments []SegmentSpec) [][2]int {
+	var items [][2]int
+	for i, s := range segments {
+		if i == 0 || s.Title != "" {
+			items = append(items, [2]int{i, i + 1})
+		} else {
+			items[len(items)-1][1] = i + 1
+		}
+	}
+	return items
+}
+
+// loadDraft returns the job's draft, or its spec when it has none yet.
+func loadDraft(job *Job) (RenderSpec, bool, error) {
+	var spec RenderSpec
+	edited := true
+	data, err := os.ReadFile(job.Path("draft.json"))
+	if err != nil {
+		edited = false
+		if data, err = os.ReadFile(job.Path("spec.json")); err != nil {
+			return spec, false, fmt.Errorf("no render spec for this job (yet)")
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return spec, false, fmt.Errorf("unreadable render spec")
+	}
+	return spec, edited, nil
+}
+
+func saveDraft(job *Job, spec RenderSpec) {
+	data, _ := json.MarshalIndent(spec, "", "  ")
+	os.WriteFile(job.Path("draft.json"), data, 0644)
+}
+
+// openScript opens a finished job's draft for the script endpoints,
+// answering the request itself on failure.
+func openScript(c *gin.Context) (*Job, RenderSpec, bool) {
+	job, result, err := openJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return nil, RenderSpec{}, false
+	}
+	if result == nil {
+		c.JSON(409, gin.H{"error": "Job is still running"})
+		return nil, RenderSpec{}, false
+	}
+	spec, _, err := loadDraft(job)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return nil, RenderSpec{}, false
+	}
+	return job, spec, true
+}
+
+// editScript applies edit to the job's draft and answers with the result.
+func editScript(c *gin.Context, edit func(spec *RenderSpec, items [][2]int) error) {
+	draftMu.Lock()
+	defer draftMu.Unlock()
+	job, spec, ok := openScript(c)
+	if !ok {
+		return
+	}
+	if err := edit(&spec, scriptItems(spec.Segments)); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	saveDraft(job, spec)
+	respondScript(c, job, spec, true)
+}
+
+func respondScript(c *gin.Context, job *Job, spec RenderSpec, edited bool) {
+	var items []EditableItem
+	for i, r := range scriptItems(spec.Segments) {
+		item := EditableItem{Index: i, Title: spec.Segments[r[0]].Title, Media: spec.Segments[r[0]].Media, Segments: r[1] - r[0]}
+		var text []string
+		for _, s := range spec.Segments[r[0]:r[1]] {
+			text = append(text, s.Text)
+		}
+		item.Text = strings.Join(text, " ")
+		items = append(items, item)
+	}
+	c.JSON(200, gin.H{"job_id": job.ID, "edited": edited, "items": items,
+		"rerender_url": baseURL(c) + "/v1/jobs/" + job.ID + "/rerender"})
+}
+
+// GET /v1/jobs/:id/script
+func handleGetScript(c *gin.Context) {
+	job, _, err := openJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	spec, edited, err := loadDraft(job)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	respondScript(c, job, spec, edited)
+}
+
+// POST /v1/jobs/:id/script/reorder (JSON: {"order": [0, 2, 1, 3]}, every
+// item index once, in the new order)
+func handleReorderScript(c *gin.Context) {
+	var body struct {
+		Order []int `json:"order"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	editScript(c, func(spec *RenderSpec, items [][2]int) error {
+		if len(body.Order) != len(items) {
+			return fmt.Errorf("order must list all %d items", len(items))
+		}
+		seen := make([]bool, len(items))
+		var segments []SegmentSpec
+		for _, i := range body.Order {
+			if i < 0 || i >= len(items) || seen[i] {
+				return fmt.Errorf("order must list every item index once")
+			}
+			seen[i] = true
+			segments = append(segments, spec.Segments[items[i][0]:items[i][1]]...)
+		}
+		spec.Segments = segments
+		return nil
+	})
+}
+
+// POST /v1/jobs/:id/script/items (JSON: {"at": 2, "title": "...", "text":
+// "...", "media": "assets/..."}); at defaults to before the last item and
+// media to the neighbouring item's. The new item takes that item's render
+// options and is split into beats like a generated one.
+func handleInsertScriptItem(c *gin.Context) {
+	var body struct {
+		At    *int   `json:"at"`
+		Title string `json:"title"`
+		Text  string `json:"text"`
+		Media string `json:"media"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	body.Title, body.Text = strings.TrimSpace(body.Title), strings.TrimSpace(body.Text)
+	if body.Title == "" || body.Text == "" {
+		c.JSON(400, gin.H{"error": "title and text are required"})
+		return
+	}
+	if r := httpRequest(c); !checkPolicy(r, body.Title) || !checkPolicy(r, body.Text) {
+		return
+	}
+	if body.Media != "" {
+		if _, err := resolveSpecRef(body.Media, keyOwner(c.GetHeader("X-Api-Key"))); err != nil {
+			c.JSON(400, gin.H{"error": "media: " + err.Error()})
+			return
+		}
+	}
+	editScript(c, func(spec *RenderSpec, items [][2]int) error {
+		if len(items) == 0 {
+			return fmt.Errorf("the script has no items")
+		}
+		if len(items) >= limitsFromEnv().MaxScenes+2 {
+			return fmt.Errorf("too many items (max %d scenes)", limitsFromEnv().MaxScenes)
+		}
+		at := max(len(items)-1, 0)
+		if body.At != nil {
+			at = *body.At
+		}
+		if at < 0 || at > len(items) {
+			return fmt.Errorf("at must be between 0 and %d", len(items))
+		}
+		like := spec.Segments[items[min(at, len(items)-1)][0]]
+		if body.Media != "" && body.Media != like.Media {
+			like.Media, like.Citation = body.Media, ""
+		}
+		inserted := itemSegments(like, body.Title, body.Text, spec.Type)
+		pos := len(spec.Segments)
+		if at < len(items) {
+			pos = items[at][0]
+		}
+		spec.Segments = append(spec.Segments[:pos], append(inserted, spec.Segments[pos:]...)...)
+		return nil
+	})
+}
+
+// itemSegments is a new item's segments: text split into beats, each
+// rendered like the first segment of like.
+func itemSegments(like SegmentSpec, title, text, videoType string) []SegmentSpec {
+	like.Duration, like.Rendered, like.TimerOffset = 0, "", 0
+	_, maxWords := wordRange(videoType)
+	var segments []SegmentSpec
+	for i, beat := range splitBeats(text, maxWords) {
+		s := like
+		s.Title, s.Text = title, beat
+		if i > 0 {
+			s.Title, s.TitleCard, s.Transition = "", 0, "cut"
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// DELETE /v1/jobs/:id/script/items/:index
+func handleDeleteScriptItem(c *gin.Context) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid item index"})
+		return
+	}
+	editScript(c, func(spec *RenderSpec, items [][2]int) error {
+		if index < 0 || index >= len(items) {
+			return fmt.Errorf("no item %d (the script has %d)", index, len(items))
+		}
+		if len(items) == 1 {
+			return fmt.Errorf("the last item cannot be deleted")
+		}
+		r := items[index]
+		spec.Segments = append(spec.Segments[:r[0]], spec.Segments[r[1]:]...)
+		return nil
+	})
+}
+
+// POST /v1/jobs/:id/rerender renders the job's draft in a new job.
+func handleRerender(c *gin.Context) {
+	draftMu.Lock()
+	job, spec, ok := openScript(c)
+	draftMu.Unlock()
+	if !ok {
+		return
+	}
+	spec.JobID = job.ID
+	fmt.Printf("✏️ Re-rendering the edited script of job %s\n", job.ID)
+	renderSpec(httpRequest(c), spec)
+}
+
+// renderedSegments maps the parent's kept segment files to the spec they
+// were rendered from, when spec renders at the same size and frame rate.
+// Kept segments are in the parent's workspace, never the asset library.
+func renderedSegments(parent *Job, spec RenderSpec) map[string]SegmentSpec {
+	data, err := os.ReadFile(parent.Path("spec.json"))
+	var exported RenderSpec
+	if err != nil || json.Unmarshal(data, &exported) != nil {
+		return nil
+	}
+	if exported.Type != spec.Type || exported.FPS != spec.FPS {
+		return nil
+	}
+	rendered := map[string]SegmentSpec{}
+	for _, s := range exported.Segments {
+		if _, err := resolveSpecRef(s.Rendered, ""); err == nil {
+			s.Duration = 0
+			rendered[s.Rendered] = s
+		}
+	}
+	return rendered
+}
+
+// reuseRendered links the parent's render of s to segPath when s is
+// exactly the segment it was rendered from.
+func reuseRendered(rendered map[string]SegmentSpec, s SegmentSpec, segPath string) bool {
+	want, ok := rendered[s.Rendered]
+	s.Duration = 0
+	if !ok || s.Rendered == "" || !reflect.DeepEqual(want, s) {
+		return false
+	}
+	file, _ := resolveSpecRef(s.Rendered, "")
+	if err := os.Link(file, segPath); err != nil && CopyFile(file, segPath) != nil {
+		return false
+	}
+	fmt.Printf("♻️ Reused %s for %s\n", s.Rendered, segPath)
+	return true
+}
@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// --- SEEDS ---
+// seed=<n> fixes the random choices a render makes, so a request can be
+// repeated exactly for debugging or A/B comparisons: the LLM sampling seed
+// (best effort on Groq's side), the Pollinations image seed and the mood
+// music pick. A request without one gets a random seed, returned as "seed"
+// with the result.
+const maxSeed = 1<<31 - 1
+
+// seedFromForm parses seed, picking a random one when it is unset or invalid.
+func seedFromForm(v string) int {
+	v = strings.TrimSpace(v)
+	if v != "" {
+		if seed, err := strconv.Atoi(v); err == nil && seed >= 0 && seed <= maxSeed {
+			return seed
+		}
+		fmt.Printf("⚠️ Seed ignored: bad seed %q\n", v)
+	}
+	return rand.IntN(maxSeed)
+}
+
+// seededIndex picks an index below n from seed. Each purpose draws its own
+// stream, so choices don't shift when others are added or run in a
+// different order.
+func seededIndex(seed int, purpose string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(purpose))
+	return rand.New(rand.NewPCG(uint64(seed), h.Sum64())).IntN(n)
+}
@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- SERIES MEMORY ---
+// Requests with series_id=<id> are episodes of one series. Each successful
+// episode is remembered (topic, intro, item titles) in SERIES_DIR (default
+// data/series), and the script prompt of the next episode lists what
+// earlier ones covered and how they opened, so items, facts and intros
+// aren't repeated, plus the series notes: running gags and terminology to
+// keep consistent, set with POST /series/:id (name, notes). GET
+// /series/:id returns the memory. Only the last maxSeriesContext episodes
+// go into prompts.
+type Series struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name,omitempty"`
+	Notes    string          `json:"notes,omitempty"`
+	Episodes []SeriesEpisode `json:"episodes"`
+}
+
+type SeriesEpisode struct {
+	JobID   string   `json:"job_id"`
+	Topic   string   `json:"topic,omitempty"`
+	Intro   string   `json:"intro,omitempty"`
+	Items   []string `json:"items,omitempty"`
+	Created string   `json:"created"`
+}
+
+const (
+	maxSeriesContext = 10
+	maxSeriesNotes   = 2000
+)
+
+// seriesMu serializes updates to every series file.
+var seriesMu sync.Mutex
+
+func seriesDir() string {
+	if dir := os.Getenv("SERIES_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "series")
+}
+
+// loadSeries returns the series, empty if it has no episodes yet.
+func loadSeries(id string) (*Series, error) {
+	if !slugPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid series_id %q (lowercase letters, digits, - or _)", id)
+	}
+	s := &Series{ID: id, Episodes: []SeriesEpisode{}}
+	data, err := os.ReadFile(filepath.Join(seriesDir(), id+".json"))
+	if err == nil {
+		err = json.Unmarshal(data, s)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	return s, err
+}
+
+func (s *Series) save() error {
+	os.MkdirAll(seriesDir(), 0755)
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return os.WriteFile(filepath.Join(seriesDir(), s.ID+".json"), data, 0644)
+}
+
+// promptLines tells the model what the series has already done.
+func (s *Series) promptLines() string {
+	if s == nil {
+		return ""
+	}
+	name := s.ID
+	if s.Name != "" {
+		name = s.Name
+	}
+	lines := fmt.Sprintf("\n    This is episode %d of the series %q.", len(s.Episodes)+1, name)
+	if s.Notes != "" {
+		lines += "\n    Series notes (running gags, terminology) to keep consistent: " + s.Notes
+	}
+	recent := s.Episodes[max(0, len(s.Episodes)-maxSeriesContext):]
+	var covered, intros []string
+	for _, e := range recent {
+		covered = append(covered, e.Items...)
+		if e.Intro != "" {
+			intros = append(intros, fmt.Sprintf("%q", e.Intro))
+		}
+	}
+	if len(covered) > 0 {
+		lines += "\n    Earlier episodes already covered: " + strings.Join(covered, "; ") + ". Don't repeat their facts; refer back to them instead."
+	}
+	if len(intros) > 0 {
+		lines += "\n    Earlier episodes opened with: " + strings.Join(intros[max(0, len(intros)-3):], " / ") + ". Open differently."
+	}
+	return lines
+}
+
+// scriptReady emits job.script_ready and keeps the script for the series.
+func (j *Job) scriptReady(script ScriptResponse) {
+	j.mu.Lock()
+	j.script = &script
+	j.mu.Unlock()
+	j.Emit("job.script_ready", script)
+}
+
+// addEpisode records the job's script as the series' next episode.
+func (j *Job) addEpisode(seriesID, topic string) error {
+	j.mu.Lock()
+	script := j.script
+	j.mu.Unlock()
+	if script == nil {
+		return nil
+	}
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+	s, err := loadSeries(seriesID)
+	if err != nil {
+		return err
+	}
+	e := SeriesEpisode{JobID: j.ID, Topic: topic, Intro: script.Intro, Created: time.Now().UTC().Format(time.RFC3339)}
+	for _, item := range script.Items {
+		e.Items = append(e.Items, item.Title)
+	}
+	s.Episodes = append(s.Episodes, e)
+	fmt.Printf("📚 Job %s saved as episode %d of series %s\n", j.ID, len(s.Episodes), s.ID)
+	return s.save()
+}
+
+// POST /series/:id (form: name, notes)
+func handleUpdateSeries(c *gin.Context) {
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+	s, err := loadSeries(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if name, ok := c.GetPostForm("name"); ok {
+		s.Name = strings.TrimSpace(name)
+	}
+	if notes, ok := c.GetPostForm("notes"); ok {
+		if len(notes) > maxSeriesNotes {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("notes are limited to %d characters", maxSeriesNotes)})
+			return
+		}
+		s.Notes = strings.TrimSpace(notes)
+	}
+	if err := s.save(); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save series"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "series": s})
+}
+
+// GET /series/:id
+func handleGetSeries(c *gin.Context) {
+	s, err := loadSeries(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"series": s})
+}
@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- SIZE BUDGET ---
+// max_file_size=287 (megabytes, 10^6 bytes, so platform limits stay safe)
+// caps the final video. A video over budget is re-encoded in two passes at
+// the video bitrate that fills the budget after the audio track, which is
+// copied; if the muxed file still overshoots, one more pass runs at a
+// proportionally lower rate. The outcome is reported as "size_budget".
+const (
+	sizeHeadroom    = 0.97 // container overhead and rate-control slack
+	minVideoBitrate = 150_000
+)
+
+type SizeBudget struct {
+	MaxSize      int64   `json:"max_size"`
+	Size         int64   `json:"size"`
+	Fits         bool    `json:"fits"`
+	Method       string  `json:"method"` // "none" (already fit) or "two-pass"
+	VideoBitrate int64   `json:"video_bitrate,omitempty"`
+	OriginalSize int64   `json:"original_size"`
+	Quality      float64 `json:"quality,omitempty"` // video bitrate against the original's
+	Error        string  `json:"error,omitempty"`
+}
+
+// sizeBudgetFromForm parses max_file_size; nil when unset or invalid.
+func sizeBudgetFromForm(v string) *SizeBudget {
+	v = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(v)), "MB")
+	if v == "" {
+		return nil
+	}
+	mb, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || mb <= 0 {
+		fmt.Printf("⚠️ Size budget skipped: bad max_file_size %q\n", v)
+		return nil
+	}
+	return &SizeBudget{MaxSize: int64(mb * 1e6)}
+}
+
+// fitFileSize brings the video at path within b.MaxSize in place and
+// records how in b.
+func fitFileSize(path string, b *SizeBudget) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b.OriginalSize, b.Size, b.Method = st.Size(), st.Size(), "none"
+	if b.Size <= b.MaxSize {
+		b.Fits = true
+		return nil
+	}
+	duration, err := probeDuration(path)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("size budget: cannot read duration")
+	}
+	original := videoBitrate(path, duration)
+	rate := int64(float64(b.MaxSize)*8*sizeHeadroom/duration) - audioBitrate(path)
+	b.Method = "two-pass"
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if rate < minVideoBitrate {
+			return fmt.Errorf("size budget: %d MB is too small for %.0fs of video", b.MaxSize/1e6, duration)
+		}
+		fmt.Printf("🗜️ Re-encoding to %d kbps to fit %d MB (was %.1f MB)\n", rate/1000, b.MaxSize/1e6, float64(b.Size)/1e6)
+		if err := encodeTwoPass(path, rate); err != nil {
+			return err
+		}
+		st, _ := os.Stat(path)
+		b.Size, b.VideoBitrate = st.Size(), rate
+		if original > 0 {
+			b.Quality = min(float64(rate)/float64(original), 1)
+		}
+		if b.Fits = b.Size <= b.MaxSize; b.Fits {
+			return nil
+		}
+		rate = int64(float64(rate) * float64(b.MaxSize) / float64(b.Size) * sizeHeadroom)
+	}
+	return fmt.Errorf("size budget: %.1f MB after re-encoding, over %d MB", float64(b.Size)/1e6, b.MaxSize/1e6)
+}
+
+// encodeTwoPass re-encodes the video stream of path at rate bits/s, copying
+// the audio.
+func encodeTwoPass(path string, rate int64) error {
+	tmp := strings.TrimSuffix(path, ".mp4") + "_sized.mp4"
+	logPrefix := filepath.Join(filepath.Dir(path), "x264_2pass")
+	defer func() {
+		matches, _ := filepath.Glob(logPrefix + "*")
+		for _, m := range matches {
+			os.Remove(m)
+		}
+	}()
+	bitrate := strconv.FormatInt(rate, 10)
+	common := []string{"-c:v", "libx264", "-preset", "medium", "-b:v", bitrate, "-pix_fmt", "yuv420p", "-passlogfile", logPrefix}
+
+	pass1 := append([]string{"-y", "-i", path}, common...)
+	pass1 = append(pass1, "-pass", "1", "-an", "-f", "mp4", os.DevNull)
+	if output, err := ffmpegCommand(pass1...).CombinedOutput(); err != nil {
+		return fmt.Errorf("size budget pass 1: %v | Log: %s", err, string(output))
+	}
+	pass2 := append([]string{"-y", "-i", path}, common...)
+	pass2 = append(pass2, "-pass", "2", "-c:a", "copy", "-movflags", "+faststart", tmp)
+	if output, err := ffmpegCommand(pass2...).CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("size budget pass 2: %v | Log: %s", err, string(output))
+	}
+	return os.Rename(tmp, path)
+}
+
+// audioBitrate is the bit rate of the first audio stream, 128k if unknown.
+func audioBitrate(path string) int64 {
+	if v := probeStreamBitrate(path, "a"); v > 0 {
+		return v
+	}
+	return 128_000
+}
+
+// videoBitrate is the first video stream's bit rate, estimated from the file
+// size less the audio when the stream doesn't say.
+func videoBitrate(path string, duration float64) int64 {
+	if v := probeStreamBitrate(path, "v"); v > 0 {
+		return v
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return int64(float64(st.Size())*8/duration) - audioBitrate(path)
+}
+
+func probeStreamBitrate(path, stream string) int64 {
+	out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", stream+":0", "-show_entries", "stream=bit_rate", "-of", "csv=p=0", path).Output()
+	if err != nil {
+		return 0
+	}
+	v, _ := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	return v
+}
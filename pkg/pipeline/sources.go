@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// --- SOURCES ---
+// Media and facts taken from TMDB, Pexels, Unsplash, Wikipedia or a fetched
+// article are recorded on the job and listed as "sources" with its result.
+// citations=true also draws a small attribution in the top-left corner,
+// inside the platform's safe area and clear of captions, of each scene whose
+// media came from one of them, for channels that credit what they show.
+type Source struct {
+	Provider  string `json:"provider"` // TMDB, Pexels, Unsplash, Wikipedia or the article's site
+	Title     string `json:"title,omitempty"`
+	Author    string `json:"author,omitempty"`
+	URL       string `json:"url,omitempty"`
+	AuthorURL string `json:"author_url,omitempty"`
+
+	clip bool // a video clip rather than a photo
+}
+
+// attribution is the on-screen credit line.
+func (s Source) attribution() string {
+	if s.Author != "" && s.Provider == "Unsplash" {
+		return fmt.Sprintf("Photo by %s on Unsplash", s.Author)
+	}
+	if s.Author != "" && s.clip {
+		return fmt.Sprintf("Clip: %s / %s", s.Author, s.Provider)
+	}
+	if s.Author != "" {
+		return fmt.Sprintf("Photo: %s / %s", s.Author, s.Provider)
+	}
+	return "Source: " + s.Provider
+}
+
+// articleSource credits the site an article was fetched from.
+func articleSource(pageURL, title string) Source {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return Source{}
+	}
+	return Source{Provider: strings.TrimPrefix(u.Hostname(), "www."), Title: title, URL: pageURL}
+}
+
+// AddSource records s, crediting the scene media at path when path is set.
+// A source already listed by URL is kept once.
+func (j *Job) AddSource(path string, s Source) {
+	if s.Provider == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if path != "" {
+		if j.credits == nil {
+			j.credits = map[string]Source{}
+		}
+		j.credits[path] = s
+		j.appendJSONLine("licenses.jsonl", mediaUse{Media: mediaRef(path), Source: &s})
+	}
+	for _, known := range j.sources {
+		if known == s || (s.URL != "" && known.URL == s.URL) {
+			return
+		}
+	}
+	j.sources = append(j.sources, s)
+}
+
+func (j *Job) Sources() []Source {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Source(nil), j.sources...)
+}
+
+// credit returns the source of the scene media at path, if any.
+func (j *Job) credit(path string) (Source, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	s, ok := j.credits[path]
+	return s, ok
+}
+
+// citationFilter draws text small and semi-opaque in the top-left corner.
+// The text goes through textPath so credits need no escaping.
+func citationFilter(text, textPath string, w, h int, safe Margins) (string, error) {
+	if err := os.WriteFile(textPath, []byte(text), 0644); err != nil {
+		return "", err
+	}
+	size := max(min(w, h)/45, 14)
+	return fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=white@0.8:box=1:boxcolor=black@0.35:boxborderw=%d:x=%d:y=%d",
+		escapeFilterArg(fontForText(text)), escapeFilterArg(textPath), size, size/3, safe.Left+w/40, safe.Top+h/40), nil
+}
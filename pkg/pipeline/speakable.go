@@ -0,0 +1,276 @@
+package pipeline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- SPEAKABLE TEXT ---
+// Simple TTS voices read "$3.5M" as "dollar three point five em" and stop
+// for a sentence break after "Dr.", so English narration is rewritten the
+// way a presenter would say it just before TTS (after the pronunciation
+// lexicon, so a respelling wins): abbreviations are expanded, and money,
+// percentages, ordinals, dates, times, years, decades and numbers become
+// words. "2024" is read as a year, any other number in full. Like the
+// lexicon it only changes what is spoken, never captions or specs. Other
+// languages are left to their voice.
+var (
+	abbreviations = []struct{ short, long string }{
+		{"e.g.", "for example"}, {"i.e.", "that is"}, {"etc.", "et cetera"}, {"vs.", "versus"},
+		{"approx.", "approximately"}, {"a.m.", "A M"}, {"p.m.", "P M"},
+		{"Dr.", "Doctor"}, {"Mr.", "Mister"}, {"Mrs.", "Missus"}, {"Ms.", "Miz"},
+		{"Prof.", "Professor"}, {"Jr.", "Junior"}, {"Sr.", "Senior"}, {"No.", "number"},
+	}
+	// Titles are followed by a name, never the end of a sentence.
+	titleAbbreviations = map[string]bool{"Dr.": true, "Mr.": true, "Mrs.": true, "Ms.": true, "Prof.": true, "No.": true}
+
+	currencyNames = map[string][2]string{"$": {"dollar", "dollars"}, "£": {"pound", "pounds"}, "€": {"euro", "euros"}, "₹": {"rupee", "rupees"}, "¥": {"yen", "yen"}}
+	magnitudes    = map[string]string{"K": "thousand", "M": "million", "B": "billion", "bn": "billion", "T": "trillion"}
+	monthNames    = map[string]string{
+		"jan": "January", "feb": "February", "mar": "March", "apr": "April", "may": "May", "jun": "June",
+		"jul": "July", "aug": "August", "sep": "September", "sept": "September", "oct": "October", "nov": "November", "dec": "December",
+	}
+
+	currencyPattern = regexp.MustCompile(`([$£€₹¥])\s?(\d[\d,]*(?:\.\d+)?)(?:\s?(K|M|B|bn|T|thousand|million|billion|trillion|lakh|crore)\b)?`)
+	percentPattern  = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s?%`)
+	datePattern     = regexp.MustCompile(`\b(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sept?|Oct|Nov|Dec)[a-z]*\.? (\d{1,2})(?:st|nd|rd|th)?\b`)
+	ordinalPattern  = regexp.MustCompile(`\b(\d+)(?:st|nd|rd|th)\b`)
+	timePattern     = regexp.MustCompile(`\b(\d{1,2}):(\d{2})\b`)
+	rangePattern    = regexp.MustCompile(`(\d)\s?[-–]\s?(\d)`)
+	decadePattern   = regexp.MustCompile(`\b(1[1-9]|20)(\d0)'?s\b`)
+	yearPattern     = regexp.MustCompile(`\b(1[1-9]|20)\d\d\b`)
+	numberPattern   = regexp.MustCompile(`\b\d{1,3}(?:,\d{3})+(?:\.\d+)?\b|\b\d+(?:\.\d+)*\b`)
+
+	smallNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tensNames  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scaleNames = []string{"", "thousand", "million", "billion", "trillion", "quadrillion"}
+)
+
+// speakable rewrites English narration into words a voice reads naturally.
+func speakable(text, locale string) string {
+	if lang, _, _ := strings.Cut(locale, "-"); lang != "" && lang != "en" {
+		return text
+	}
+	text = expandAbbreviations(text)
+	text = strings.ReplaceAll(text, " & ", " and ")
+	text = currencyPattern.ReplaceAllStringFunc(text, func(m string) string {
+		p := currencyPattern.FindStringSubmatch(m)
+		names, amount, scale := currencyNames[p[1]], strings.ReplaceAll(p[2], ",", ""), p[3]
+		if long, ok := magnitudes[scale]; ok {
+			scale = long
+		}
+		if scale != "" {
+			return decimalWords(amount) + " " + strings.ToLower(scale) + " " + names[1]
+		}
+		whole, cents, _ := strings.Cut(amount, ".")
+		n, _ := strconv.ParseInt(whole, 10, 64)
+		unit := names[1]
+		if n == 1 {
+			unit = names[0]
+		}
+		spoken := numberWords(n) + " " + unit
+		if c, err := strconv.Atoi(cents); err == nil && len(cents) == 2 && c > 0 && p[1] != "¥" {
+			spoken += " and " + numberWords(int64(c)) + " cents"
+			if p[1] == "£" {
+				spoken = strings.TrimSuffix(spoken, "cents") + "pence"
+			}
+		}
+		return spoken
+	})
+	text = percentPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return decimalWords(strings.ReplaceAll(percentPattern.FindStringSubmatch(m)[1], ",", "")) + " percent"
+	})
+	text = datePattern.ReplaceAllStringFunc(text, func(m string) string {
+		p := datePattern.FindStringSubmatch(m)
+		day, _ := strconv.ParseInt(p[2], 10, 64)
+		return monthNames[strings.ToLower(p[1])] + " " + ordinalWords(day)
+	})
+	text = ordinalPattern.ReplaceAllStringFunc(text, func(m string) string {
+		n, _ := strconv.ParseInt(ordinalPattern.FindStringSubmatch(m)[1], 10, 64)
+		return ordinalWords(n)
+	})
+	text = timePattern.ReplaceAllStringFunc(text, func(m string) string {
+		p := timePattern.FindStringSubmatch(m)
+		h, _ := strconv.ParseInt(p[1], 10, 64)
+		min, _ := strconv.ParseInt(p[2], 10, 64)
+		switch {
+		case min == 0:
+			return numberWords(h) + " o'clock"
+		case min < 10:
+			return numberWords(h) + " oh " + numberWords(min)
+		}
+		return numberWords(h) + " " + numberWords(min)
+	})
+	text = rangePattern.ReplaceAllString(text, "$1 to $2")
+	text = decadePattern.ReplaceAllStringFunc(text, func(m string) string {
+		y, _ := strconv.ParseInt(strings.TrimRight(m, "'s"), 10, 64)
+		words := yearWords(y)
+		if strings.HasSuffix(words, "y") {
+			return strings.TrimSuffix(words, "y") + "ies"
+		}
+		return words + "s"
+	})
+	text = yearPattern.ReplaceAllStringFunc(text, func(m string) string {
+		y, _ := strconv.ParseInt(m, 10, 64)
+		return yearWords(y)
+	})
+	return replaceNumbers(text)
+}
+
+// replaceNumbers spells out the numbers left, skipping ones that are part
+// of a word or code such as "MP3" or "v1.2".
+func replaceNumbers(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range numberPattern.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 && (isASCIILetter(text[loc[0]-1]) || text[loc[0]-1] == '.') ||
+			loc[1] < len(text) && isASCIILetter(text[loc[1]]) {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(numberText(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// numberText reads "3.14" as a decimal and "1.2.3" part by part.
+func numberText(m string) string {
+	parts := strings.Split(strings.ReplaceAll(m, ",", ""), ".")
+	if len(parts) <= 2 {
+		return decimalWords(strings.Join(parts, "."))
+	}
+	for i, part := range parts {
+		n, _ := strconv.ParseInt(part, 10, 64)
+		parts[i] = numberWords(n)
+	}
+	return strings.Join(parts, " point ")
+}
+
+// expandAbbreviations spells out abbreviations, keeping the full stop of
+// one that ends a sentence.
+func expandAbbreviations(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		for _, a := range abbreviations {
+			long := a.long
+			rest, ok := strings.CutPrefix(w, a.short)
+			if !ok && !isUpperStart(a.short) {
+				// "E.g." opening a sentence
+				if rest, ok = strings.CutPrefix(w, strings.ToUpper(a.short[:1])+a.short[1:]); ok {
+					long = strings.ToUpper(long[:1]) + long[1:]
+				}
+			}
+			if !ok {
+				continue
+			}
+			if a.short == "No." && (i+1 == len(words) || words[i+1][0] < '0' || words[i+1][0] > '9') {
+				continue // "No." only before a number
+			}
+			if rest == "" && !titleAbbreviations[a.short] && (i+1 == len(words) || isUpperStart(words[i+1])) {
+				long += "."
+			}
+			words[i] = long + rest
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func isUpperStart(w string) bool {
+	return w != "" && w[0] >= 'A' && w[0] <= 'Z'
+}
+
+// numberWords spells n out, e.g. 1205 is "one thousand two hundred five".
+func numberWords(n int64) string {
+	if n < 0 {
+		return "minus " + numberWords(-n)
+	}
+	if n < 1000 {
+		return hundredsWords(int(n))
+	}
+	var groups []string
+	for scale := 0; n > 0 && scale < len(scaleNames); scale++ {
+		if g := int(n % 1000); g > 0 {
+			words := hundredsWords(g)
+			if scaleNames[scale] != "" {
+				words += " " + scaleNames[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		n /= 1000
+	}
+	return strings.Join(groups, " ")
+}
+
+func hundredsWords(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, smallNumbers[n/100]+" hundred")
+		n %= 100
+		if n == 0 {
+			return words[0]
+		}
+	}
+	switch {
+	case n < 20:
+		words = append(words, smallNumbers[n])
+	case n%10 == 0:
+		words = append(words, tensNames[n/10])
+	default:
+		words = append(words, tensNames[n/10]+"-"+smallNumbers[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// decimalWords reads "3.14" as "three point one four".
+func decimalWords(s string) string {
+	whole, frac, _ := strings.Cut(s, ".")
+	n, _ := strconv.ParseInt(whole, 10, 64)
+	words := numberWords(n)
+	if frac != "" {
+		digits := make([]string, len(frac))
+		for i, d := range frac {
+			digits[i] = smallNumbers[d-'0']
+		}
+		words += " point " + strings.Join(digits, " ")
+	}
+	return words
+}
+
+// ordinalWords reads 21 as "twenty-first".
+func ordinalWords(n int64) string {
+	words := numberWords(n)
+	cut := strings.LastIndexAny(words, " -") + 1
+	last := words[cut:]
+	irregular := map[string]string{"one": "first", "two": "second", "three": "third", "five": "fifth", "eight": "eighth", "nine": "ninth", "twelve": "twelfth"}
+	switch {
+	case irregular[last] != "":
+		last = irregular[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	return words[:cut] + last
+}
+
+// yearWords reads 1999 as "nineteen ninety-nine" and 2005 as "two thousand five".
+func yearWords(y int64) string {
+	switch {
+	case y >= 2000 && y%1000 < 10:
+		return numberWords(y)
+	case y%100 == 0:
+		return numberWords(y/100) + " hundred"
+	case y%100 < 10:
+		return numberWords(y/100) + " oh " + numberWords(y%100)
+	}
+	return numberWords(y/100) + " " + numberWords(y%100)
+}
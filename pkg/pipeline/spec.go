@@ -0,0 +1,384 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- RENDER SPECS ---
+// Every render saves its resolved specification as spec.json: each segment
+// in order with its narration text, media, measured duration and every
+// render option it used, plus the sting. GET /v1/jobs/:id/spec returns it,
+// and POST /v1/render-spec renders an edited copy in a new job without any
+// script generation or media search, so a render can be reproduced or
+// fine-tuned field by field. Files are referenced as "jobs/<id>/<file>" (a
+// job workspace) or "assets/<file>" (the caller's own assets in the
+// library); nothing else can be read. Provider keys are never exported: send the headers again.
+// Segments left exactly as exported reuse their "rendered" file from the
+// source job when it is still there (see keep_segments).
+const renderSpecVersion = 1
+
+type RenderSpec struct {
+	Version   int           `json:"version"`
+	JobID     string        `json:"job_id,omitempty"` // the job it was exported from
+	Type      string        `json:"type"`
+	FPS       int           `json:"fps,omitempty"`
+	Sting     string        `json:"sting,omitempty"` // file reference or "template"
+	BrandName string        `json:"brand_name,omitempty"`
+	Title     string        `json:"title,omitempty"`     // metadata title, import only
+	Music     string        `json:"music,omitempty"`     // music bed file reference, import only
+	Ambience  string        `json:"ambience,omitempty"`  // see ambienceBeds, import only
+	Mastering bool          `json:"mastering,omitempty"` // see mastering.go, import only
+	Segments  []SegmentSpec `json:"segments"`
+}
+
+type SegmentSpec struct {
+	Title     string  `json:"title,omitempty"` // chapter; empty continues the previous one
+	Text      string  `json:"text"`
+	Media     string  `json:"media"`
+	Duration  float64 `json:"duration,omitempty"`   // measured at export, ignored on import
+	Rendered  string  `json:"rendered,omitempty"`   // segment file at export, see reuseRendered
+	TitleCard float64 `json:"title_card,omitempty"` // seconds of title card played before it
+
+	Transition   string `json:"transition,omitempty"`
+	Effect       string `json:"effect,omitempty"`
+	Fit          string `json:"fit,omitempty"`
+	BlurFaces    bool   `json:"blur_faces,omitempty"`
+	Look         string `json:"look,omitempty"`
+	LUT          string `json:"lut,omitempty"`
+	Captions     bool   `json:"captions,omitempty"`
+	CaptionStyle string `json:"caption_style,omitempty"`
+	Subtitles    string `json:"subtitles,omitempty"` // .ass file reference
+	Platform     string `json:"platform,omitempty"`
+	Citation     string `json:"citation,omitempty"`
+
+	TTSProvider string  `json:"tts_provider,omitempty"`
+	VoiceID     string  `json:"voice_id,omitempty"`
+	Locale      string  `json:"locale,omitempty"`
+	SentenceGap float64 `json:"sentence_gap,omitempty"`
+
+	ChromaKey         string  `json:"chroma_key,omitempty"`
+	KeyColor          string  `json:"key_color,omitempty"`
+	PresenterPosition string  `json:"presenter_position,omitempty"`
+	PiP               string  `json:"pip,omitempty"`
+	PiPPosition       string  `json:"pip_position,omitempty"`
+	PiPSize           int     `json:"pip_size,omitempty"`
+	PiPDuration       float64 `json:"pip_duration,omitempty"`
+	PiPOffset         float64 `json:"pip_offset,omitempty"`
+	Avatar            string  `json:"avatar,omitempty"`
+	AvatarID          string  `json:"avatar_id,omitempty"`
+
+	Timer       string  `json:"timer,omitempty"`
+	TimerFrom   float64 `json:"timer_from,omitempty"`
+	TimerOffset float64 `json:"timer_offset,omitempty"`
+
+	BRoll []string `json:"broll,omitempty"` // clip references, see brollCuts
+}
+
+// segmentSpec records a rendered segment's options.
+func segmentSpec(title, text, mediaPath string, d float64, opts RenderOptions) SegmentSpec {
+	return SegmentSpec{
+		Title: title, Text: text, Media: specRef(mediaPath), Duration: d,
+		Transition: opts.Transition, Effect: opts.Effect, Fit: opts.Fit, BlurFaces: opts.BlurFaces, Look: opts.Look, LUT: specRef(opts.LUTPath),
+		Captions: opts.Captions, CaptionStyle: opts.CaptionStyle, Subtitles: specRef(opts.SubtitlePath),
+		Platform: opts.Platform, Citation: opts.Citation,
+		TTSProvider: opts.TTSProvider, VoiceID: opts.VoiceID, Locale: opts.Locale, SentenceGap: opts.SentenceGap,
+		ChromaKey: specRef(opts.ChromaKeyPath), KeyColor: opts.KeyColor, PresenterPosition: opts.PresenterPosition,
+		PiP: specRef(opts.PiPPath), PiPPosition: opts.PiPPosition, PiPSize: opts.PiPSize,
+		PiPDuration: opts.PiPDuration, PiPOffset: opts.PiPOffset,
+		Avatar: opts.Avatar, AvatarID: opts.AvatarID, BRoll: specRefs(opts.BRollClips),
+		Timer: opts.Timer, TimerFrom: opts.TimerFrom, TimerOffset: opts.TimerOffset,
+	}
+}
+
+// options turns the segment back into render options over base; owner is
+// the caller, see resolveSpecRef.
+func (s SegmentSpec) options(base RenderOptions, owner string) (RenderOptions, error) {
+	opts := base
+	opts.Transition, opts.Effect, opts.Fit, opts.Look = s.Transition, s.Effect, s.Fit, s.Look
+	opts.BlurFaces = s.BlurFaces
+	opts.Captions, opts.CaptionStyle, opts.Platform, opts.Citation = s.Captions, s.CaptionStyle, s.Platform, s.Citation
+	opts.TTSProvider, opts.VoiceID, opts.Locale, opts.SentenceGap = s.TTSProvider, s.VoiceID, s.Locale, s.SentenceGap
+	opts.KeyColor, opts.PresenterPosition = s.KeyColor, s.PresenterPosition
+	opts.PiPPosition, opts.PiPSize, opts.PiPDuration, opts.PiPOffset = s.PiPPosition, s.PiPSize, s.PiPDuration, s.PiPOffset
+	opts.Avatar, opts.AvatarID = s.Avatar, s.AvatarID
+	opts.Timer, opts.TimerFrom, opts.TimerOffset, opts.TitleCard = s.Timer, s.TimerFrom, s.TimerOffset, s.TitleCard
+
+	if opts.TTSProvider == "" {
+		opts.TTSProvider = "google"
+	}
+	if err := checkStyle("segment", opts.Effect, opts.Transition, opts.Fit); err != nil {
+		return opts, err
+	}
+	if err := checkKeyColor("segment", opts.KeyColor); err != nil {
+		return opts, err
+	}
+	if err := checkVoiceID(opts.VoiceID); err != nil {
+		return opts, err
+	}
+	if opts.Look != "" && opts.Look != "none" && colorLooks[opts.Look] == "" {
+		return opts, fmt.Errorf("unknown look %q", opts.Look)
+	}
+	if !ttsProviders[opts.TTSProvider] {
+		return opts, fmt.Errorf("unknown tts_provider %q", opts.TTSProvider)
+	}
+	if opts.Avatar != "" && !avatarProviders[opts.Avatar] {
+		return opts, fmt.Errorf("unknown avatar provider %q", opts.Avatar)
+	}
+	if err := checkTimer("segment", opts.Timer, opts.TimerFrom); err != nil {
+		return opts, err
+	}
+	if opts.TitleCard != 0 && (opts.TitleCard < minTitleCard || opts.TitleCard > maxTitleCard) {
+		return opts, fmt.Errorf("title_card must be between %.0f and %.0f", minTitleCard, maxTitleCard)
+	}
+	for _, f := range []struct {
+		ref  string
+		path *string
+	}{{s.LUT, &opts.LUTPath}, {s.Subtitles, &opts.SubtitlePath}, {s.ChromaKey, &opts.ChromaKeyPath}, {s.PiP, &opts.PiPPath}} {
+		if f.ref == "" {
+			continue
+		}
+		path, err := resolveSpecRef(f.ref, owner)
+		if err != nil {
+			return opts, err
+		}
+		*f.path = path
+	}
+	opts.BRollClips = nil
+	for _, ref := range s.BRoll {
+		path, err := resolveSpecRef(ref, owner)
+		if err != nil {
+			return opts, err
+		}
+		opts.BRollClips = append(opts.BRollClips, path)
+	}
+	return opts, nil
+}
+
+func specRefs(paths []string) []string {
+	var refs []string
+	for _, p := range paths {
+		if ref := specRef(p); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// specRef is the spec reference of a file in a job workspace or the asset
+// library, "" for anything else.
+func specRef(path string) string {
+	if path == "" {
+		return ""
+	}
+	switch dir := filepath.Dir(path); dir {
+	case filepath.Clean(assetsDir):
+		return "assets/" + filepath.Base(path)
+	default:
+		if filepath.Dir(dir) == filepath.Join("output", "jobs") && uuidPattern.MatchString(filepath.Base(dir)) {
+			return "jobs/" + filepath.Base(dir) + "/" + filepath.Base(path)
+		}
+	}
+	return ""
+}
+
+// resolveSpecRef maps a spec reference back to an existing file. Library
+// files resolve only for their owners, see assetPath.
+func resolveSpecRef(ref, owner string) (string, error) {
+	parts := strings.Split(ref, "/")
+	name := parts[len(parts)-1]
+	var path string
+	switch {
+	case name == "" || name == "." || name == "..":
+	case len(parts) == 3 && parts[0] == "jobs" && uuidPattern.MatchString(parts[1]):
+		path = filepath.Join("output", "jobs", parts[1], name)
+	case len(parts) == 2 && parts[0] == "assets":
+		asset, err := assetPath(strings.TrimSuffix(name, filepath.Ext(name)), owner)
+		if err != nil || filepath.Base(asset) != name {
+			return "", fmt.Errorf("file %q not found", ref)
+		}
+		path = asset
+	}
+	if path == "" {
+		return "", fmt.Errorf("invalid file reference %q", ref)
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("file %q not found", ref)
+	}
+	return path, nil
+}
+
+// saveSpec writes the job's spec.json.
+func (j *Job) saveSpec(base RenderOptions, segments []SegmentSpec) {
+	spec := RenderSpec{Version: renderSpecVersion, JobID: j.ID, Type: base.VideoType, FPS: base.FPS,
+		Sting: base.StingPath, BrandName: base.BrandName, Segments: segments}
+	if spec.Sting != "" && spec.Sting != "template" {
+		spec.Sting = specRef(spec.Sting)
+	}
+	data, _ := json.MarshalIndent(spec, "", "  ")
+	os.WriteFile(j.Path("spec.json"), data, 0644)
+}
+
+// GET /v1/jobs/:id/spec
+func handleGetSpec(c *gin.Context) {
+	job, _, err := openJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := os.ReadFile(job.Path("spec.json"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "no render spec for this job (yet)"})
+		return
+	}
+	c.Data(200, "application/json", data)
+}
+
+// POST /v1/render-spec renders a (possibly edited) spec in a new job.
+func handleRenderSpec(c *gin.Context) {
+	var spec RenderSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	renderSpec(httpRequest(c), spec)
+}
+
+// renderSpec renders spec in a new job and answers the request. Segments
+// unchanged from the job it was exported from reuse that job's render.
+func renderSpec(r *request, spec RenderSpec) {
+	if spec.Version != renderSpecVersion {
+		r.JSON(400, gin.H{"error": fmt.Sprintf("unsupported spec version %d", spec.Version)})
+		return
+	}
+	if spec.Type != "long" {
+		spec.Type = "short"
+	}
+	if spec.FPS != 0 && !frameRates[spec.FPS] {
+		r.JSON(400, gin.H{"error": fmt.Sprintf("unsupported fps %d", spec.FPS)})
+		return
+	}
+	if err := checkAmbience(spec.Ambience); err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// Items render as one to a few beats each; leave room for intro and outro.
+	if limit := limitsFromEnv().MaxScenes*4 + 2; len(spec.Segments) == 0 || len(spec.Segments) > limit {
+		r.JSON(422, gin.H{"error": fmt.Sprintf("A spec needs 1 to %d segments", limit)})
+		return
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	var rendered map[string]SegmentSpec
+	if parent, _, err := openJob(spec.JobID); err == nil {
+		job.parentJob = spec.JobID
+		CopyFile(parent.Path("licenses.jsonl"), job.Path("licenses.jsonl")) // the media keeps its origins
+		rendered = renderedSegments(parent, spec)
+	}
+	base := RenderOptions{VideoType: spec.Type, FPS: spec.FPS, BrandName: spec.BrandName,
+		TTSKey: r.GetHeader("X-ElevenLabs-Key"), Lexicon: loadLexicon(r.GetHeader("X-Api-Key")), Timings: job.timings}
+	if spec.Sting == "template" {
+		base.StingPath = "template"
+	} else if spec.Sting != "" {
+		if base.StingPath, err = resolveSpecRef(spec.Sting, job.owner); err != nil {
+			r.JSON(400, gin.H{"error": "sting: " + err.Error()})
+			return
+		}
+	}
+	media := make([]string, len(spec.Segments))
+	opts := make([]RenderOptions, len(spec.Segments))
+	for i, s := range spec.Segments {
+		if !checkPolicy(r, s.Title) || !checkPolicy(r, s.Text) {
+			return
+		}
+		if strings.TrimSpace(s.Text) == "" {
+			r.JSON(400, gin.H{"error": fmt.Sprintf("segment %d: text is required", i)})
+			return
+		}
+		if media[i], err = resolveSpecRef(s.Media, job.owner); err == nil {
+			opts[i], err = s.options(base, job.owner)
+		}
+		if err != nil {
+			r.JSON(400, gin.H{"error": fmt.Sprintf("segment %d: %v", i, err)})
+			return
+		}
+	}
+
+	fmt.Println("🔹 STEP 1: Rendering Spec Segments...")
+	job.Stage("render")
+	var segments []Segment
+	var specs []SegmentSpec
+	for i, s := range spec.Segments {
+		segPath := job.Path(fmt.Sprintf("seg_%d.mp4", i))
+		title := s.Title
+		if s.TitleCard > 0 {
+			title = ""
+		}
+		var fallback string
+		var err error
+		if !reuseRendered(rendered, s, segPath) {
+			fallback, err = renderWithFallbacks(job, s.Title, s.Text, media[i], segPath, opts[i])
+		}
+		if err != nil {
+			fmt.Printf("⚠️ Segment %d skipped: %v\n", i, err)
+			continue
+		}
+		d, _ := probeDuration(segPath)
+		scene := Segment{Path: segPath, Transition: opts[i].Transition, Title: title, Duration: d, Fallback: fallback}
+		if s.TitleCard > 0 {
+			cardPath := job.Path(fmt.Sprintf("card_%d.mp4", i))
+			if err := renderTitleCard(s.Title, media[i], segPath, cardPath, opts[i]); err != nil {
+				fmt.Printf("⚠️ %v\n", err)
+				scene.Title = s.Title
+			} else {
+				segments = append(segments, Segment{Path: cardPath, Transition: scene.Transition, Title: s.Title, Duration: opts[i].TitleCard})
+				scene.Transition = "cut"
+			}
+		}
+		segments = append(segments, scene)
+		job.segmentRendered(len(segments)-1, scene)
+		specs = append(specs, segmentSpec(s.Title, s.Text, media[i], d, opts[i]))
+		specs[len(specs)-1].TitleCard, specs[len(specs)-1].Rendered = s.TitleCard, specRef(segPath)
+	}
+	job.saveSpec(base, specs)
+	segments = withSting(segments, base)
+
+	fmt.Println("🔹 STEP 2: Stitching Video...")
+	job.Stage("stitch")
+	finalVideo := job.Path("final_spec.mp4")
+	stitch, err := stitchSegments(segments, finalVideo)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Stitch): %v\n", err)
+		r.JSON(500, gin.H{"error": "Stitch failed: " + err.Error()})
+		return
+	}
+	finish := FinishOptions{VideoType: spec.Type, Title: spec.Title, Comment: "job " + job.ID, MusicVolume: 0.15,
+		AmbienceVolume: defaultAmbienceVolume, Mastering: spec.Mastering, Chapters: segmentChapters(segments)}
+	if spec.Ambience != "none" {
+		finish.Ambience = spec.Ambience
+	}
+	if spec.Music != "" {
+		if finish.MusicPath, err = resolveSpecRef(spec.Music, job.owner); err != nil {
+			fmt.Printf("⚠️ Music skipped: %v\n", err)
+		}
+	}
+	job.Stage("finish")
+	if err := finishVideo(finalVideo, finish); err != nil {
+		fmt.Printf("⚠️ Finishing skipped: %v\n", err)
+	}
+
+	fmt.Println("✅ SUCCESS! Video Ready.")
+	output, segmentInfo := segmentResults(r, job, finalVideo, segments)
+	r.JSON(200, gin.H{"status": "success", "job_id": job.ID, "video_url": job.URL(r.base, finalVideo), "stitch": stitch,
+		"spec_url": r.base + "/v1/jobs/" + job.ID + "/spec", "output": output, "segments": segmentInfo,
+		"chapters": finish.Chapters, "chapters_text": youtubeChapters(finish.Chapters)})
+}
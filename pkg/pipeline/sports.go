@@ -0,0 +1,344 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- SPORTS MODE ---
+// POST /generate-sports-video turns a league's results or fixtures into a
+// video with one scene per match: league=<name> (aliases such as "premier
+// league" or "nba" work) and date=yesterday|today|tomorrow|YYYY-MM-DD
+// (yesterday). Match data comes from TheSportsDB (SPORTSDB_KEY, the public
+// test key otherwise); each scene shows both team crests and the score, and
+// the LLM writes the commentary from the data only.
+const sportsDBAPI = "https://www.thesportsdb.com/api/v1/json/"
+
+var leagueAliases = map[string]string{
+	"premier league": "English Premier League", "epl": "English Premier League",
+	"championship": "English League Championship", "la liga": "Spanish La Liga",
+	"bundesliga": "German Bundesliga", "serie a": "Italian Serie A", "ligue 1": "French Ligue 1",
+	"mls": "American Major League Soccer", "champions league": "UEFA Champions League",
+	"nba": "NBA", "nfl": "NFL", "nhl": "NHL", "mlb": "MLB",
+}
+
+type Match struct {
+	ID        string
+	Home      string
+	Away      string
+	HomeScore string // "" until played
+	AwayScore string
+	Venue     string
+	Kickoff   string // local time as the provider gives it
+	HomeCrest string
+	AwayCrest string
+}
+
+func (m Match) played() bool {
+	return m.HomeScore != "" && m.AwayScore != ""
+}
+
+// Label is the scene title, e.g. "Arsenal 2-1 Chelsea".
+func (m Match) Label() string {
+	if m.played() {
+		return fmt.Sprintf("%s %s-%s %s", m.Home, m.HomeScore, m.AwayScore, m.Away)
+	}
+	return m.Home + " vs " + m.Away
+}
+
+func handleSportsVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Sports Request Received")
+
+	league := strings.TrimSpace(r.PostForm("league"))
+	if alias, ok := leagueAliases[strings.ToLower(league)]; ok {
+		league = alias
+	}
+	if league == "" {
+		r.JSON(400, gin.H{"error": "league is required"})
+		return
+	}
+	day, err := parseMatchDay(r.DefaultPostForm("date", "yesterday"))
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	scriptOpts, err := scriptOptionsFromForm(r)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Fetching Matches...")
+	job.Stage("matches")
+	matches, err := fetchMatches(league, day)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Sports): %v\n", err)
+		r.JSON(502, gin.H{"error": "Could not fetch matches: " + err.Error()})
+		return
+	}
+	if len(matches) == 0 {
+		r.JSON(422, gin.H{"error": fmt.Sprintf("No %s matches on %s", league, day.Format("2006-01-02"))})
+		return
+	}
+	if limit := limitsFromEnv().MaxScenes; len(matches) > limit {
+		matches = matches[:limit]
+	}
+	for _, m := range matches {
+		job.AddSource("", Source{Provider: "TheSportsDB", Title: m.Label(), URL: "https://www.thesportsdb.com/event/" + m.ID})
+	}
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	if topic == "" {
+		kind := "results"
+		if !matches[0].played() {
+			kind = "fixtures"
+		}
+		topic = fmt.Sprintf("%s %s, %s", league, kind, day.Format("January 2"))
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Matches: %d\n", topic, videoType, len(matches))
+
+	fmt.Println("🔹 STEP 3: Writing Commentary (Groq)...")
+	job.Stage("script")
+	scriptData, err := writeCommentary(job, topic, matches, videoType, scriptOpts)
+	if err != nil {
+		fmt.Printf("❌ CRITICAL ERROR (Groq): %v\n", err)
+		r.JSON(500, gin.H{"error": "AI Script failed: " + err.Error()})
+		return
+	}
+	if !applyHook(r, job, topic, &scriptData, scriptOpts) {
+		return
+	}
+	job.scriptReady(scriptData)
+
+	job.Stage("media")
+	introPath := placeholderCard(job, topic, 0, videoType, base.Card)
+	scenePaths := make([]string, len(scriptData.Items))
+	for i := range scriptData.Items {
+		scenePaths[i] = matchCard(job, matches[i], i+1, videoType, base.Card)
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 4, roundup{Name: "sports", Topic: topic, VideoType: videoType, Script: scriptData,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+func parseMatchDay(v string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	day, err := time.Parse("2006-01-02", strings.TrimSpace(v))
+	if err != nil {
+		return day, fmt.Errorf("date must be yesterday, today, tomorrow or YYYY-MM-DD")
+	}
+	return day, nil
+}
+
+func sportsDBKey() string {
+	if key := os.Getenv("SPORTSDB_KEY"); key != "" {
+		return key
+	}
+	return "3" // TheSportsDB's public test key
+}
+
+// fetchMatches lists league's events on day with crests filled in.
+func fetchMatches(league string, day time.Time) ([]Match, error) {
+	client := &http.Client{Timeout: newsTimeout}
+	q := url.Values{"d": {day.Format("2006-01-02")}, "l": {league}}
+	var res struct {
+		Events []struct {
+			ID        string  `json:"idEvent"`
+			HomeTeam  string  `json:"strHomeTeam"`
+			AwayTeam  string  `json:"strAwayTeam"`
+			HomeID    string  `json:"idHomeTeam"`
+			AwayID    string  `json:"idAwayTeam"`
+			HomeScore *string `json:"intHomeScore"`
+			AwayScore *string `json:"intAwayScore"`
+			Venue     string  `json:"strVenue"`
+			Time      string  `json:"strTimeLocal"`
+			HomeBadge string  `json:"strHomeTeamBadge"`
+			AwayBadge string  `json:"strAwayTeamBadge"`
+		} `json:"events"`
+	}
+	if err := callJSON(client, "GET", sportsDBAPI+sportsDBKey()+"/eventsday.php?"+q.Encode(), "", nil, &res); err != nil {
+		return nil, err
+	}
+
+	badges := map[string]string{}
+	crest := func(id, badge string) string {
+		if badge != "" || id == "" {
+			return badge
+		}
+		if b, ok := badges[id]; ok {
+			return b
+		}
+		badges[id] = teamBadge(client, id)
+		return badges[id]
+	}
+	var matches []Match
+	for _, e := range res.Events {
+		m := Match{ID: e.ID, Home: e.HomeTeam, Away: e.AwayTeam, Venue: e.Venue, Kickoff: strings.TrimSuffix(e.Time, ":00"),
+			HomeCrest: crest(e.HomeID, e.HomeBadge), AwayCrest: crest(e.AwayID, e.AwayBadge)}
+		if e.HomeScore != nil && e.AwayScore != nil {
+			m.HomeScore, m.AwayScore = *e.HomeScore, *e.AwayScore
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+func teamBadge(client *http.Client, teamID string) string {
+	var res struct {
+		Teams []struct {
+			Badge string `json:"strBadge"`
+		} `json:"teams"`
+	}
+	target := sportsDBAPI + sportsDBKey() + "/lookupteam.php?id=" + url.QueryEscape(teamID)
+	if err := callJSON(client, "GET", target, "", nil, &res); err != nil || len(res.Teams) == 0 {
+		return ""
+	}
+	return res.Teams[0].Badge
+}
+
+// writeCommentary scripts one item per match, in the matches' order.
+func writeCommentary(job *Job, topic string, matches []Match, videoType string, opts ScriptOptions) (ScriptResponse, error) {
+	minWords, maxWords := wordRange(videoType)
+	var list strings.Builder
+	for i, m := range matches {
+		fmt.Fprintf(&list, "\nMatch %d: %s", i+1, m.Label())
+		if m.Venue != "" {
+			fmt.Fprintf(&list, " at %s", m.Venue)
+		}
+		if !m.played() && m.Kickoff != "" {
+			fmt.Fprintf(&list, ", kick-off %s", m.Kickoff)
+		}
+	}
+
+	return generateForAudience(opts, func(audience string) (ScriptResponse, error) {
+		prompt := fmt.Sprintf(`
+    Topic: "%s" (%s mode)
+    Tone: Lively sports presenter.%s
+    Write exactly %d items, one per match below and in the same order: commentary on each result, or a
+    preview for a match not yet played. Use only the data given; never invent scorers, minutes or statistics.
+    Constraint: Each item must be between %d and %d words to ensure duration.
+    MATCHES:%s
+    RETURN JSON ONLY:
+    {
+        "intro": "Hook around 35 words",
+        "items": [
+            { "title": "Home 2-1 Away", "details": "Script text between %d and %d words..." }
+        ],
+        "outro": "Conclusion around 35 words",
+        "mood": "one of: %s"
+    }
+    `, topic, videoType, audience, len(matches), minWords, maxWords, list.String(), minWords, maxWords, strings.Join(musicMoods, ", "))
+
+		var result ScriptResponse
+		if err := completeTemplated(job, "sports_commentary", opts.Template, prompt, &result); err != nil {
+			return ScriptResponse{}, err
+		}
+		if len(result.Items) == 0 {
+			return ScriptResponse{}, fmt.Errorf("no matches returned")
+		}
+		if len(result.Items) > len(matches) {
+			result.Items = result.Items[:len(matches)]
+		}
+		// The data's label is authoritative over the model's.
+		for i := range result.Items {
+			result.Items[i].Title = matches[i].Label()
+		}
+		return result, nil
+	})
+}
+
+// matchCard is the scene image for m: both crests on the card background
+// with the score (or "vs") between them. Without both crests it falls back
+// to a placeholder card with the label.
+func matchCard(job *Job, m Match, index int, videoType string, card CardStyle) string {
+	var crests [2]string
+	for i, src := range []string{m.HomeCrest, m.AwayCrest} {
+		if src == "" {
+			return placeholderCard(job, m.Label(), index, videoType, card)
+		}
+		path, err := job.Fetch("url:"+src, ".png", func(dest string) error {
+			return downloadFile(src, dest)
+		})
+		if err != nil {
+			return placeholderCard(job, m.Label(), index, videoType, card)
+		}
+		crests[i] = path
+	}
+
+	path, err := job.Fetch("match:"+m.Label(), ".jpg", func(dest string) error {
+		return renderMatchCard(m, crests, dest, videoType, card)
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Match card for %s failed: %v\n", m.Label(), err)
+		return placeholderCard(job, m.Label(), index, videoType, card)
+	}
+	return path
+}
+
+func renderMatchCard(m Match, crests [2]string, dest, videoType string, card CardStyle) error {
+	w, h := frameSize(videoType)
+	bgPath := dest + ".bg.png"
+	if err := writePNG(bgPath, cardBackground(w, h, card)); err != nil {
+		return err
+	}
+	defer os.Remove(bgPath)
+
+	score := "vs"
+	if m.played() {
+		score = m.HomeScore + "-" + m.AwayScore
+	}
+	scorePath, namesPath := dest+".score.txt", dest+".names.txt"
+	os.WriteFile(scorePath, []byte(score), 0644)
+	os.WriteFile(namesPath, []byte(m.Home+"   vs   "+m.Away), 0644)
+	defer os.Remove(scorePath)
+	defer os.Remove(namesPath)
+
+	crest := min(w, h) * 3 / 10
+	size := min(w, h) / 12
+	font := escapeFilterArg(fontForText(m.Home + m.Away))
+	filter := fmt.Sprintf("[1:v]scale=%d:%d:force_original_aspect_ratio=decrease[home];"+
+		"[2:v]scale=%d:%d:force_original_aspect_ratio=decrease[away];"+
+		"[0:v][home]overlay=x=W/4-w/2:y=(H-h)/2[t];[t][away]overlay=x=3*W/4-w/2:y=(H-h)/2,"+
+		"drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s:x=(w-text_w)/2:y=(h-text_h)/2:shadowcolor=black@0.4:shadowx=3:shadowy=3,"+
+		"drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s:x=(w-text_w)/2:y=h/2+%d:shadowcolor=black@0.4:shadowx=2:shadowy=2",
+		crest, crest, crest, crest,
+		font, escapeFilterArg(scorePath), size, card.TextColor,
+		font, escapeFilterArg(namesPath), size/3, card.TextColor, crest/2+size/2)
+	cmd := ffmpegCommand("-y", "-i", bgPath, "-i", crests[0], "-i", crests[1],
+		"-filter_complex", filter, "-frames:v", "1", "-q:v", "2", dest)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("match card: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
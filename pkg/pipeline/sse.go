@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- SCRIPT STREAMING ---
+// stream=true (or an Accept: text/event-stream header) turns a generation
+// request's response into server-sent events, so a UI can show the script
+// being written instead of waiting through a silent first stage:
+//
+//	event: job      {"job_id": "..."}                     as soon as the job exists
+//	event: stage    {"stage": "script"}                   at every stage
+//	event: script   {"call": "script", "delta": "{\"in"}  LLM output as it arrives
+//	event: status   {...}                                 an early response, e.g. the preview
+//	event: result   {...}                                 the final response
+//
+// A failed request ends with "error" instead of "result", carrying the
+// status as "code". Every LLM call is streamed under its name (script,
+// hooks, fact_check, ...); a retry's reused answers arrive as one delta.
+type scriptStream struct {
+	mu  sync.Mutex
+	rec *bodyRecorder
+}
+
+// openStream switches the response to server-sent events if asked.
+func (j *Job) openStream(c *gin.Context) {
+	if c.PostForm("stream") != "true" && !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		return
+	}
+	rec, ok := c.Writer.(*bodyRecorder)
+	if !ok || rec.detached {
+		return
+	}
+	w := rec.ResponseWriter
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	rec.detached = true // every later response goes out as an event
+	j.stream = &scriptStream{rec: rec}
+	j.stream.send("job", gin.H{"job_id": j.ID})
+}
+
+// send writes one event; s may be nil.
+func (s *scriptStream) send(event string, data any) {
+	if s == nil {
+		return
+	}
+	payload, _ := json.Marshal(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.rec.ResponseWriter, "event: %s\ndata: %s\n\n", event, payload)
+	s.rec.ResponseWriter.Flush()
+}
+
+func (s *scriptStream) delta(call, text string) {
+	s.send("script", gin.H{"call": call, "delta": text})
+}
+
+// finish sends the final response, body as recorded by jobEvents.
+func (s *scriptStream) finish(status int, body []byte) {
+	if s == nil {
+		return
+	}
+	var resp map[string]any
+	if json.Unmarshal(body, &resp) != nil || resp == nil {
+		resp = map[string]any{}
+	}
+	if status != 200 {
+		resp["code"] = status
+		s.send("error", resp)
+		return
+	}
+	s.send("result", resp)
+}
+
+// complete runs req as a streaming completion, forwarding each delta, and
+// returns the whole answer.
+func (s *scriptStream) complete(client *openai.Client, call string, req openai.ChatCompletionRequest) (string, error) {
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	var answer strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) > 0 && resp.Choices[0].Delta.Content != "" {
+			answer.WriteString(resp.Choices[0].Delta.Content)
+			s.delta(call, resp.Choices[0].Delta.Content)
+		}
+	}
+	if answer.Len() == 0 {
+		return "", fmt.Errorf("empty model response")
+	}
+	return answer.String(), nil
+}
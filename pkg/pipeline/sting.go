@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// --- BRAND STING ---
+// A short animated opener placed before the intro: either an uploaded clip
+// or a template (brand name animating in over the brand gradient). Stings
+// are conformed to the segment format once and cached by source and format
+// under output/stings, so every video from the same kit reuses the file.
+const (
+	stingDir      = "output/stings"
+	templateSting = 2.0 // seconds
+)
+
+// withSting prepends the request's sting to the rendered segments.
+func withSting(segments []Segment, opts RenderOptions) []Segment {
+	if opts.StingPath == "" || len(segments) == 0 {
+		return segments
+	}
+	path, err := stingClip(opts, segments[0].Path)
+	if err != nil {
+		fmt.Printf("⚠️ Sting skipped: %v\n", err)
+		return segments
+	}
+	d, _ := probeDuration(path)
+	return append([]Segment{{Path: path, Duration: d}}, segments...)
+}
+
+// stingClip returns the cached sting matching the first segment's format,
+// rendering it on first use.
+func stingClip(opts RenderOptions, firstSeg string) (string, error) {
+	w, h := frameSize(opts.VideoType)
+	rate, layout := probeAudioFormat(firstSeg)
+
+	key := sha256.New()
+	if opts.StingPath == "template" {
+		fmt.Fprintf(key, "template|%s|%s|%v|%v|%s", opts.BrandName, opts.Card.Style, opts.Card.From, opts.Card.To, opts.Card.TextColor)
+	} else {
+		fmt.Fprintf(key, "clip|%s", filepath.Base(opts.StingPath)) // asset names are content hashes
+	}
+	fmt.Fprintf(key, "|%dx%d|%d|%s|%s|%s", w, h, opts.frameRate(), rate, layout, opts.Look)
+	dest := filepath.Join(stingDir, hex.EncodeToString(key.Sum(nil))[:32]+".mp4")
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+	os.MkdirAll(stingDir, 0755)
+
+	tmp := dest + ".tmp.mp4"
+	var args []string
+	if opts.StingPath == "template" {
+		bgPath := dest + ".bg.png"
+		if err := writePNG(bgPath, cardBackground(w, h, opts.Card)); err != nil {
+			return "", err
+		}
+		defer os.Remove(bgPath)
+		textPath := dest + ".txt"
+		os.WriteFile(textPath, []byte(opts.BrandName), 0644)
+		defer os.Remove(textPath)
+
+		size := w / 10
+		filter := fmt.Sprintf("[0:v]scale=%d:%d,drawtext=font='%s':textfile=%s:fontcolor=%s:fontsize='%d*(0.85+0.15*min(1,t/0.6))':x=(w-text_w)/2:y=(h-text_h)/2:alpha='min(1,t/0.4)*min(1,(%.1f-t)/0.3)',fade=t=out:st=%.1f:d=0.3",
+			w, h, escapeFilterArg(fontForText(opts.BrandName)), escapeFilterArg(textPath), opts.Card.TextColor, size, templateSting, templateSting-0.3)
+		args = []string{"-y", "-loop", "1", "-i", bgPath}
+		args = append(args, stingArgs(filter, rate, layout, opts, templateSting)...)
+	} else {
+		filter := "[0:v]" + fitFilter(opts.StingPath, w, h, "pad") + fmt.Sprintf(",fps=%d", opts.frameRate())
+		args = []string{"-y", "-i", opts.StingPath}
+		if d, err := probeDuration(opts.StingPath); err == nil && d > 0 {
+			args = append(args, stingArgs(filter, rate, layout, opts, d)...)
+		} else {
+			return "", fmt.Errorf("cannot read sting duration")
+		}
+	}
+	args = append(args, tmp)
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("%v | Log: %s", err, string(output))
+	}
+	return dest, os.Rename(tmp, dest)
+}
+
+// stingArgs encodes the sting like a rendered segment. Uploaded clips keep
+// their own audio when they have some; the template is silent.
+func stingArgs(filter, rate, layout string, opts RenderOptions, duration float64) []string {
+	if grade := gradeFilter(opts); grade != "" {
+		filter += "," + grade
+	}
+	graph := filter + ",format=yuv420p[vout]"
+	aMap := "1:a"
+	if opts.StingPath != "template" && hasAudio(opts.StingPath) {
+		graph += fmt.Sprintf(";[0:a]aresample=%s,aformat=channel_layouts=%s,apad[aout]", rate, layout)
+		aMap = "[aout]"
+	}
+	return []string{"-f", "lavfi", "-i", fmt.Sprintf("anullsrc=r=%s:cl=%s", rate, layout),
+		"-filter_complex", graph, "-map", "[vout]", "-map", aMap, "-t", fmt.Sprintf("%.3f", duration), "-r", fmt.Sprint(opts.frameRate()),
+		"-c:v", "libx264", "-preset", "ultrafast", "-c:a", "aac", "-b:a", "128k"}
+}
+
+func hasAudio(path string) bool {
+	out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "a", "-show_entries", "stream=index", "-of", "csv=p=0", path).Output()
+	return err == nil && len(out) > 0
+}
+
+func writePNG(path string, img *image.RGBA) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
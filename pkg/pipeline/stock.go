@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// --- STOCK / AI IMAGERY ---
+type PexelsSearchResponse struct {
+	Photos []struct {
+		URL          string `json:"url"`
+		Alt          string `json:"alt"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		Photographer string `json:"photographer"`
+		Src          struct {
+			Large2x   string `json:"large2x"`
+			Portrait  string `json:"portrait"`
+			Landscape string `json:"landscape"`
+		} `json:"src"`
+	} `json:"photos"`
+}
+
+// downloadStockPhoto takes the Pexels result for query that best matches
+// scene, see rankCandidates.
+func downloadStockPhoto(query, scene, dest, videoType string) (Source, error) {
+	apiKey := os.Getenv("PEXELS_API_KEY")
+	if apiKey == "" {
+		return Source{}, fmt.Errorf("missing key")
+	}
+	orientation := "portrait"
+	if videoType == "long" {
+		orientation = "landscape"
+	}
+	searchUrl := fmt.Sprintf("https://api.pexels.com/v1/search?query=%s&per_page=%d&orientation=%s", url.QueryEscape(query), stockCandidates(), orientation)
+	req, _ := http.NewRequest("GET", searchUrl, nil)
+	req.Header.Set("Authorization", apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Source{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return Source{}, fmt.Errorf("pexels status %d", resp.StatusCode)
+	}
+	var res PexelsSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Source{}, err
+	}
+	if len(res.Photos) == 0 {
+		return Source{}, fmt.Errorf("not found")
+	}
+	photos := res.Photos[:0]
+	for _, p := range res.Photos {
+		if err := mediaQualityOK(p.Width, p.Height); err == nil {
+			photos = append(photos, p)
+		}
+	}
+	if len(photos) == 0 {
+		return Source{}, fmt.Errorf("no result passes the quality gate")
+	}
+	alts := make([]string, len(photos))
+	for i, p := range photos {
+		alts[i] = p.Alt
+	}
+	photo := photos[rankCandidates(scene, alts)]
+	return Source{Provider: "Pexels", Author: photo.Photographer, URL: photo.URL}, downloadFile(photo.Src.Large2x, dest)
+}
+
+func downloadAIImage(prompt, dest, videoType string, seed int) error {
+	w, h := 1080, 1920
+	if videoType == "long" {
+		w, h = 1920, 1080
+	}
+	return downloadFile(fmt.Sprintf("https://image.pollinations.ai/prompt/%s?width=%d&height=%d&seed=%d&nologo=true", url.PathEscape(prompt), w, h, seed), dest)
+}
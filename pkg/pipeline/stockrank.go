@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- STOCK RANKING ---
+// Stock searches (Pexels and Unsplash) ask for STOCK_CANDIDATES results
+// (default 10, at most 30) instead of taking the first one, and pick the
+// photo whose description is closest to the scene: its narration where the
+// script is already written, else its name and search text. Descriptions
+// and scene are compared as text embeddings, with the same model and
+// fallback as duplicate detection (see embedTexts). STOCK_CANDIDATES=1
+// keeps the provider's first result; results without a description are
+// never preferred over ones with.
+func stockCandidates() int {
+	return int(min(max(envInt("STOCK_CANDIDATES", 10), 1), 30))
+}
+
+// rankCandidates returns the index of the description closest to scene.
+func rankCandidates(scene string, descriptions []string) int {
+	if len(descriptions) < 2 || strings.TrimSpace(scene) == "" {
+		return 0
+	}
+	var texts []string
+	var indexes []int
+	for i, d := range descriptions {
+		if strings.TrimSpace(d) != "" {
+			texts = append(texts, d)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(texts) == 0 {
+		return 0
+	}
+	vectors, _ := embedTexts(append([]string{scene}, texts...))
+	best, bestScore := 0, -1.0
+	for i, v := range vectors[1:] {
+		if score := cosine(vectors[0], v); score > bestScore {
+			best, bestScore = indexes[i], score
+		}
+	}
+	fmt.Printf("🎯 Stock pick %d of %d (%.2f): %s\n", best+1, len(descriptions), bestScore, descriptions[best])
+	return best
+}
+
+// rankText is what stock results are ranked against.
+func (m MediaRequest) rankText() string {
+	if m.Context != "" {
+		return m.Context
+	}
+	if m.Text != "" && m.Text != m.Query {
+		return m.Query + ". " + m.Text
+	}
+	return m.Query
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- PROGRESSIVE AVAILABILITY ---
+// Stitched and finished videos are written with the moov atom first
+// (+faststart), so players can start before the download ends.
+// progressive=true also publishes the stitched video the moment stitching
+// completes, before the finishing passes (music, progress bar, size budget,
+// metadata): GET /v1/jobs/:id then reports status "ready_for_streaming" with
+// its stream_url, and the webhook receives job.ready_for_streaming. The
+// stream file is a hard link, so finishing passes (which rename a new file
+// over the final path) never change bytes a player is already reading.
+const streamStateFile = "stream.json"
+
+// offerStream publishes the stitched video at finalVideo when the request
+// asked for progressive availability.
+func offerStream(r *request, job *Job, finalVideo string) {
+	if r.PostForm("progressive") != "true" {
+		return
+	}
+	streamPath := job.Path("stream_" + filepath.Base(finalVideo))
+	os.Remove(streamPath)
+	if err := os.Link(finalVideo, streamPath); err != nil {
+		fmt.Printf("⚠️ Progressive stream skipped: %v\n", err)
+		return
+	}
+	state := gin.H{"status": "ready_for_streaming", "job_id": job.ID, "stream_url": job.URL(r.base, streamPath)}
+	if d, err := probeDuration(streamPath); err == nil {
+		state["duration"] = d
+	}
+	data, _ := json.MarshalIndent(state, "", "  ")
+	os.WriteFile(job.Path(streamStateFile), data, 0644)
+	job.Emit("job.ready_for_streaming", state)
+	fmt.Println("📡 Stitched video ready for streaming")
+}
+
+// streamState returns the job's ready_for_streaming state, nil if it was
+// never published.
+func streamState(job *Job) map[string]any {
+	data, err := os.ReadFile(job.Path(streamStateFile))
+	if err != nil {
+		return nil
+	}
+	var state map[string]any
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return state
+}
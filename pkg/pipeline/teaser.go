@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// --- TEASER ---
+// teaser=gif|mp4 cuts a short silent loop from the most visually active
+// stretch of the final video, for social previews and link embeds.
+// teaser_length picks its length within 3-5 seconds (default 4). Activity is
+// ffmpeg's per-frame scene-change score, summed over a sliding window.
+const (
+	minTeaserLength     = 3.0
+	maxTeaserLength     = 5.0
+	defaultTeaserLength = 4.0
+	teaserWidth         = 480
+)
+
+func teaserLength(v string) float64 {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n <= 0 {
+		return defaultTeaserLength
+	}
+	return math.Max(minTeaserLength, math.Min(n, maxTeaserLength))
+}
+
+type frameScore struct {
+	Time  float64
+	Score float64
+}
+
+// sceneScores returns the scene-change score of every frame, analysed on a
+// small copy for speed.
+func sceneScores(video string) ([]frameScore, error) {
+	cmd := ffmpegCommand("-i", video, "-an",
+		"-vf", "scale=160:-2,select='gte(scene,0)',metadata=print:key=lavfi.scene_score:file=-",
+		"-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("scene analysis failed: %v | Log: %s", err, stderr.String())
+	}
+
+	// metadata=print writes "frame:N pts:P pts_time:T" then "lavfi.scene_score=S".
+	var scores []frameScore
+	t := -1.0
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "pts_time:"); i >= 0 {
+			t, _ = strconv.ParseFloat(strings.Fields(line[i+len("pts_time:"):])[0], 64)
+		} else if v, ok := strings.CutPrefix(line, "lavfi.scene_score="); ok && t >= 0 {
+			s, _ := strconv.ParseFloat(v, 64)
+			scores = append(scores, frameScore{Time: t, Score: s})
+		}
+	}
+	return scores, nil
+}
+
+// busiestWindow returns the start of the length-second window with the
+// highest total score. Videos shorter than length start at 0.
+func busiestWindow(scores []frameScore, duration, length float64) float64 {
+	if duration <= length || len(scores) == 0 {
+		return 0
+	}
+	best, bestStart := -1.0, 0.0
+	sum, j := 0.0, 0
+	for i := range scores {
+		start := scores[i].Time
+		if start+length > duration {
+			break
+		}
+		for j < len(scores) && scores[j].Time < start+length {
+			sum += scores[j].Score
+			j++
+		}
+		if sum > best {
+			best, bestStart = sum, start
+		}
+		sum -= scores[i].Score
+	}
+	return bestStart
+}
+
+// writeTeaser cuts the teaser into dest; the extension picks GIF or MP4.
+func writeTeaser(video, dest string, length float64) error {
+	duration, err := probeDuration(video)
+	if err != nil {
+		return err
+	}
+	scores, err := sceneScores(video)
+	if err != nil {
+		return err
+	}
+	start := busiestWindow(scores, duration, length)
+	fmt.Printf("🎞️ Teaser: %.1fs from %.1fs\n", length, start)
+
+	args := []string{"-y", "-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", length), "-i", video, "-an"}
+	scale := fmt.Sprintf("scale=%d:-2:flags=lanczos", teaserWidth)
+	if strings.HasSuffix(dest, ".gif") {
+		args = append(args, "-vf", "fps=12,"+scale+",split[a][b];[a]palettegen[p];[b][p]paletteuse", "-loop", "0", dest)
+	} else {
+		args = append(args, "-vf", scale, "-c:v", "libx264", "-preset", "veryfast", "-crf", "26",
+			"-pix_fmt", "yuv420p", "-movflags", "+faststart", dest)
+	}
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("teaser failed: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+func teaserURL(base string, job *Job, opts FinishOptions) string {
+	if opts.TeaserPath == "" {
+		return ""
+	}
+	if _, err := os.Stat(opts.TeaserPath); err != nil {
+		return ""
+	}
+	return job.URL(base, opts.TeaserPath)
+}
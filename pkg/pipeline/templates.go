@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sashabaranov/go-openai"
+)
+
+// --- SCRIPT TEMPLATES ---
+// A script template steers the script model for a niche format (horror
+// narration, finance explainer, ...) without code changes: a system prompt
+// and few-shot example scripts, sent ahead of the route's own prompt on its
+// script call. Templates are JSON files in SCRIPT_TEMPLATE_DIR (default
+// data/script_templates), one <id>.json each, which POST /script-templates
+// also writes:
+//
+//	{"id": "horror", "name": "Horror narration",
+//	 "system_prompt": "You narrate slow-burning horror stories ...",
+//	 "examples": [{"input": "Topic: \"Abandoned lighthouses\" ...",
+//	               "output": {"intro": "...", "items": [...], "outro": "..."}}]}
+//
+// Requests (or brand kits) choose one with script_template=<id>. Examples
+// go to the model as earlier user and assistant turns, so each output
+// should be the JSON the route asks for; at most maxTemplateExamples.
+type ScriptTemplate struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name,omitempty"`
+	SystemPrompt string            `json:"system_prompt"`
+	Examples     []TemplateExample `json:"examples,omitempty"`
+
+	Pronunciations Lexicon `json:"pronunciations,omitempty"` // see pronounce.go
+	Ambience       string  `json:"ambience,omitempty"`       // see ambience.go
+}
+
+type TemplateExample struct {
+	Input  string          `json:"input"`
+	Output json.RawMessage `json:"output"`
+}
+
+const maxTemplateExamples = 5
+
+// slugPattern is what client-chosen IDs (templates, series) may look like.
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func scriptTemplateDir() string {
+	if dir := os.Getenv("SCRIPT_TEMPLATE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join("data", "script_templates")
+}
+
+func loadScriptTemplate(id string) (*ScriptTemplate, error) {
+	if !slugPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid script_template id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(scriptTemplateDir(), id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("script template %s not found", id)
+	}
+	var t ScriptTemplate
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("script template %s: %v", id, err)
+	}
+	t.ID = id
+	return &t, t.validate()
+}
+
+func (t *ScriptTemplate) validate() error {
+	if strings.TrimSpace(t.SystemPrompt) == "" && len(t.Examples) == 0 {
+		return fmt.Errorf("script template needs a system_prompt or examples")
+	}
+	if len(t.Examples) > maxTemplateExamples {
+		return fmt.Errorf("script template has %d examples (max %d)", len(t.Examples), maxTemplateExamples)
+	}
+	for i, ex := range t.Examples {
+		if strings.TrimSpace(ex.Input) == "" || !json.Valid(ex.Output) {
+			return fmt.Errorf("example %d needs an input and a JSON output", i+1)
+		}
+	}
+	if err := checkAmbience(t.Ambience); err != nil {
+		return err
+	}
+	return t.Pronunciations.validate()
+}
+
+// messages puts the system prompt and examples ahead of prompt.
+func (t *ScriptTemplate) messages(prompt string) []openai.ChatCompletionMessage {
+	var msgs []openai.ChatCompletionMessage
+	if t != nil {
+		if t.SystemPrompt != "" {
+			msgs = append(msgs, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: t.SystemPrompt})
+		}
+		for _, ex := range t.Examples {
+			msgs = append(msgs,
+				openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: ex.Input},
+				openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: string(ex.Output)})
+		}
+	}
+	return append(msgs, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: prompt})
+}
+
+// POST /script-templates (JSON body as above)
+func handleCreateScriptTemplate(c *gin.Context) {
+	var t ScriptTemplate
+	if err := c.ShouldBindJSON(&t); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	t.ID = strings.ToLower(strings.TrimSpace(t.ID))
+	if !slugPattern.MatchString(t.ID) {
+		c.JSON(400, gin.H{"error": "id must be lowercase letters, digits, - or _"})
+		return
+	}
+	if err := t.validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	os.MkdirAll(scriptTemplateDir(), 0755)
+	data, _ := json.MarshalIndent(t, "", "  ")
+	if err := os.WriteFile(filepath.Join(scriptTemplateDir(), t.ID+".json"), data, 0644); err != nil {
+		c.JSON(500, gin.H{"error": "Could not save script template"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "success", "script_template": t})
+}
+
+// GET /script-templates
+func handleListScriptTemplates(c *gin.Context) {
+	names, _ := filepath.Glob(filepath.Join(scriptTemplateDir(), "*.json"))
+	sort.Strings(names)
+	templates := []gin.H{}
+	for _, name := range names {
+		if t, err := loadScriptTemplate(strings.TrimSuffix(filepath.Base(name), ".json")); err == nil {
+			templates = append(templates, gin.H{"id": t.ID, "name": t.Name, "examples": len(t.Examples)})
+		}
+	}
+	c.JSON(200, gin.H{"script_templates": templates})
+}
+
+// GET /script-templates/:id
+func handleGetScriptTemplate(c *gin.Context) {
+	t, err := loadScriptTemplate(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"script_template": t})
+}
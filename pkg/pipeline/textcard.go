@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strings"
+)
+
+// --- TEXT CARDS ---
+// Text cards lay out a block of text on the card background server-side:
+// the body is wrapped to the card width and shrunk until it fits, with
+// optional smaller header and footer lines and an oversized mark (such as a
+// quote sign) above. Every line is its own drawtext so lines can be centred
+// individually; ffmpeg's multi-line text only centres the block.
+var textPanel = color.NRGBA{0x00, 0x00, 0x00, 0x66}
+
+type TextCard struct {
+	Mark   string // oversized glyph above the text, e.g. "“"
+	Header string // small line above the body
+	Body   string // wrapped and shrunk to fit
+	Footer string // small line below the body
+	Size   int    // starting body font size; 0 picks min(w, h)/18
+	Panel  bool   // translucent rounded panel behind the text
+	Center bool   // centre lines instead of aligning them left
+}
+
+// renderTextCard writes tc as a frame-sized jpg to dest.
+func renderTextCard(tc TextCard, dest, videoType string, card CardStyle) error {
+	w, h := frameSize(videoType)
+	panelW := w * 86 / 100
+	if videoType == "long" {
+		panelW = w * 70 / 100
+	}
+	size := tc.Size
+	if size == 0 {
+		size = min(w, h) / 18
+	}
+	var lines []string
+	for {
+		lines = strings.Split(wrapWords(tc.Body, max((panelW-2*size)*10/(size*6), 10)), "\n")
+		if len(lines)*size*13/10 <= h*6/10 || size <= min(w, h)/40 {
+			break
+		}
+		size = size * 9 / 10
+	}
+	small, pad, lineH := size*3/4, size, size*13/10
+
+	// Each entry is one drawtext: text, font size, opacity and top offset.
+	type textLine struct {
+		text  string
+		size  int
+		alpha float64
+		y     int
+	}
+	var layout []textLine
+	y := 0
+	if tc.Mark != "" {
+		layout = append(layout, textLine{tc.Mark, size * 3, 0.5, y})
+		y += size * 2
+	}
+	if tc.Header != "" {
+		layout = append(layout, textLine{tc.Header, small, 0.7, y})
+		y += small + pad/2
+	}
+	for _, line := range lines {
+		layout = append(layout, textLine{line, size, 1, y})
+		y += lineH
+	}
+	if tc.Footer != "" {
+		y += pad / 2
+		layout = append(layout, textLine{tc.Footer, small, 0.8, y})
+		y += small
+	}
+
+	top := (h - y) / 2
+	img := cardBackground(w, h, card)
+	if tc.Panel {
+		panel := image.Rect((w-panelW)/2, top-pad, (w+panelW)/2, top+y+pad)
+		fillRoundedRect(img, panel, size, textPanel)
+	}
+	bgPath := dest + ".bg.png"
+	if err := writePNG(bgPath, img); err != nil {
+		return err
+	}
+	defer os.Remove(bgPath)
+
+	font := escapeFilterArg(fontForText(tc.Body))
+	x := fmt.Sprint((w-panelW)/2 + pad)
+	if tc.Center {
+		x = "(w-text_w)/2"
+	}
+	shadow := ":shadowcolor=black@0.4:shadowx=2:shadowy=2"
+	if tc.Panel {
+		shadow = ""
+	}
+	var filters []string
+	for i, l := range layout {
+		textPath := fmt.Sprintf("%s.%d.txt", dest, i)
+		os.WriteFile(textPath, []byte(l.text), 0644)
+		defer os.Remove(textPath)
+		filters = append(filters, fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s@%.1f:x=%s:y=%d%s",
+			font, escapeFilterArg(textPath), l.size, card.TextColor, l.alpha, x, top+l.y, shadow))
+	}
+	cmd := ffmpegCommand("-y", "-i", bgPath, "-vf", strings.Join(filters, ","), "-frames:v", "1", "-q:v", "2", dest)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("text card: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+// fillRoundedRect fills r with corners of the given radius, row by row so
+// translucent colors blend once.
+func fillRoundedRect(img *image.RGBA, r image.Rectangle, radius int, c color.Color) {
+	radius = min(radius, r.Dx()/2, r.Dy()/2)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		inset := 0
+		if dy := max(r.Min.Y+radius-y, y-(r.Max.Y-1-radius)); dy > 0 {
+			inset = radius - int(math.Sqrt(float64(radius*radius-dy*dy)))
+		}
+		fillRect(img, image.Rect(r.Min.X+inset, y, r.Max.X-inset, y+1), c)
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- SCRUB THUMBNAILS ---
+// thumbnails=true tiles a frame every thumbnail_interval seconds (default 5)
+// of the final video into one JPEG sprite sheet and writes a WebVTT track
+// that points into it with #xywh fragments, the format web players (video.js,
+// Plyr, JW Player) read for scrubbing previews:
+//
+//	00:00:05.000 --> 00:00:10.000
+//	thumbnails.jpg#xywh=160,0,160,90
+//
+// Long videos get a wider interval so the sheet stays under maxThumbTiles.
+const (
+	thumbWidth           = 160
+	thumbColumns         = 10
+	maxThumbTiles        = 200
+	defaultThumbInterval = 5.0
+)
+
+// writeScrubThumbnails renders the sprite sheet and its WebVTT track next to
+// each other; the track refers to the sheet by file name.
+func writeScrubThumbnails(video, spritePath, vttPath string, interval float64) error {
+	info, err := probeMediaInfo(video)
+	if err != nil {
+		return err
+	}
+	if info.Duration <= 0 || info.Width <= 0 || info.Height <= 0 {
+		return fmt.Errorf("cannot probe %s", filepath.Base(video))
+	}
+	if interval <= 0 {
+		interval = defaultThumbInterval
+	}
+	if info.Duration/interval > maxThumbTiles {
+		interval = math.Ceil(info.Duration / maxThumbTiles)
+	}
+	count := int(math.Ceil(info.Duration / interval))
+	tw, th := thumbWidth, thumbWidth*info.Height/info.Width/2*2
+	cols := min(count, thumbColumns)
+	rows := (count + cols - 1) / cols
+
+	vf := fmt.Sprintf("fps=1/%g,scale=%d:%d,tile=%dx%d", interval, tw, th, cols, rows)
+	cmd := ffmpegCommand("-y", "-i", video, "-vf", vf, "-frames:v", "1", "-q:v", "4", spritePath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sprite sheet failed: %v | Log: %s", err, string(output))
+	}
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	name := filepath.Base(spritePath)
+	for i := 0; i < count; i++ {
+		start := float64(i) * interval
+		end := math.Min(start+interval, info.Duration)
+		fmt.Fprintf(&b, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n", vttTime(start), vttTime(end), name, i%cols*tw, i/cols*th, tw, th)
+	}
+	return os.WriteFile(vttPath, []byte(b.String()), 0644)
+}
+
+func vttTime(sec float64) string {
+	ms := int(sec*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// thumbnailURLs returns the sprite and track URLs for the response, or nil
+// when they were not requested or could not be made.
+func thumbnailURLs(base string, job *Job, opts FinishOptions) gin.H {
+	if opts.ThumbnailVTT == "" {
+		return nil
+	}
+	if _, err := os.Stat(opts.ThumbnailVTT); err != nil {
+		return nil
+	}
+	return gin.H{"sprite_url": job.URL(base, opts.ThumbnailSprite), "vtt_url": job.URL(base, opts.ThumbnailVTT)}
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- TIMELINE ---
+// A Timeline describes one ffmpeg run as tracks over its inputs and compiles
+// to the inputs, the filter graph and the stream maps; the caller adds the
+// encoder flags and output. The video track joins its clips in order, cut or
+// crossfaded, then overlay layers composite on top and the caption filters
+// (grade, citations, timers, subtitles) burn over the whole picture. The
+// narration track joins like the video track, the music bed is mixed
+// under it and the master filters run over the mix. Segment renders, the
+// filtered stitch and the music mix all compose this way so new layers
+// slot in without hand-numbered labels.
+type Timeline struct {
+	inputs [][]string
+
+	Video     Track
+	Overlays  []Layer
+	Captions  []string // filters over the composite, applied in order
+	Narration Track
+	Music     *MusicBed
+	Master    []string // filters over the mixed audio, see mastering.go
+}
+
+// Track is a sequence of clips. A clip's Duration is needed for offsets
+// when a crossfade follows it.
+type Track struct {
+	Clips []Clip
+}
+
+type Clip struct {
+	Input      int    // see Timeline.Input
+	Filter     string // chain over the clip's stream, may be empty
+	Duration   float64
+	Transition string // into this clip: "" or "cut" concatenates, else an xfade transition
+}
+
+// Layer composites input Input over the picture; Compose returns the graph
+// from [in] to [out], like chromaKeyGraph and overlayGraph.
+type Layer struct {
+	Input   int
+	Compose func(idx int, in, out string) string
+}
+
+// MusicBed is mixed under the narration for the narration's length.
+type MusicBed struct {
+	Input  int
+	Filter string // e.g. volume and fade
+}
+
+// Input adds a file with its input options (such as -stream_loop -1) and
+// returns its index.
+func (t *Timeline) Input(path string, opts ...string) int {
+	t.inputs = append(t.inputs, append(append([]string{}, opts...), "-i", path))
+	return len(t.inputs) - 1
+}
+
+// Args compiles the timeline to ffmpeg input, filter and map arguments.
+func (t *Timeline) Args() ([]string, error) {
+	var args, graph []string
+	for _, in := range t.inputs {
+		args = append(args, in...)
+	}
+	if len(t.Video.Clips) == 0 {
+		return nil, fmt.Errorf("timeline: empty video track")
+	}
+	video := t.Video.compile(&graph, "v")
+	for i, l := range t.Overlays {
+		out := fmt.Sprintf("layer%d", i)
+		graph = append(graph, l.Compose(l.Input, video, out))
+		video = out
+	}
+	if len(t.Captions) > 0 {
+		graph = append(graph, fmt.Sprintf("[%s]%s[vout]", video, strings.Join(t.Captions, ",")))
+		video = "vout"
+	}
+
+	audio := ""
+	if len(t.Narration.Clips) > 0 {
+		audio = t.Narration.compile(&graph, "a")
+		if t.Music != nil {
+			graph = append(graph, fmt.Sprintf("[%d:a]%s[m];[%s][m]amix=inputs=2:duration=first:dropout_transition=0:normalize=0[aout]",
+				t.Music.Input, t.Music.Filter, audio))
+			audio = "aout"
+		}
+		if len(t.Master) > 0 {
+			graph = append(graph, fmt.Sprintf("[%s]%s[amaster]", audio, strings.Join(t.Master, ",")))
+			audio = "amaster"
+		}
+	}
+
+	if len(graph) > 0 {
+		args = append(args, "-filter_complex", strings.Join(graph, ";"))
+	}
+	args = append(args, "-map", streamMap(video))
+	if audio != "" {
+		args = append(args, "-map", streamMap(audio))
+	}
+	return args, nil
+}
+
+// compile adds the track's clips and joints to graph and returns the label
+// of the joined stream. kind is "v" or "a".
+func (tr Track) compile(graph *[]string, kind string) string {
+	refs := make([]string, len(tr.Clips))
+	for i, c := range tr.Clips {
+		refs[i] = fmt.Sprintf("%d:%s", c.Input, kind)
+		if c.Filter != "" {
+			label := fmt.Sprintf("%s%d", kind, i)
+			*graph = append(*graph, fmt.Sprintf("[%s]%s[%s]", refs[i], c.Filter, label))
+			refs[i] = label
+		}
+	}
+
+	// total tracks the running length of the joined stream for xfade offsets.
+	prev, total := refs[0], tr.Clips[0].Duration
+	for i := 1; i < len(tr.Clips); i++ {
+		c := tr.Clips[i]
+		out := fmt.Sprintf("%sx%d", kind, i)
+		cut := c.Transition == "" || c.Transition == "cut"
+		switch {
+		case cut && kind == "v":
+			*graph = append(*graph, fmt.Sprintf("[%s][%s]concat=n=2:v=1:a=0[%s]", prev, refs[i], out))
+		case cut:
+			*graph = append(*graph, fmt.Sprintf("[%s][%s]concat=n=2:v=0:a=1[%s]", prev, refs[i], out))
+		case kind == "v":
+			*graph = append(*graph, fmt.Sprintf("[%s][%s]xfade=transition=%s:duration=%.2f:offset=%.3f[%s]",
+				prev, refs[i], c.Transition, transitionDuration, total-transitionDuration, out))
+		default:
+			*graph = append(*graph, fmt.Sprintf("[%s][%s]acrossfade=d=%.2f[%s]", prev, refs[i], transitionDuration, out))
+		}
+		total += c.Duration
+		if !cut {
+			total -= transitionDuration
+		}
+		prev = out
+	}
+	return prev
+}
+
+// streamMap is the -map argument for a compiled stream: an input stream
+// such as 0:v as is, a graph label in brackets.
+func streamMap(ref string) string {
+	if strings.Contains(ref, ":") {
+		return ref
+	}
+	return "[" + ref + "]"
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+)
+
+// --- TIMER OVERLAYS ---
+// timer=countdown counts the seconds down to the end of a scene, for a
+// reveal: over the whole scene, or its last timer_from seconds. timer=
+// stopwatch shows a running mm:ss.t clock for challenge videos. Either can be
+// set for the request or per scene (scenes[i].timer, "none" turns it off);
+// intro and outro never show one. The clock is a drawtext expansion of the
+// segment timestamp, so it follows the rendered timeline exactly.
+var timerKinds = map[string]bool{"none": true, "countdown": true, "stopwatch": true}
+
+const maxTimerFrom = 60
+
+func checkTimer(where, kind string, from float64) error {
+	if kind != "" && !timerKinds[kind] {
+		return fmt.Errorf("%s: unknown timer %q", where, kind)
+	}
+	if from < 0 || from > maxTimerFrom {
+		return fmt.Errorf("%s: timer_from must be between 0 and %d seconds", where, maxTimerFrom)
+	}
+	return nil
+}
+
+// timerFilter returns the drawtext for opts' timer on a segment lasting
+// duration seconds, in the top-right corner inside the safe area. The
+// expansion goes in a textfile, where its ':' separators need no escaping.
+func timerFilter(opts RenderOptions, duration float64, textPath string, w, h int, safe Margins) (string, error) {
+	var text, enable string
+	switch opts.Timer {
+	case "countdown":
+		text = fmt.Sprintf("%%{eif:ceil(%.3f-t):d}", duration)
+		if opts.TimerFrom > 0 && opts.TimerFrom < duration {
+			enable = fmt.Sprintf(":enable='gte(t,%.3f)'", duration-opts.TimerFrom)
+		}
+	case "stopwatch":
+		// mod() would need an escaped comma, so the remainders are spelled out.
+		t := "t"
+		if opts.TimerOffset > 0 {
+			t = fmt.Sprintf("(t+%.3f)", opts.TimerOffset)
+		}
+		text = fmt.Sprintf("%%{eif:floor(%[1]s/60):d:2}:%%{eif:floor(%[1]s)-60*floor(%[1]s/60):d:2}.%%{eif:floor(%[1]s*10)-10*floor(%[1]s):d}", t)
+	default:
+		return "", nil
+	}
+	if err := os.WriteFile(textPath, []byte(text), 0644); err != nil {
+		return "", err
+	}
+	size := max(min(w, h)/14, 24)
+	return fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=white:box=1:boxcolor=black@0.5:boxborderw=%d:x=w-text_w-%d:y=%d%s",
+		escapeFilterArg(captionFont), escapeFilterArg(textPath), size, size/4, safe.Right+w/30, safe.Top+h/30, enable), nil
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// --- TIMINGS ---
+// Every job result carries "timings": the milliseconds spent in each
+// pipeline stage (upload, script, render, stitch, finish, ...) and, per
+// rendered segment, in its TTS call and its encode.
+//
+//	"timings": {"total_ms": 241000,
+//	  "stages": [{"stage": "upload", "ms": 900}, {"stage": "script", "ms": 3100}, ...],
+//	  "segments": [{"file": "seg_intro.mp4", "tts_ms": 1800, "encode_ms": 6400}, ...]}
+type Timings struct {
+	mu       sync.Mutex
+	stages   []StageTiming
+	segments []SegmentTiming
+	current  string // stage in progress
+	started  time.Time
+}
+
+type StageTiming struct {
+	Stage string `json:"stage"`
+	MS    int64  `json:"ms"`
+}
+
+type SegmentTiming struct {
+	File     string `json:"file"`
+	TTSMS    int64  `json:"tts_ms,omitempty"`
+	EncodeMS int64  `json:"encode_ms,omitempty"`
+}
+
+// Stage marks the start of a pipeline stage and ends the previous one; the
+// last stage ends when the timings are reported. Re-entering a stage adds
+// to its time.
+func (j *Job) Stage(name string) {
+	t := j.timings
+	t.mu.Lock()
+	t.endStage()
+	t.current, t.started = name, time.Now()
+	t.mu.Unlock()
+	j.stream.send("stage", map[string]string{"stage": name})
+	if j.progress != nil {
+		j.progress(Progress{JobID: j.ID, Stage: name})
+	}
+}
+
+// addStage records a stage measured elsewhere, e.g. the upload.
+func (t *Timings) addStage(name string, ms int64) {
+	t.mu.Lock()
+	t.stages = append(t.stages, StageTiming{Stage: name, MS: ms})
+	t.mu.Unlock()
+}
+
+func (t *Timings) endStage() {
+	if t.current == "" {
+		return
+	}
+	ms := time.Since(t.started).Milliseconds()
+	if i := slices.IndexFunc(t.stages, func(s StageTiming) bool { return s.Stage == t.current }); i >= 0 {
+		t.stages[i].MS += ms
+	} else {
+		t.stages = append(t.stages, StageTiming{Stage: t.current, MS: ms})
+	}
+	t.current = ""
+}
+
+// Record adds the time since start to segment's "tts" or "encode" entry.
+// It is a no-op on nil, so renders outside a job need no timings.
+func (t *Timings) Record(segment, kind string, start time.Time) {
+	if t == nil {
+		return
+	}
+	ms := time.Since(start).Milliseconds()
+	file := filepath.Base(segment)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := slices.IndexFunc(t.segments, func(s SegmentTiming) bool { return s.File == file })
+	if i < 0 {
+		t.segments = append(t.segments, SegmentTiming{File: file})
+		i = len(t.segments) - 1
+	}
+	switch kind {
+	case "tts":
+		t.segments[i].TTSMS += ms
+	case "encode":
+		t.segments[i].EncodeMS += ms
+	}
+}
+
+// TimingReport is the "timings" value of a job result.
+type TimingReport struct {
+	TotalMS  int64           `json:"total_ms"`
+	Stages   []StageTiming   `json:"stages"`
+	Segments []SegmentTiming `json:"segments"`
+}
+
+// Report ends the running stage and returns the timings so far.
+func (t *Timings) Report(total time.Duration) TimingReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endStage()
+	return TimingReport{
+		TotalMS:  total.Milliseconds(),
+		Stages:   append([]StageTiming{}, t.stages...),
+		Segments: append([]SegmentTiming{}, t.segments...),
+	}
+}
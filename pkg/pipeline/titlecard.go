@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// --- TITLE CARDS ---
+// A short card before a scene: the scene title over a blurred, darkened
+// still of its media. The card's audio is silence in the same format as the
+// scene segment so the stream-copy concat still applies.
+const (
+	minTitleCard = 1.0
+	maxTitleCard = 2.0
+)
+
+func renderTitleCard(title, mediaPath, sceneSeg, outPath string, opts RenderOptions) error {
+	w, h := frameSize(opts.VideoType)
+	rate, layout := probeAudioFormat(sceneSeg)
+
+	textPath := outPath + ".txt"
+	lineLen, size := 16, w/12
+	if opts.VideoType == "long" {
+		lineLen, size = 28, h/10
+	}
+	if err := os.WriteFile(textPath, []byte(wrapWords(title, lineLen)), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(textPath)
+
+	// Centered within the platform's safe area.
+	safe := safeMargins(opts.Platform, w, h)
+	still := fitFilter(mediaPath, w, h, "crop") + ",trim=end_frame=1,loop=loop=-1:size=1:start=0,setpts=N/" + fmt.Sprint(opts.frameRate()) + "/TB"
+	filter := fmt.Sprintf("[0:v]%s,boxblur=24:2,eq=brightness=-0.12,drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=white:line_spacing=%d:x=%d+(w-%d-text_w)/2:y=%d+(h-%d-text_h)/2:shadowcolor=black@0.5:shadowx=3:shadowy=3:alpha='min(1,t/0.3)',format=yuv420p[vout]",
+		still, escapeFilterArg(fontForText(title)), escapeFilterArg(textPath), size, size/4,
+		safe.Left, safe.Left+safe.Right, safe.Top, safe.Top+safe.Bottom)
+	if grade := gradeFilter(opts); grade != "" {
+		filter = strings.Replace(filter, ",format=yuv420p", ","+grade+",format=yuv420p", 1)
+	}
+
+	args := []string{"-y"}
+	if isVideoFile(mediaPath) {
+		args = append(args, "-i", mediaPath)
+	} else {
+		args = append(args, "-loop", "1", "-i", mediaPath)
+	}
+	args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("anullsrc=r=%s:cl=%s", rate, layout),
+		"-filter_complex", filter, "-map", "[vout]", "-map", "1:a",
+		"-t", fmt.Sprintf("%.2f", opts.TitleCard), "-r", fmt.Sprint(opts.frameRate()),
+		"-c:v", "libx264", "-preset", "ultrafast", "-c:a", "aac", "-b:a", "128k", outPath)
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("title card: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+// probeAudioFormat returns the sample rate and channel layout of the first
+// audio stream, defaulting to 44100 stereo.
+func probeAudioFormat(path string) (string, string) {
+	out, err := exec.Command("ffprobe", "-v", "error", "-select_streams", "a:0",
+		"-show_entries", "stream=sample_rate,channels", "-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+	fields := strings.Fields(string(out))
+	if err != nil || len(fields) < 2 {
+		return "44100", "stereo"
+	}
+	layout := "stereo"
+	if fields[1] == "1" {
+		layout = "mono"
+	}
+	return fields[0], layout
+}
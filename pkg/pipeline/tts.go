@@ -0,0 +1,272 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// --- TEXT TO SPEECH ---
+const defaultSentenceGap = 0.25 // seconds of silence between sentences
+
+type ttsChunk struct {
+	Path        string
+	SentenceEnd bool
+}
+
+var ttsProviders = map[string]bool{"google": true, "elevenlabs": true}
+
+// NarrationGap is a Google TTS run where some chunks got no audio even after
+// a retry. The segment fails rather than playing with narration missing;
+// strict_tts=true fails the whole job instead. Either way the lost text is
+// listed as "narration_lost" with the job's result.
+type NarrationGap struct {
+	Chunks int
+	Lost   []string
+}
+
+func (g *NarrationGap) Error() string {
+	return fmt.Sprintf("narration incomplete: %d of %d chunks failed", len(g.Lost), g.Chunks)
+}
+
+// LostNarration is the text a segment lost to TTS failures.
+type LostNarration struct {
+	Segment string   `json:"segment"`
+	Text    []string `json:"text"`
+}
+
+func (j *Job) narrationLost(segment string, text []string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.lostNarration = append(j.lostNarration, LostNarration{Segment: segment, Text: text})
+}
+
+func (j *Job) LostNarration() []LostNarration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]LostNarration(nil), j.lostNarration...)
+}
+
+// synthesizeSpeech narrates text into outFile with the segment's provider.
+func synthesizeSpeech(text, outFile string, opts RenderOptions) error {
+	text = speakable(opts.Lexicon.apply(text), opts.Locale)
+	switch opts.TTSProvider {
+	case "elevenlabs":
+		if err := elevenLabsTTS(text, outFile, opts.VoiceID, opts.TTSKey); err != nil {
+			return fmt.Errorf("ElevenLabs TTS failed: %v", err)
+		}
+		return nil
+	}
+
+	// FIX: Throttled Downloader
+	gap := defaultSentenceGap
+	if opts.SentenceGap > 0 {
+		gap = opts.SentenceGap
+	}
+	if err := downloadGoogleTTS_Smart(text, outFile, opts.Locale, gap); err != nil {
+		return fmt.Errorf("Google TTS failed: %w", err)
+	}
+	return nil
+}
+
+// Google TTS accents: the language goes in tl, the regional accent is picked
+// by which Google Translate domain serves the request.
+var googleLocales = map[string]struct{ Lang, Host string }{
+	"en":    {"en", "translate.googleapis.com"},
+	"en-us": {"en", "translate.googleapis.com"},
+	"en-gb": {"en", "translate.google.co.uk"},
+	"en-au": {"en", "translate.google.com.au"},
+	"en-in": {"en", "translate.google.co.in"},
+	"en-ca": {"en", "translate.google.ca"},
+	"hi":    {"hi", "translate.googleapis.com"},
+	"es":    {"es", "translate.google.es"},
+	"es-mx": {"es", "translate.google.com.mx"},
+	"fr":    {"fr", "translate.google.fr"},
+	"fr-ca": {"fr", "translate.google.ca"},
+	"pt-br": {"pt", "translate.google.com.br"},
+	"pt-pt": {"pt", "translate.google.pt"},
+	"de":    {"de", "translate.googleapis.com"},
+	"ar":    {"ar", "translate.googleapis.com"},
+	"bn":    {"bn", "translate.googleapis.com"},
+	"ta":    {"ta", "translate.googleapis.com"},
+	"ja":    {"ja", "translate.googleapis.com"},
+}
+
+func downloadGoogleTTS_Smart(text, outFile, locale string, gap float64) error {
+	loc, ok := googleLocales[strings.ToLower(locale)]
+	if !ok {
+		loc = googleLocales["en"]
+	}
+	chunks := splitText(text, 180)
+	var parts []ttsChunk
+	defer func() {
+		for _, p := range parts {
+			os.Remove(p.Path)
+		}
+	}()
+
+	gapErr := &NarrationGap{}
+	for i, chunk := range chunks {
+		chunk = strings.TrimSpace(chunk)
+		if len(chunk) < 2 {
+			continue
+		}
+		gapErr.Chunks++
+
+		// FIX: Add Delay to prevent Google 429/403 Errors
+		if i > 0 {
+			time.Sleep(250 * time.Millisecond)
+		}
+
+		partPath := fmt.Sprintf("%s.part%d.mp3", outFile, i)
+		err := fetchTTSChunk(loc.Host, loc.Lang, chunk, partPath)
+		if err != nil {
+			time.Sleep(time.Second)
+			err = fetchTTSChunk(loc.Host, loc.Lang, chunk, partPath)
+		}
+		if err != nil {
+			os.Remove(partPath)
+			fmt.Printf("⚠️ TTS chunk lost (%v): %q\n", err, chunk)
+			gapErr.Lost = append(gapErr.Lost, chunk)
+			continue
+		}
+		end := strings.HasSuffix(chunk, ".") || strings.HasSuffix(chunk, "!") || strings.HasSuffix(chunk, "?")
+		parts = append(parts, ttsChunk{Path: partPath, SentenceEnd: end})
+	}
+	if len(gapErr.Lost) > 0 {
+		return gapErr
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("no text to narrate")
+	}
+
+	if err := joinTTSChunks(parts, gap, outFile); err != nil {
+		fmt.Printf("⚠️ TTS normalization failed, using raw chunks: %v\n", err)
+		return concatRaw(parts, outFile)
+	}
+	return nil
+}
+
+// fetchTTSChunk downloads one chunk's audio to partPath. Anything but a 200
+// with a body counts as a failure.
+func fetchTTSChunk(host, lang, chunk, partPath string) error {
+	safeText := url.QueryEscape(chunk)
+	ttsUrl := fmt.Sprintf("https://%s/translate_tts?client=gtx&ie=UTF-8&tl=%s&dt=t&q=%s", host, lang, safeText)
+
+	req, _ := http.NewRequest("GET", ttsUrl, nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	f, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, resp.Body)
+	f.Close()
+	if err == nil && n == 0 {
+		err = fmt.Errorf("empty audio")
+	}
+	return err
+}
+
+// joinTTSChunks trims each chunk's leading/trailing silence, inserts a fixed
+// gap after every sentence (a short breath inside split sentences) and
+// loudness-normalizes the result.
+func joinTTSChunks(parts []ttsChunk, gap float64, outFile string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("no audio chunks")
+	}
+	trim := "silenceremove=start_periods=1:start_threshold=-45dB:start_silence=0.02"
+	args := []string{"-y"}
+	var graph strings.Builder
+	for i, p := range parts {
+		args = append(args, "-i", p.Path)
+		pad := gap
+		if !p.SentenceEnd {
+			pad = 0.05
+		}
+		if i == len(parts)-1 {
+			pad = 0
+		}
+		fmt.Fprintf(&graph, "[%d:a]aresample=24000,%s,areverse,%s,areverse,apad=pad_dur=%.3f[c%d];", i, trim, trim, pad, i)
+	}
+	for i := range parts {
+		fmt.Fprintf(&graph, "[c%d]", i)
+	}
+	fmt.Fprintf(&graph, "concat=n=%d:v=0:a=1,loudnorm=I=-16:TP=-1.5:LRA=11[out]", len(parts))
+
+	args = append(args, "-filter_complex", graph.String(), "-map", "[out]", "-c:a", "libmp3lame", "-b:a", "128k", outFile)
+	if output, err := ffmpegCommand(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+// concatRaw appends the MP3 chunks byte-for-byte, the original behavior.
+func concatRaw(parts []ttsChunk, outFile string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("no audio chunks")
+	}
+	finalFile, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer finalFile.Close()
+	for _, p := range parts {
+		if f, err := os.Open(p.Path); err == nil {
+			io.Copy(finalFile, f)
+			f.Close()
+		}
+	}
+	return nil
+}
+
+func splitText(text string, limit int) []string {
+	var chunks []string
+	sentences := strings.Split(text, ". ")
+
+	for _, s := range sentences {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if !strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "!") && !strings.HasSuffix(s, "?") {
+			s += "."
+		}
+
+		if len(s) <= limit {
+			chunks = append(chunks, s)
+		} else {
+			words := strings.Fields(s)
+			var currentChunk strings.Builder
+			for _, word := range words {
+				if currentChunk.Len()+len(word)+1 <= limit {
+					if currentChunk.Len() > 0 {
+						currentChunk.WriteString(" ")
+					}
+					currentChunk.WriteString(word)
+				} else {
+					chunks = append(chunks, currentChunk.String())
+					currentChunk.Reset()
+					currentChunk.WriteString(word)
+				}
+			}
+			if currentChunk.Len() > 0 {
+				chunks = append(chunks, currentChunk.String())
+			}
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// --- UNSPLASH ---
+// The "unsplash" media source is a stock provider like Pexels, searched
+// with UNSPLASH_ACCESS_KEY. Its API guidelines are followed for every photo
+// used: the download is reported to Unsplash, and the source lists the
+// photographer and photo with links carrying utm_source (UNSPLASH_APP_NAME)
+// for the "Photo by X on Unsplash" credit. unsplash_credit=true draws that
+// credit on the scenes showing Unsplash photos, even without citations.
+type UnsplashSearchResponse struct {
+	Results []struct {
+		Description    string `json:"description"`
+		AltDescription string `json:"alt_description"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		URLs           struct {
+			Full string `json:"full"`
+		} `json:"urls"`
+		Links struct {
+			HTML             string `json:"html"`
+			DownloadLocation string `json:"download_location"`
+		} `json:"links"`
+		User struct {
+			Name  string `json:"name"`
+			Links struct {
+				HTML string `json:"html"`
+			} `json:"links"`
+		} `json:"user"`
+	} `json:"results"`
+}
+
+func downloadUnsplashPhoto(query, scene, dest, videoType string) (Source, error) {
+	apiKey := os.Getenv("UNSPLASH_ACCESS_KEY")
+	if apiKey == "" {
+		return Source{}, fmt.Errorf("missing key")
+	}
+	orientation := "portrait"
+	if videoType == "long" {
+		orientation = "landscape"
+	}
+	searchUrl := fmt.Sprintf("https://api.unsplash.com/search/photos?query=%s&per_page=%d&orientation=%s&content_filter=high", url.QueryEscape(query), stockCandidates(), orientation)
+	resp, err := unsplashGet(searchUrl, apiKey)
+	if err != nil {
+		return Source{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return Source{}, fmt.Errorf("unsplash status %d", resp.StatusCode)
+	}
+	var res UnsplashSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Source{}, err
+	}
+	if len(res.Results) == 0 {
+		return Source{}, fmt.Errorf("not found")
+	}
+	results := res.Results[:0]
+	for _, r := range res.Results {
+		if err := mediaQualityOK(r.Width, r.Height); err == nil {
+			results = append(results, r)
+		}
+	}
+	if len(results) == 0 {
+		return Source{}, fmt.Errorf("no result passes the quality gate")
+	}
+	descriptions := make([]string, len(results))
+	for i, r := range results {
+		descriptions[i] = strings.TrimSpace(r.Description + " " + r.AltDescription)
+	}
+	photo := results[rankCandidates(scene, descriptions)]
+	if err := downloadFile(photo.URLs.Full+"&fm=jpg&q=85", dest); err != nil {
+		return Source{}, err
+	}
+	// Required by the API guidelines whenever a photo is used.
+	if resp, err := unsplashGet(photo.Links.DownloadLocation, apiKey); err == nil {
+		resp.Body.Close()
+	} else {
+		fmt.Printf("⚠️ Unsplash download not reported: %v\n", err)
+	}
+	return Source{Provider: "Unsplash", Author: photo.User.Name, URL: unsplashLink(photo.Links.HTML),
+		AuthorURL: unsplashLink(photo.User.Links.HTML)}, nil
+}
+
+func unsplashGet(apiURL, apiKey string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Client-ID "+apiKey)
+	req.Header.Set("Accept-Version", "v1")
+	return http.DefaultClient.Do(req)
+}
+
+// unsplashLink adds the referral parameters Unsplash asks credits to carry.
+func unsplashLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || link == "" {
+		return link
+	}
+	app := os.Getenv("UNSPLASH_APP_NAME")
+	if app == "" {
+		app = "vixio"
+	}
+	q := u.Query()
+	q.Set("utm_source", app)
+	q.Set("utm_medium", "referral")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
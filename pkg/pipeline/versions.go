@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- VERSIONS ---
+// A re-render (a retry, or an edited spec sent to /v1/render-spec) runs as
+// a new job, so earlier outputs are never overwritten; it is recorded as the
+// next version of the original job. The original is v1 and keeps the
+// versions.json list; each later version's workspace has version.json
+// pointing back to it. GET /v1/jobs/:id/versions (any version's ID) lists
+// them with what changed against the version each was made from, segment
+// by segment from their render specs.
+type JobVersion struct {
+	Version  int          `json:"version"`
+	JobID    string       `json:"job_id"`
+	Parent   string       `json:"parent,omitempty"`
+	Created  string       `json:"created"`
+	VideoURL string       `json:"video_url,omitempty"`
+	Changes  *SpecChanges `json:"changes,omitempty"` // nil when either spec is missing
+}
+
+// SpecChanges summarizes how a render spec differs from its parent's.
+type SpecChanges struct {
+	Summary  string          `json:"summary"`
+	Segments []SegmentChange `json:"segments,omitempty"`
+	Render   []string        `json:"render,omitempty"` // changed job-wide fields, e.g. type, fps
+}
+
+type SegmentChange struct {
+	Index  int      `json:"index"`
+	Title  string   `json:"title,omitempty"`
+	Change string   `json:"change"`           // changed, added or removed
+	Fields []string `json:"fields,omitempty"` // spec fields that differ
+}
+
+// versionsMu serializes updates to every versions.json.
+var versionsMu sync.Mutex
+
+type versionLink struct {
+	Root    string `json:"root"`
+	Version int    `json:"version"`
+}
+
+// versionRoot is the original job of id's version chain.
+func versionRoot(id string) string {
+	var link versionLink
+	if data, err := os.ReadFile(filepath.Join("output", "jobs", id, "version.json")); err == nil && json.Unmarshal(data, &link) == nil && link.Root != "" {
+		return link.Root
+	}
+	return id
+}
+
+// addVersion records the job as the next version of its parent's chain.
+func (j *Job) addVersion(videoURL string) (JobVersion, error) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	root, _, err := openJob(versionRoot(j.parentJob))
+	if err != nil {
+		return JobVersion{}, err
+	}
+	versions := loadVersions(root)
+	v := JobVersion{Version: len(versions) + 1, JobID: j.ID, Parent: j.parentJob,
+		Created: time.Now().UTC().Format(time.RFC3339), VideoURL: videoURL}
+	if parent, _, err := openJob(j.parentJob); err == nil {
+		v.Changes = diffSpecs(parent.Path("spec.json"), j.Path("spec.json"))
+	}
+	data, _ := json.MarshalIndent(append(versions, v), "", "  ")
+	if err := os.WriteFile(root.Path("versions.json"), data, 0644); err != nil {
+		return JobVersion{}, err
+	}
+	link, _ := json.Marshal(versionLink{Root: root.ID, Version: v.Version})
+	os.WriteFile(j.Path("version.json"), link, 0644)
+	fmt.Printf("🗂️ Job %s saved as v%d of %s\n", j.ID, v.Version, root.ID)
+	return v, nil
+}
+
+// loadVersions returns root's versions, starting with the root itself as v1.
+func loadVersions(root *Job) []JobVersion {
+	var versions []JobVersion
+	if data, err := os.ReadFile(root.Path("versions.json")); err == nil && json.Unmarshal(data, &versions) == nil && len(versions) > 0 {
+		return versions
+	}
+	v1 := JobVersion{Version: 1, JobID: root.ID}
+	if info, err := os.Stat(root.Dir); err == nil {
+		v1.Created = info.ModTime().UTC().Format(time.RFC3339)
+	}
+	if _, result, _ := openJob(root.ID); result != nil {
+		v1.VideoURL, _ = result["video_url"].(string)
+	}
+	return []JobVersion{v1}
+}
+
+// diffSpecs compares two spec.json files, nil if either can't be read.
+func diffSpecs(before, after string) *SpecChanges {
+	var a, b RenderSpec
+	if !readSpec(before, &a) || !readSpec(after, &b) {
+		return nil
+	}
+	changes := &SpecChanges{}
+	if a.Type != b.Type {
+		changes.Render = append(changes.Render, "type")
+	}
+	if a.FPS != b.FPS {
+		changes.Render = append(changes.Render, "fps")
+	}
+	if a.Sting != b.Sting || a.BrandName != b.BrandName {
+		changes.Render = append(changes.Render, "sting")
+	}
+	for i := 0; i < max(len(a.Segments), len(b.Segments)); i++ {
+		switch {
+		case i >= len(a.Segments):
+			changes.Segments = append(changes.Segments, SegmentChange{Index: i, Title: b.Segments[i].Title, Change: "added"})
+		case i >= len(b.Segments):
+			changes.Segments = append(changes.Segments, SegmentChange{Index: i, Title: a.Segments[i].Title, Change: "removed"})
+		default:
+			if fields := specFieldChanges(a.Segments[i], b.Segments[i]); len(fields) > 0 {
+				changes.Segments = append(changes.Segments, SegmentChange{Index: i, Title: b.Segments[i].Title, Change: "changed", Fields: fields})
+			}
+		}
+	}
+	changes.Summary = fmt.Sprintf("%d of %d segments changed", len(changes.Segments), len(b.Segments))
+	if len(changes.Segments) == 0 && len(changes.Render) == 0 {
+		changes.Summary = "no changes"
+	}
+	return changes
+}
+
+func readSpec(path string, spec *RenderSpec) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && json.Unmarshal(data, spec) == nil
+}
+
+// specFieldChanges lists the JSON fields that differ between two segments,
+// ignoring the measured duration.
+func specFieldChanges(a, b SegmentSpec) []string {
+	var ma, mb map[string]any
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	json.Unmarshal(da, &ma)
+	json.Unmarshal(db, &mb)
+	var fields []string
+	for k := range ma {
+		if _, ok := mb[k]; !ok {
+			mb[k] = nil
+		}
+	}
+	for k, v := range mb {
+		if k != "duration" && fmt.Sprint(ma[k]) != fmt.Sprint(v) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// GET /v1/jobs/:id/versions
+func handleListVersions(c *gin.Context) {
+	if _, _, err := openJob(c.Param("id")); err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	root, _, err := openJob(versionRoot(c.Param("id")))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	versionsMu.Lock()
+	versions := loadVersions(root)
+	versionsMu.Unlock()
+	c.JSON(200, gin.H{"job_id": root.ID, "versions": versions})
+}
@@ -0,0 +1,324 @@
+package pipeline
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- WEATHER MODE ---
+// POST /generate-weather-video renders one scene per city in
+// locations=Paris,London,Tokyo (at most 10) with a weather icon, the high
+// and low and the conditions for day=today|tomorrow. units=fahrenheit
+// switches from Celsius. Forecasts come from Open-Meteo, which needs no key.
+// The narration is written from the forecast directly rather than by the
+// LLM, so signage loops stay exact and cheap to regenerate.
+const maxLocations = 10
+
+type Forecast struct {
+	City      string
+	Country   string
+	Code      int // WMO weather code
+	High, Low float64
+	RainPct   int
+	WindKMH   float64
+}
+
+// weatherCondition maps WMO codes to a description and an icon kind.
+func weatherCondition(code int) (string, string) {
+	switch {
+	case code == 0:
+		return "clear skies", "sun"
+	case code <= 2:
+		return "partly cloudy skies", "partly"
+	case code == 3:
+		return "overcast skies", "cloud"
+	case code == 45 || code == 48:
+		return "fog", "fog"
+	case code >= 51 && code <= 57:
+		return "drizzle", "rain"
+	case code >= 61 && code <= 67, code >= 80 && code <= 82:
+		return "rain", "rain"
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return "snow", "snow"
+	case code >= 95:
+		return "thunderstorms", "storm"
+	}
+	return "mixed weather", "cloud"
+}
+
+func handleWeatherVideo(r *request) {
+	fmt.Println("\n🔹 STEP 1: Weather Request Received")
+
+	var cities []string
+	for _, l := range strings.Split(r.PostForm("locations"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			cities = append(cities, l)
+		}
+	}
+	if len(cities) == 0 || len(cities) > maxLocations {
+		r.JSON(400, gin.H{"error": fmt.Sprintf("locations must list 1 to %d cities", maxLocations)})
+		return
+	}
+	day := strings.ToLower(strings.TrimSpace(r.DefaultPostForm("day", "today")))
+	if day != "today" && day != "tomorrow" {
+		r.JSON(400, gin.H{"error": "day must be today or tomorrow"})
+		return
+	}
+	fahrenheit := strings.ToLower(r.PostForm("units")) == "fahrenheit"
+	videoType := strings.ToLower(strings.TrimSpace(r.PostForm("type")))
+	if videoType == "" {
+		videoType = "short"
+	}
+
+	job, err := newRequestJob(r)
+	if err != nil {
+		r.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := renderOptionsFromForm(r, job, videoType)
+	if err != nil {
+		r.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	fmt.Println("🔹 STEP 2: Fetching Forecasts...")
+	job.Stage("forecasts")
+	client := &http.Client{Timeout: newsTimeout}
+	var forecasts []Forecast
+	for _, city := range cities {
+		f, err := fetchForecast(client, city, day, fahrenheit)
+		if err != nil {
+			fmt.Printf("❌ CRITICAL ERROR (Weather): %v\n", err)
+			r.JSON(502, gin.H{"error": fmt.Sprintf("Could not fetch forecast for %s: %v", city, err)})
+			return
+		}
+		forecasts = append(forecasts, f)
+	}
+	job.AddSource("", Source{Provider: "Open-Meteo", Title: "Weather forecast", URL: "https://open-meteo.com/"})
+
+	topic := strings.TrimSpace(r.PostForm("topic"))
+	if topic == "" {
+		topic = "Weather " + day
+	}
+	fmt.Printf("🎬 Topic: %s | Mode: %s | Cities: %d\n", topic, videoType, len(forecasts))
+	job.Stage("script")
+	scriptData := weatherScript(forecasts, day, fahrenheit)
+	job.scriptReady(scriptData)
+
+	job.Stage("media")
+	introPath := placeholderCard(job, topic, 0, videoType, base.Card)
+	scenePaths := make([]string, len(forecasts))
+	for i, f := range forecasts {
+		path, err := job.Fetch(fmt.Sprintf("weather:%s:%s:%v", f.City, day, fahrenheit), ".jpg", func(dest string) error {
+			return renderWeatherCard(f, fahrenheit, dest, videoType, base.Card)
+		})
+		if err != nil {
+			fmt.Printf("⚠️ Weather card for %s failed: %v\n", f.City, err)
+			path = placeholderCard(job, scriptData.Items[i].Title, i+1, videoType, base.Card)
+		}
+		scenePaths[i] = path
+	}
+	outroPath := placeholderCard(job, "Thanks for watching!", 0, videoType, base.Card)
+
+	renderRoundup(r, job, 3, roundup{Name: "weather", Topic: topic, VideoType: videoType, Script: scriptData,
+		Intro: introPath, Scenes: scenePaths, Outro: outroPath, Base: base})
+}
+
+// fetchForecast geocodes city and loads its daily forecast for day.
+func fetchForecast(client *http.Client, city, day string, fahrenheit bool) (Forecast, error) {
+	var geo struct {
+		Results []struct {
+			Name      string  `json:"name"`
+			Country   string  `json:"country"`
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"results"`
+	}
+	q := url.Values{"name": {city}, "count": {"1"}, "language": {"en"}}
+	if err := callJSON(client, "GET", "https://geocoding-api.open-meteo.com/v1/search?"+q.Encode(), "", nil, &geo); err != nil {
+		return Forecast{}, err
+	}
+	if len(geo.Results) == 0 {
+		return Forecast{}, fmt.Errorf("unknown location")
+	}
+	place := geo.Results[0]
+
+	var res struct {
+		Daily struct {
+			Code    []int     `json:"weather_code"`
+			High    []float64 `json:"temperature_2m_max"`
+			Low     []float64 `json:"temperature_2m_min"`
+			RainPct []int     `json:"precipitation_probability_max"`
+			Wind    []float64 `json:"wind_speed_10m_max"`
+		} `json:"daily"`
+	}
+	q = url.Values{
+		"latitude": {fmt.Sprint(place.Latitude)}, "longitude": {fmt.Sprint(place.Longitude)}, "timezone": {"auto"}, "forecast_days": {"2"},
+		"daily": {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max,wind_speed_10m_max"},
+	}
+	if fahrenheit {
+		q.Set("temperature_unit", "fahrenheit")
+	}
+	if err := callJSON(client, "GET", "https://api.open-meteo.com/v1/forecast?"+q.Encode(), "", nil, &res); err != nil {
+		return Forecast{}, err
+	}
+	i := 0
+	if day == "tomorrow" {
+		i = 1
+	}
+	d := res.Daily
+	if len(d.Code) <= i || len(d.High) <= i || len(d.Low) <= i {
+		return Forecast{}, fmt.Errorf("no forecast for %s", day)
+	}
+	f := Forecast{City: place.Name, Country: place.Country, Code: d.Code[i], High: d.High[i], Low: d.Low[i]}
+	if len(d.RainPct) > i {
+		f.RainPct = d.RainPct[i]
+	}
+	if len(d.Wind) > i {
+		f.WindKMH = d.Wind[i]
+	}
+	return f, nil
+}
+
+// weatherScript narrates the forecasts: one item per city.
+func weatherScript(forecasts []Forecast, day string, fahrenheit bool) ScriptResponse {
+	unit := "degrees"
+	if fahrenheit {
+		unit = "degrees Fahrenheit"
+	}
+	script := ScriptResponse{
+		Intro: fmt.Sprintf("Here is the weather for %s across %d cities.", day, len(forecasts)),
+		Outro: "That's the forecast. Stay safe and have a great day!",
+		Mood:  "calm",
+	}
+	for _, f := range forecasts {
+		condition, _ := weatherCondition(f.Code)
+		text := fmt.Sprintf("In %s %s, expect %s, with a high of %.0f and a low of %.0f %s.",
+			f.City, day, condition, f.High, f.Low, unit)
+		if f.RainPct >= 30 {
+			text += fmt.Sprintf(" There is a %d percent chance of rain.", f.RainPct)
+		}
+		if f.WindKMH >= 40 {
+			text += " It will be windy, so hold on to your hat."
+		}
+		script.Items = append(script.Items, ScriptItem{Title: fmt.Sprintf("%s %.0f°/%.0f°", f.City, f.High, f.Low), Details: text})
+	}
+	return script
+}
+
+// renderWeatherCard draws the icon on the card background, then the city,
+// temperatures and conditions with drawtext.
+func renderWeatherCard(f Forecast, fahrenheit bool, dest, videoType string, card CardStyle) error {
+	w, h := frameSize(videoType)
+	img := cardBackground(w, h, card)
+	condition, icon := weatherCondition(f.Code)
+	drawWeatherIcon(img, icon, w/2, h*32/100, min(w, h)/5)
+
+	bgPath := dest + ".bg.png"
+	if err := writePNG(bgPath, img); err != nil {
+		return err
+	}
+	defer os.Remove(bgPath)
+	unit := "C"
+	if fahrenheit {
+		unit = "F"
+	}
+	lines := []string{f.City, fmt.Sprintf("%.0f°%s / %.0f°%s", f.High, unit, f.Low, unit), condition}
+	sizes := []int{min(w, h) / 11, min(w, h) / 8, min(w, h) / 18}
+	ys := []int{h * 56 / 100, h * 66 / 100, h * 78 / 100}
+	var filters []string
+	for i, line := range lines {
+		textPath := fmt.Sprintf("%s.%d.txt", dest, i)
+		os.WriteFile(textPath, []byte(line), 0644)
+		defer os.Remove(textPath)
+		filters = append(filters, fmt.Sprintf("drawtext=font='%s':textfile=%s:fontsize=%d:fontcolor=%s:x=(w-text_w)/2:y=%d:shadowcolor=black@0.4:shadowx=3:shadowy=3",
+			escapeFilterArg(fontForText(line)), escapeFilterArg(textPath), sizes[i], card.TextColor, ys[i]))
+	}
+	cmd := ffmpegCommand("-y", "-i", bgPath, "-vf", strings.Join(filters, ","), "-frames:v", "1", "-q:v", "2", dest)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("weather card: %v | Log: %s", err, string(output))
+	}
+	return nil
+}
+
+var (
+	iconSun   = color.RGBA{0xff, 0xc8, 0x2e, 0xff}
+	iconCloud = color.RGBA{0xe8, 0xec, 0xf2, 0xff}
+	iconDark  = color.RGBA{0x9a, 0xa3, 0xb0, 0xff}
+	iconRain  = color.RGBA{0x4f, 0xa3, 0xff, 0xff}
+	iconSnow  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// drawWeatherIcon draws icon (sun, partly, cloud, fog, rain, snow, storm)
+// centred on cx, cy within radius r.
+func drawWeatherIcon(img *image.RGBA, icon string, cx, cy, r int) {
+	switch icon {
+	case "sun":
+		drawSun(img, cx, cy, r)
+		return
+	case "partly":
+		drawSun(img, cx+r/3, cy-r/3, r*2/3)
+		drawCloud(img, cx-r/6, cy+r/6, r*3/4, iconCloud)
+		return
+	}
+	cloud := iconCloud
+	if icon == "rain" || icon == "storm" {
+		cloud = iconDark
+	}
+	drawCloud(img, cx, cy-r/4, r, cloud)
+	below := cy + r/2
+	switch icon {
+	case "fog":
+		for i := 0; i < 3; i++ {
+			y := below + i*r/5
+			fillRect(img, image.Rect(cx-r+i*r/6, y, cx+r-i*r/6, y+r/12), iconDark)
+		}
+	case "rain":
+		for i := -1; i <= 1; i++ {
+			x := cx + i*r/2
+			drawLine(img, x, below, x-r/8, below+r/2, max(r/12, 3), iconRain)
+		}
+	case "snow":
+		for i := -1; i <= 1; i++ {
+			fillCircle(img, cx+i*r/2, below+r/4+abs(i)*r/6, max(r/10, 3), iconSnow)
+		}
+	case "storm":
+		t := max(r/10, 4)
+		drawLine(img, cx+r/8, below-r/8, cx-r/8, below+r/4, t, iconSun)
+		drawLine(img, cx-r/8, below+r/4, cx+r/8, below+r/4, t, iconSun)
+		drawLine(img, cx+r/8, below+r/4, cx-r/6, below+r*2/3, t, iconSun)
+	}
+}
+
+func drawSun(img *image.RGBA, cx, cy, r int) {
+	for a := 0; a < 8; a++ {
+		angle := float64(a) * math.Pi / 4
+		x0, y0 := cx+int(float64(r)*0.75*math.Cos(angle)), cy+int(float64(r)*0.75*math.Sin(angle))
+		x1, y1 := cx+int(float64(r)*math.Cos(angle)), cy+int(float64(r)*math.Sin(angle))
+		drawLine(img, x0, y0, x1, y1, max(r/10, 3), iconSun)
+	}
+	fillCircle(img, cx, cy, r*6/10, iconSun)
+}
+
+// drawCloud draws a cloud about 2r wide from three circles and a flat base.
+func drawCloud(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	fillCircle(img, cx-r/2, cy+r/8, r*4/10, c)
+	fillCircle(img, cx+r/2, cy+r/8, r*4/10, c)
+	fillCircle(img, cx, cy-r/8, r*55/100, c)
+	fillRect(img, image.Rect(cx-r/2, cy+r/8, cx+r/2, cy+r/8+r*4/10), c)
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	for y := -r; y <= r; y++ {
+		dx := int(math.Sqrt(float64(r*r - y*y)))
+		fillRect(img, image.Rect(cx-dx, cy+y, cx+dx+1, cy+y+1), c)
+	}
+}
@@ -0,0 +1,318 @@
+package pipeline
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- WEBHOOKS ---
+// A job started with webhook_url receives lifecycle events as JSON POSTs:
+//
+//	job.created, job.script_ready, job.plan_ready, job.preview_ready,
+//	job.segment_rendered, job.ready_for_streaming, job.completed, job.failed
+//
+// webhook_url must be a public address (see checkPublicURL). webhook_events
+// (comma separated) narrows the set. Bodies are signed with
+// WEBHOOK_SECRET: X-Vixio-Signature is "sha256=" + hex HMAC-SHA256 of
+// "<X-Vixio-Timestamp>.<body>". Events are delivered in order by one worker
+// per job, retried with backoff, and every attempt is appended to the job's
+// webhooks.jsonl.
+var webhookRetryDelays = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+type webhook struct {
+	url    string
+	events map[string]bool // nil: all events
+	queue  chan webhookEvent
+}
+
+type webhookEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	JobID   string `json:"job_id"`
+	Created string `json:"created"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// newRequestJob creates the job for a generation request (or adopts
+// r.preset) and attaches the request's webhook, if any, so the job's events
+// reach it.
+func newRequestJob(r *request) (*Job, error) {
+	job := r.preset
+	if job == nil {
+		var err error
+		if job, err = newJob(); err != nil {
+			return nil, err
+		}
+	}
+	r.job = job
+	job.owner = keyOwner(r.GetHeader("X-Api-Key"))
+	job.progress = r.progress
+	if r.c != nil {
+		r.c.Set("job", job)
+		if ms, ok := r.c.Get(uploadMSKey); ok {
+			job.timings.addStage("upload", ms.(int64))
+		}
+	}
+	if r.PostForm("debug") == "true" {
+		job.debug = &Debug{}
+	}
+	job.seed = seedFromForm(r.PostForm("seed"))
+	if r.retry != nil {
+		job.loadReuse(*r.retry)
+	}
+	job.saveRequest(r)
+	if target := strings.TrimSpace(r.PostForm("webhook_url")); target != "" {
+		if err := checkPublicURL(target); err != nil {
+			fmt.Printf("⚠️ Webhook ignored: webhook_url %q: %v\n", target, err)
+		} else {
+			job.hook = &webhook{url: target, queue: make(chan webhookEvent, 256)}
+			if list := r.PostForm("webhook_events"); list != "" {
+				job.hook.events = map[string]bool{}
+				for _, e := range strings.Split(list, ",") {
+					job.hook.events[strings.TrimSpace(e)] = true
+				}
+			}
+			go job.deliverWebhooks()
+		}
+	}
+	job.Emit("job.created", gin.H{"path": r.path, "topic": r.PostForm("topic"), "type": r.PostForm("type")})
+	if r.c != nil {
+		job.openStream(r.c)
+	}
+	return job, nil
+}
+
+// Emit reports a lifecycle event to Pipeline.Progress and queues it for the
+// job's webhook. It never blocks the render: if the queue is full the
+// event is dropped and logged.
+func (j *Job) Emit(event string, data any) {
+	if j.progress != nil {
+		j.progress(Progress{JobID: j.ID, Event: event, Data: data})
+	}
+	if j.hook == nil || (j.hook.events != nil && !j.hook.events[event]) {
+		return
+	}
+	e := webhookEvent{ID: newUUID(), Type: event, JobID: j.ID, Created: time.Now().UTC().Format(time.RFC3339), Data: data}
+	select {
+	case j.hook.queue <- e:
+	default:
+		fmt.Printf("⚠️ Webhook queue full, dropped %s for job %s\n", event, j.ID)
+	}
+}
+
+func (j *Job) segmentRendered(index int, s Segment) {
+	j.Emit("job.segment_rendered", gin.H{"index": index, "title": s.Title, "duration": s.Duration, "file": filepath.Base(s.Path), "fallback": s.Fallback})
+}
+
+// closeWebhook ends delivery once the job's final event is queued.
+func (j *Job) closeWebhook() {
+	if j.hook != nil {
+		close(j.hook.queue)
+	}
+}
+
+func (j *Job) deliverWebhooks() {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		fmt.Println("⚠️ WEBHOOK_SECRET not set, webhooks are sent unsigned")
+	}
+	// The target was public when the job started; publicClient keeps it so.
+	client := &http.Client{Timeout: 10 * time.Second, Transport: publicClient.Transport, CheckRedirect: publicClient.CheckRedirect}
+	for e := range j.hook.queue {
+		body, _ := json.Marshal(e)
+		for attempt := 0; ; attempt++ {
+			status, err := postWebhook(client, j.hook.url, secret, body)
+			j.logDelivery(e, attempt+1, status, err)
+			if err == nil && status < 300 || errors.Is(err, errNotPublic) {
+				break
+			}
+			if attempt >= len(webhookRetryDelays) {
+				fmt.Printf("⚠️ Webhook %s for job %s gave up after %d attempts\n", e.Type, j.ID, attempt+1)
+				break
+			}
+			time.Sleep(webhookRetryDelays[attempt])
+		}
+	}
+}
+
+func postWebhook(client *http.Client, target, secret string, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vixio-Timestamp", ts)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "."))
+		mac.Write(body)
+		req.Header.Set("X-Vixio-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (j *Job) logDelivery(e webhookEvent, attempt, status int, err error) {
+	entry := gin.H{"time": time.Now().UTC().Format(time.RFC3339), "event_id": e.ID, "type": e.Type, "attempt": attempt, "status": status}
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+	line, _ := json.Marshal(entry)
+	f, ferr := os.OpenFile(j.Path("webhooks.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if ferr != nil {
+		return
+	}
+	f.Write(append(line, '\n'))
+	f.Close()
+}
+
+// jobEvents saves the handler's response as the job result and emits
+// job.completed or job.failed for requests that created a job, notifies the
+// caller's chat and email targets, then closes the job's webhook. The response is held back until then so the job's
+// timings and debug record can be added to it. Pipeline.Generate does the
+// same through completeResponse and complete.
+func jobEvents(c *gin.Context) {
+	if c.Request.Method != "POST" {
+		c.Next()
+		return
+	}
+	start := time.Now()
+	w := c.Writer
+	rec := &bodyRecorder{ResponseWriter: w}
+	c.Writer = rec
+	defer func() { c.Writer = w }() // a panicking handler's 500 must not be swallowed
+	c.Next()
+
+	body := rec.body.Bytes()
+	v, ok := c.Get("job")
+	if !ok {
+		w.Write(body)
+		return
+	}
+	job := v.(*Job)
+	var resp map[string]any
+	json.Unmarshal(body, &resp)
+	timings := job.completeResponse(resp, rec.Status(), time.Since(start))
+	if resp != nil {
+		body, _ = json.Marshal(resp)
+		delete(resp, "debug")
+	}
+	if job.stream != nil {
+		job.stream.finish(rec.Status(), body)
+	} else if !rec.detached {
+		w.Write(body)
+	}
+	job.complete(httpForm{c}, rec.Status(), resp, timings)
+}
+
+// completeResponse adds the job's timings, seed, version, sources and
+// debug record to its response resp, which may be nil.
+func (j *Job) completeResponse(resp map[string]any, status int, elapsed time.Duration) TimingReport {
+	timings := j.timings.Report(elapsed)
+	if resp == nil {
+		return timings
+	}
+	resp["timings"] = timings
+	resp["seed"] = j.seed
+	if j.retryOf != "" {
+		resp["retry_of"] = j.retryOf
+	}
+	if j.parentJob != "" && status == 200 {
+		videoURL, _ := resp["video_url"].(string)
+		if v, err := j.addVersion(videoURL); err != nil {
+			fmt.Printf("⚠️ Version not recorded: %v\n", err)
+		} else {
+			resp["version"] = v.Version
+		}
+	}
+	if sources := j.Sources(); len(sources) > 0 {
+		resp["sources"] = sources
+	}
+	if plan := j.Plan(); plan != nil {
+		resp["plan"] = plan
+	}
+	if lost := j.LostNarration(); len(lost) > 0 {
+		resp["narration_lost"] = lost
+	}
+	if similar := j.duplicate(); similar != nil {
+		resp["duplicate"] = similar
+	}
+	if report := j.debug.Report(); report != nil {
+		j.SaveDebug(report)
+		resp["debug"] = report
+	}
+	return timings
+}
+
+// complete saves the job's final response as its result, emits
+// job.completed or job.failed, notifies the caller and closes the webhook.
+func (j *Job) complete(form Form, status int, resp map[string]any, timings TimingReport) {
+	apiKey, topic := form.GetHeader("X-Api-Key"), form.PostForm("topic")
+	if status == 200 {
+		if id := strings.ToLower(strings.TrimSpace(form.PostForm("series_id"))); id != "" {
+			if err := j.addEpisode(id, topic); err != nil {
+				fmt.Printf("⚠️ Series episode not recorded: %v\n", err)
+			}
+		}
+		rememberScript(form, j, topic)
+		j.SaveResult(resp)
+		j.Emit("job.completed", resp)
+		notifyJobDone(apiKey, j.ID, topic, "completed", resp)
+	} else {
+		j.SaveResult(gin.H{"status": "failed", "job_id": j.ID, "code": status, "error": resp["error"], "timings": timings})
+		j.Emit("job.failed", gin.H{"status": status, "error": resp["error"]})
+		notifyJobDone(apiKey, j.ID, topic, "failed", resp)
+	}
+	j.closeWebhook()
+}
+
+// bodyRecorder buffers the response body; the status and headers still go
+// to the underlying writer, which sends them with the first real write.
+// Once detached (see Job.Detach) the client has its response and the
+// status is only recorded.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	status   int
+	detached bool
+}
+
+func (w *bodyRecorder) WriteHeader(code int) {
+	w.status = code
+	if !w.detached {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *bodyRecorder) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}